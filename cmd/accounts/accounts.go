@@ -0,0 +1,112 @@
+/*
+Manages the accounts of a server through its admin endpoints. The admin
+token is the one the server was started with (-admin-token), read from
+CHESSBOT_ADMIN_TOKEN unless given as flag.
+
+Usage:
+
+	accounts [flags] create <name>
+	accounts [flags] list
+	accounts [flags] delete <name>
+
+create prints the API key of the new account. The server only keeps its
+hash, so the key can't be shown again.
+*/
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/matetirpak/chessbot-playground-server/pkg/client"
+)
+
+type options struct {
+	server     string
+	adminToken string
+	command    string
+	name       string
+}
+
+func main() {
+	opts, err := parseFlags(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := run(ctx, opts, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func parseFlags(args []string) (*options, error) {
+	opts := &options{}
+	fs := flag.NewFlagSet("accounts", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: accounts [flags] create <name> | list | delete <name>\n\n")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.server, "server", "http://localhost:8080", "Base URL of the chessbot playground server")
+	fs.StringVar(&opts.adminToken, "admin-token", "", "Admin token of the server, defaults to CHESSBOT_ADMIN_TOKEN")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if opts.adminToken == "" {
+		opts.adminToken = os.Getenv("CHESSBOT_ADMIN_TOKEN")
+	}
+
+	rest := fs.Args()
+	if len(rest) > 0 {
+		opts.command = rest[0]
+	}
+	switch {
+	case opts.adminToken == "":
+		return nil, errors.New("-admin-token or CHESSBOT_ADMIN_TOKEN is required")
+	case (opts.command == "create" || opts.command == "delete") && len(rest) == 2:
+		opts.name = rest[1]
+	case opts.command == "list" && len(rest) == 1:
+	default:
+		fs.Usage()
+		return nil, errors.New("expected create <name>, list or delete <name>")
+	}
+	return opts, nil
+}
+
+func run(ctx context.Context, opts *options, out io.Writer) error {
+	c := client.New(opts.server)
+	switch opts.command {
+	case "create":
+		account, err := c.CreateAccount(ctx, opts.adminToken, opts.name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, account.Key)
+	case "list":
+		list, err := c.ListAccounts(ctx, opts.adminToken)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tCREATED")
+		for _, account := range list {
+			fmt.Fprintf(tw, "%s\t%s\n", account.Name, account.CreatedAt.Format(time.RFC3339))
+		}
+		return tw.Flush()
+	case "delete":
+		return c.DeleteAccount(ctx, opts.adminToken, opts.name)
+	}
+	return nil
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"io/fs"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,6 +15,20 @@ import (
 	"github.com/rs/cors"
 
 	_ "github.com/matetirpak/chessbot-playground-server/docs"
+	"github.com/matetirpak/chessbot-playground-server/internal/accounts"
+	"github.com/matetirpak/chessbot-playground-server/internal/api"
+	"github.com/matetirpak/chessbot-playground-server/internal/bots"
+	"github.com/matetirpak/chessbot-playground-server/internal/config"
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+	"github.com/matetirpak/chessbot-playground-server/internal/engine"
+	"github.com/matetirpak/chessbot-playground-server/internal/logging"
+	"github.com/matetirpak/chessbot-playground-server/internal/openings"
+	"github.com/matetirpak/chessbot-playground-server/internal/polyglot"
+	"github.com/matetirpak/chessbot-playground-server/internal/ratings"
+	"github.com/matetirpak/chessbot-playground-server/internal/reaper"
+	"github.com/matetirpak/chessbot-playground-server/internal/status"
+	"github.com/matetirpak/chessbot-playground-server/internal/tournament"
+	"github.com/matetirpak/chessbot-playground-server/internal/uci"
 	"github.com/matetirpak/chessbot-playground-server/pkg/server"
 	"github.com/matetirpak/chessbot-playground-server/web"
 )
@@ -30,55 +46,172 @@ import (
 
 // @BasePath  /chessserver/v1
 func main() {
-	signalChan := make(chan os.Signal, 1)
-	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	cfg, err := config.Load(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fatal("invalid configuration", "error", err)
+	}
+	if _, err := logging.Setup(cfg.LogLevel, cfg.LogFormat, os.Stderr); err != nil {
+		fatal("invalid logging configuration", "error", err)
+	}
+	slog.Info("effective configuration", cfg.LogAttrs()...)
+
+	api.TurnTimeout = cfg.TurnTimeout
+	api.RetryAfter = cfg.RetryAfter
+	api.FeatureToggles = map[string]bool{
+		"webui":       cfg.Features.WebUI,
+		"swagger":     cfg.Features.Swagger,
+		"metrics":     cfg.Features.Metrics,
+		"reaper":      cfg.Features.Reaper,
+		"singleport":  cfg.SinglePort,
+		"tls":         cfg.TLS.Enabled(),
+		"persistence": cfg.PersistencePath != "",
+	}
 
-	srvApi := initApiServer(":8080")
-	srvFrontend := initHttpServer(":8081")
+	for name, path := range cfg.Books {
+		book, err := polyglot.LoadFile(path)
+		if err != nil {
+			fatal("invalid opening book", "book", name, "error", err)
+		}
+		polyglot.Register(name, book)
+		slog.Info("registered opening book", "book", name, "entries", book.Len())
+	}
+	if cfg.EngineBook != "" {
+		book, _ := polyglot.Lookup(cfg.EngineBook)
+		engine.UseBook(book)
+	}
+	for name, path := range cfg.UCIEngines {
+		engine.Register(name, func() engine.Engine { return uci.NewPlayer(path) })
+	}
+	if cfg.Bots.File != "" {
+		botList, err := bots.LoadFile(cfg.Bots.File)
+		if err != nil {
+			fatal("invalid bots file", "error", err)
+		}
+		for _, bot := range botList {
+			bots.Register(bot)
+		}
+		slog.Info("registered bots", "bots", bots.Names())
+	}
+	for name, path := range cfg.OpeningSuites {
+		suite, err := openings.LoadFile(path)
+		if err != nil {
+			fatal("invalid opening suite", "suite", name, "error", err)
+		}
+		openings.Register(name, suite)
+		slog.Info("registered opening suite", "suite", name, "openings", len(suite))
+	}
+	api.BotServerURL = cfg.Bots.ServerURL
+	api.BotLogDir = cfg.Bots.LogDir
+	api.BotTimeout = cfg.Bots.Timeout
+	api.AdminToken = cfg.AdminToken
+	api.TokenTTL = cfg.TokenTTL
+	data.OnGameEnd = ratings.RecordGame
+	api.AccountsDir = cfg.PersistencePath
 
-	go func() {
-		log.Println("Serving API on http://localhost:8080")
-		if err := srvApi.ListenAndServe(); err != http.ErrServerClosed {
-			log.Fatalf("API server error: %v", err)
+	status.SetCheck("persistence", errors.New("not recovered yet"))
+	if cfg.PersistencePath != "" {
+		n, err := data.LoadSnapshot(cfg.PersistencePath)
+		if err != nil {
+			fatal("failed to recover persisted games", "error", err)
+		}
+		slog.Info("recovered persisted games", "games", n, "path", cfg.PersistencePath)
+		if n, err = ratings.LoadSnapshot(cfg.PersistencePath); err != nil {
+			fatal("failed to recover persisted ratings", "error", err)
+		}
+		slog.Info("recovered persisted ratings", "players", n)
+		if n, err = accounts.LoadSnapshot(cfg.PersistencePath); err != nil {
+			fatal("failed to recover persisted accounts", "error", err)
 		}
-	}()
+		slog.Info("recovered persisted accounts", "accounts", n)
+		api.ResumeEngines()
+		running, err := tournament.LoadSnapshot(cfg.PersistencePath)
+		if err != nil {
+			fatal("failed to recover persisted tournaments", "error", err)
+		}
+		api.ResumeTournaments(running)
+	}
+	status.SetCheck("persistence", nil)
 
-	go func() {
-		log.Println("Serving frontend on http://localhost:8081")
-		if err := srvFrontend.ListenAndServe(); err != http.ErrServerClosed {
-			log.Fatalf("Frontend server error: %v", err)
+	if cfg.Features.WebUI {
+		_, err := fs.Stat(webUIFS(), "index.html")
+		if err != nil {
+			slog.Warn("web UI files are missing, run go generate in tools/", "error", err)
 		}
-	}()
+		status.SetCheck("webui", err)
+	}
+
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+
+	reaperCtx, stopReaper := context.WithCancel(context.Background())
+	if cfg.Features.Reaper {
+		go reaper.Run(reaperCtx, cfg.Reaper)
+	}
+
+	tlsConfig := initTLS(cfg)
+
+	srvApi := initApiServer(cfg)
+	srvApi.TLSConfig = tlsConfig
+	if cfg.SinglePort {
+		listen(srvApi, "API and frontend")
+	} else {
+		listen(srvApi, "API")
+	}
+
+	var srvFrontend *http.Server
+	if cfg.Features.WebUI && !cfg.SinglePort {
+		srvFrontend = initHttpServer(cfg.WebAddr)
+		srvFrontend.TLSConfig = tlsConfig
+		listen(srvFrontend, "frontend")
+	}
+
+	if cfg.PersistencePath != "" {
+		api.ResumeBots()
+	}
+
+	var srvRedirect *http.Server
+	if cfg.TLS.RedirectAddr != "" {
+		srvRedirect = initRedirectServer(cfg.TLS.RedirectAddr, cfg.APIAddr)
+		listen(srvRedirect, "HTTPS redirect")
+	}
 
 	// Wait for termination signal (Ctrl+C)
 	sig := <-signalChan
-	log.Printf("%s signal caught", sig)
-	log.Println("Shutdown signal received, shutting down servers...")
+	slog.Info("shutdown signal received, shutting down servers", "signal", sig.String())
 
-	closeHttp(srvFrontend)
-	closeHttp(srvApi)
+	stopReaper()
+	shutdown(cfg, srvApi, srvFrontend, srvRedirect)
 
-	log.Println("Servers exited.")
+	slog.Info("servers exited")
 }
 
-func initApiServer(port string) *http.Server {
-	log.Printf("Server started")
+func initApiServer(cfg *config.Config) *http.Server {
+	slog.Info("server started")
 
-	router := server.NewRouter()
+	opts := server.Options{
+		Swagger: cfg.Features.Swagger,
+		Metrics: cfg.Features.Metrics,
+	}
+	if cfg.SinglePort && cfg.Features.WebUI {
+		opts.WebUI = webUIFS()
+	}
+	router := server.NewRouter(opts)
 
 	c := cors.New(cors.Options{
-		AllowedOrigins: []string{
-			"http://localhost:8081",
-		},
+		AllowedOrigins:   cfg.CORSOrigins,
 		AllowedMethods:   []string{"DELETE", "GET", "POST", "PUT", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", api.APIKeyHeader, logging.RequestIDHeader},
+		ExposedHeaders:   []string{logging.RequestIDHeader},
 		AllowCredentials: true,
 	})
 
 	handler := c.Handler(router)
 
 	srv := &http.Server{
-		Addr:    port,
+		Addr:    cfg.APIAddr,
 		Handler: handler,
 	}
 
@@ -86,35 +219,69 @@ func initApiServer(port string) *http.Server {
 }
 
 func initHttpServer(port string) *http.Server {
+	srv := &http.Server{
+		Addr:    port,
+		Handler: server.WebUIHandler(webUIFS()),
+	}
+
+	return srv
+}
+
+func webUIFS() fs.FS {
 	subFS, err := fs.Sub(web.EmbeddedWebFiles, "webui/src")
 	if err != nil {
-		log.Printf("Error when trying to access frontend subdirectory: %v", err)
-		panic(err)
+		fatal("failed to access frontend subdirectory", "error", err)
 	}
+	return subFS
+}
 
-	fs := http.FileServer(http.FS(subFS))
-
-	handler := func(w http.ResponseWriter, r *http.Request) {
-		// Add necessary headers for SharedArrayBuffer support
-		w.Header().Set("Cross-Origin-Opener-Policy", "same-origin")
-		w.Header().Set("Cross-Origin-Embedder-Policy", "require-corp")
+// Shuts down in stages: stop accepting sessions and notify waiting clients,
+// let them disconnect, persist the games and finally close the listeners.
+func shutdown(cfg *config.Config, servers ...*http.Server) {
+	status.SetCheck("shutdown", errors.New("server is shutting down"))
+	api.Drain()
 
-		// Serve the requested file
-		fs.ServeHTTP(w, r)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.DrainTimeout)
+	if err := api.WaitForClients(ctx); err != nil {
+		slog.Warn("drain incomplete", "error", err)
 	}
+	cancel()
 
-	srv := &http.Server{
-		Addr:    port,
-		Handler: http.HandlerFunc(handler),
+	api.Freeze()
+	bots.StopAll(2 * time.Second)
+	if cfg.PersistencePath != "" {
+		if err := data.SaveSnapshot(cfg.PersistencePath); err != nil {
+			slog.Error("failed to persist games", "error", err)
+		} else {
+			slog.Info("persisted games", "path", cfg.PersistencePath)
+		}
+		if err := tournament.SaveSnapshot(cfg.PersistencePath); err != nil {
+			slog.Error("failed to persist tournaments", "error", err)
+		}
+		if err := ratings.SaveSnapshot(cfg.PersistencePath); err != nil {
+			slog.Error("failed to persist ratings", "error", err)
+		}
+		if err := accounts.SaveSnapshot(cfg.PersistencePath); err != nil {
+			slog.Error("failed to persist accounts", "error", err)
+		}
 	}
 
-	return srv
+	for _, srv := range servers {
+		if srv != nil {
+			closeHttp(srv, cfg.ShutdownTimeout)
+		}
+	}
 }
 
-func closeHttp(srv *http.Server) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+func closeHttp(srv *http.Server, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Printf("Server shutdown failed: %+v", err)
+		slog.Error("server shutdown failed", "addr", srv.Addr, "error", err)
 	}
 }
+
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
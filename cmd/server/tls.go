@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/matetirpak/chessbot-playground-server/internal/certs"
+	"github.com/matetirpak/chessbot-playground-server/internal/config"
+)
+
+// Returns the TLS configuration shared by all servers, nil if TLS is disabled.
+func initTLS(cfg *config.Config) *tls.Config {
+	if !cfg.TLS.Enabled() {
+		return nil
+	}
+
+	var cert tls.Certificate
+	var err error
+	if cfg.TLS.SelfSigned {
+		cert, err = certs.LoadOrCreateSelfSigned(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.Hosts)
+		if err == nil {
+			slog.Info("using self-signed certificate", "path", cfg.TLS.CertFile)
+		}
+	} else {
+		cert, err = certs.Load(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+	}
+	if err != nil {
+		fatal("failed to load TLS certificate", "error", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// Redirects plain HTTP requests to the HTTPS server listening on httpsAddr.
+func initRedirectServer(addr string, httpsAddr string) *http.Server {
+	_, httpsPort, err := net.SplitHostPort(httpsAddr)
+	if err != nil {
+		fatal("invalid API address", "addr", httpsAddr, "error", err)
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		target := "https://" + net.JoinHostPort(host, httpsPort) + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	}
+
+	return &http.Server{
+		Addr:    addr,
+		Handler: http.HandlerFunc(handler),
+	}
+}
+
+// Binds the address of a server and serves in the background, with or
+// without TLS depending on its TLSConfig. Once listen returns, connections
+// are accepted.
+func listen(srv *http.Server, name string) {
+	scheme := "http"
+	if srv.TLSConfig != nil {
+		scheme = "https"
+	}
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		fatal(name+" server error", "error", err)
+	}
+	slog.Info("serving "+name, "url", scheme+"://"+srv.Addr)
+
+	go func() {
+		var err error
+		if srv.TLSConfig != nil {
+			err = srv.ServeTLS(ln, "", "")
+		} else {
+			err = srv.Serve(ln)
+		}
+		if err != http.ErrServerClosed {
+			fatal(name+" server error", "error", err)
+		}
+	}()
+}
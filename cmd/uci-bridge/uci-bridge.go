@@ -0,0 +1,251 @@
+/*
+Connects a UCI engine to a game on the server. The bridge joins or creates
+a session, waits for its turn, sends the game to the engine as
+"position fen ... moves ..." and plays the engine's bestmove. The FEN is
+the position of the bridge's first turn, so games from openings work too.
+
+Usage:
+
+	uci-bridge [flags] -engine /path/to/engine [-- engine args]
+*/
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/matetirpak/chessbot-playground-server/internal/engine"
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+	"github.com/matetirpak/chessbot-playground-server/internal/logging"
+	"github.com/matetirpak/chessbot-playground-server/internal/uci"
+	"github.com/matetirpak/chessbot-playground-server/pkg/client"
+)
+
+type options struct {
+	server     string
+	enginePath string
+	engineArgs []string
+	setOptions []string // name=value pairs for "setoption"
+	boardID    int
+	password   string
+	name       string
+	color      string
+	player     string
+	apiKey     string
+	moveTime   time.Duration
+	depth      int
+	logLevel   string
+}
+
+func main() {
+	opts, err := parseFlags(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if _, err := logging.Setup(opts.logLevel, "text", os.Stderr); err != nil {
+		fatal("invalid logging configuration", "error", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	result, err := run(ctx, opts)
+	if err != nil {
+		fatal("bridge stopped", "error", err)
+	}
+	fmt.Println(result)
+}
+
+func parseFlags(args []string) (*options, error) {
+	opts := &options{}
+	fs := flag.NewFlagSet("uci-bridge", flag.ContinueOnError)
+	fs.StringVar(&opts.server, "server", "http://localhost:8080", "Base URL of the chessbot playground server")
+	fs.StringVar(&opts.enginePath, "engine", "", "Path of the UCI engine executable, arguments follow after --")
+	fs.Func("option", "Engine option as name=value, can be repeated", func(value string) error {
+		if !strings.Contains(value, "=") {
+			return fmt.Errorf("%q is not of the form name=value", value)
+		}
+		opts.setOptions = append(opts.setOptions, value)
+		return nil
+	})
+	fs.IntVar(&opts.boardID, "board", 0, "Board ID of the session to join, 0 creates a new session")
+	fs.StringVar(&opts.password, "password", "", "Password of the session to join")
+	fs.StringVar(&opts.name, "name", "uci-bridge", "Name of a newly created session")
+	fs.StringVar(&opts.color, "color", "w", "Color to play, 'w' or 'b'")
+	fs.StringVar(&opts.player, "player", "", "Player name the engine plays under; rated sessions need -api-key instead")
+	fs.StringVar(&opts.apiKey, "api-key", "", "API key of the account the engine plays as, defaults to CHESSBOT_API_KEY")
+	fs.DurationVar(&opts.moveTime, "movetime", time.Second, "Time per move, 0 to search by depth only")
+	fs.IntVar(&opts.depth, "depth", 0, "Maximum search depth, 0 for no limit")
+	fs.StringVar(&opts.logLevel, "log-level", "info", "Log level: debug, info, warn or error")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	opts.engineArgs = fs.Args()
+	if opts.apiKey == "" {
+		opts.apiKey = os.Getenv("CHESSBOT_API_KEY")
+	}
+
+	switch {
+	case opts.enginePath == "":
+		return nil, errors.New("-engine is required")
+	case opts.color != "w" && opts.color != "b":
+		return nil, fmt.Errorf("invalid color %q, use 'w' or 'b'", opts.color)
+	case opts.boardID != 0 && opts.password == "":
+		return nil, errors.New("-password is required to join an existing session")
+	case opts.moveTime <= 0 && opts.depth <= 0:
+		return nil, errors.New("either -movetime or -depth has to be positive")
+	}
+	return opts, nil
+}
+
+// Plays one game and returns its result, e.g. "1-0".
+func run(ctx context.Context, opts *options) (string, error) {
+	proc, err := uci.Start(ctx, opts.enginePath, opts.engineArgs...)
+	if err != nil {
+		return "", err
+	}
+	defer proc.Close()
+	for _, option := range opts.setOptions {
+		name, value, _ := strings.Cut(option, "=")
+		if err := proc.SetOption(name, value); err != nil {
+			return "", err
+		}
+	}
+	if err := proc.NewGame(ctx); err != nil {
+		return "", err
+	}
+	slog.Info("engine started", "engine", proc.Name, "author", proc.Author)
+
+	c := client.New(opts.server)
+	c.APIKey = opts.apiKey
+	session := client.Session{BoardID: int32(opts.boardID), Password: opts.password}
+	if opts.boardID == 0 {
+		if session, err = c.CreateSession(ctx, opts.name); err != nil {
+			return "", fmt.Errorf("creating session: %w", err)
+		}
+		slog.Info("session created", "boardid", session.BoardID, "password", session.Password)
+	}
+	player, err := c.JoinAs(ctx, session, opts.color, opts.player)
+	if err != nil {
+		return "", fmt.Errorf("joining session %d: %w", session.BoardID, err)
+	}
+	slog.Info("joined session", "boardid", player.BoardID, "color", player.Color)
+
+	b := &bridge{engine: proc, params: uci.GoParams{MoveTime: opts.moveTime, Depth: opts.depth}}
+	final, err := client.Run(ctx, c, player, b)
+	if err != nil {
+		return "", err
+	}
+	return result(final.Winner), nil
+}
+
+// Relays positions to the engine and its moves back to the server.
+type bridge struct {
+	engine   *uci.Engine
+	params   uci.GoParams
+	startFEN string   // Position of the bridge's first turn
+	moves    []string // Moves since startFEN in UCI notation
+}
+
+func (b *bridge) Move(ctx context.Context, state client.BoardState) (string, error) {
+	color := rune(state.TurnColor[0])
+	pos := gl.BoardState{
+		Board:          state.Board,
+		LastMove:       state.LastMove,
+		WhiteKingPos:   state.WhiteKingPos,
+		BlackKingPos:   state.BlackKingPos,
+		WhiteKingMoved: state.WhiteKingMoved,
+		BlackKingMoved: state.BlackKingMoved,
+		Winner:         state.Winner,
+		TurnColor:      state.TurnColor,
+		EnPassant:      state.EnPassant,
+	}
+	if b.startFEN == "" {
+		// The game may start from an opening, so the engine gets the
+		// position of the first turn rather than the start position
+		b.startFEN = gl.ToFEN(&pos, 1)
+	} else {
+		// Every later turn follows exactly one opponent move
+		opponent := 'b'
+		if color == 'b' {
+			opponent = 'w'
+		}
+		last, err := gl.StringToMoveStruct(state.LastMove, opponent)
+		if err != nil {
+			return "", fmt.Errorf("parsing opponent move %q: %w", state.LastMove, err)
+		}
+		b.moves = append(b.moves, uci.MoveToUCI(&last))
+	}
+
+	// The server knows neither castling nor en passant captures, so the
+	// engine may only choose among the moves the server accepts.
+	legal, err := engine.LegalMoves(&pos)
+	if err != nil {
+		return "", err
+	}
+	if len(legal) == 0 {
+		return "", engine.ErrNoLegalMoves
+	}
+	params := b.params
+	for i := range legal {
+		params.SearchMoves = append(params.SearchMoves, uci.MoveToUCI(&legal[i]))
+	}
+
+	if err := b.engine.Position(b.startFEN, b.moves); err != nil {
+		return "", err
+	}
+	search, err := b.engine.Go(ctx, params)
+	if err != nil {
+		return "", err
+	}
+	move, err := uci.MoveFromUCI(search.BestMove, color)
+	if err != nil {
+		return "", err
+	}
+	b.moves = append(b.moves, uci.MoveToUCI(&move))
+
+	slog.Info("engine move", "move", search.BestMove, "depth", search.Info.Depth, "score", scoreString(search.Info))
+	return gl.MoveToString(&move), nil
+}
+
+func scoreString(info uci.Info) string {
+	switch {
+	case !info.HasScore:
+		return ""
+	case info.IsMate:
+		return fmt.Sprintf("mate %d", info.Mate)
+	default:
+		return fmt.Sprintf("cp %d", info.ScoreCP)
+	}
+}
+
+// Returns the game result in PGN notation.
+func result(winner string) string {
+	switch winner {
+	case "w":
+		return "1-0"
+	case "b":
+		return "0-1"
+	case "r":
+		return "1/2-1/2"
+	default:
+		return "*"
+	}
+}
+
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
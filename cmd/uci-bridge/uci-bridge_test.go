@@ -0,0 +1,179 @@
+/*
+Unittest for the uci-bridge command.
+*/
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matetirpak/chessbot-playground-server/pkg/client"
+	"github.com/matetirpak/chessbot-playground-server/pkg/server"
+)
+
+// The test binary doubles as scripted UCI engine, see TestMain.
+const (
+	helperEnv    = "UCI_BRIDGE_TEST_ENGINE"
+	positionsEnv = "UCI_BRIDGE_TEST_POSITIONS"
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) == "" {
+		os.Exit(m.Run())
+	}
+	scriptedEngine()
+	os.Exit(0)
+}
+
+// Answers the handshake, appends "position" commands to the file named by
+// positionsEnv and plays the first of the "searchmoves".
+func scriptedEngine() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "uci":
+			fmt.Println("id name ScriptedEngine")
+			fmt.Println("id author chessbot")
+			fmt.Println("uciok")
+		case "isready":
+			fmt.Println("readyok")
+		case "position":
+			f, err := os.OpenFile(os.Getenv(positionsEnv), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+			if err == nil {
+				fmt.Fprintln(f, line)
+				f.Close()
+			}
+		case "go":
+			move := "0000"
+			for i, field := range fields {
+				if field == "searchmoves" && i+1 < len(fields) {
+					move = fields[i+1]
+					break
+				}
+			}
+			fmt.Println("info depth 1 score cp 10 pv " + move)
+			fmt.Println("bestmove " + move)
+		case "quit":
+			return
+		}
+	}
+}
+
+// Returns the path of the scripted engine and of the file it records
+// positions in.
+func scriptedEnginePath(t *testing.T) (string, string) {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	positions := filepath.Join(t.TempDir(), "positions")
+	t.Setenv(helperEnv, "1")
+	t.Setenv(positionsEnv, positions)
+	return exe, positions
+}
+
+func TestParseFlags(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{}, "-engine is required"},
+		{[]string{"-engine", "x", "-color", "r"}, "invalid color"},
+		{[]string{"-engine", "x", "-board", "3"}, "-password is required"},
+		{[]string{"-engine", "x", "-movetime", "0"}, "either -movetime or -depth"},
+		{[]string{"-engine", "x", "-option", "Hash"}, "not of the form name=value"},
+	} {
+		if _, err := parseFlags(tc.args); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%v: expected error containing %q, got %v", tc.args, tc.err, err)
+		}
+	}
+
+	opts, err := parseFlags([]string{"-engine", "stockfish", "-option", "Hash=64", "-depth", "5", "--", "-v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.enginePath != "stockfish" || opts.depth != 5 || len(opts.setOptions) != 1 || len(opts.engineArgs) != 1 {
+		t.Errorf("unexpected options %+v", opts)
+	}
+}
+
+// Plays the bridge against a scripted opponent, which answers two moves
+// and forfeits, and checks that the engine got the whole game each turn.
+func TestBridgePlaysGame(t *testing.T) {
+	exe, positions := scriptedEnginePath(t)
+	srv := httptest.NewServer(server.NewRouter(server.Options{}))
+	t.Cleanup(srv.Close)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	c := client.New(srv.URL)
+	session, err := c.CreateSession(ctx, "bridge test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opponent, err := c.Join(ctx, session, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for _, move := range []string{"g8 f6", "f6 g8"} {
+			if c.WaitForTurn(ctx, opponent) != nil || c.Move(ctx, opponent, move) != nil {
+				return
+			}
+		}
+		if c.WaitForTurn(ctx, opponent) == nil {
+			c.Forfeit(ctx, opponent)
+		}
+	}()
+
+	opts := &options{
+		server:     srv.URL,
+		enginePath: exe,
+		boardID:    int(session.BoardID),
+		password:   session.Password,
+		color:      "w",
+		moveTime:   10 * time.Millisecond,
+	}
+	result, err := run(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "1-0" {
+		t.Errorf("expected 1-0 after the forfeit, got %s", result)
+	}
+
+	content, err := os.ReadFile(positions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a position for each of the 3 turns, got %q", lines)
+	}
+	start := "position fen rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w"
+	if !strings.HasPrefix(lines[0], start) || strings.Contains(lines[0], "moves") {
+		t.Errorf("expected the start position first, got %q", lines[0])
+	}
+	for i, suffix := range []string{"g8f6", "f6g8"} {
+		line := lines[i+1]
+		if !strings.HasPrefix(line, lines[0]+" moves ") || !strings.HasSuffix(line, " "+suffix) {
+			t.Errorf("expected turn %d to send the moves up to %s, got %q", i+2, suffix, line)
+		}
+		if moves := strings.Fields(strings.SplitN(line, " moves ", 2)[1]); len(moves) != 2*(i+1) {
+			t.Errorf("expected %d moves at turn %d, got %v", 2*(i+1), i+2, moves)
+		}
+	}
+}
@@ -16,7 +16,7 @@ const docTemplate = `{
         },
         "license": {
             "name": "MIT",
-            "url": "https://github.com/matetirpak/chessbot-playground/blob/main/LICENSE"
+            "url": "https://github.com/matetirpak/chessbot-playground-server/blob/main/LICENSE"
         },
         "version": "{{.Version}}"
     },
@@ -30,7 +30,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Requesting the board state requires the 'moveidx' parameter. Requesting possible moves of a piece requires 'row' and 'col'. 'turn' holds the request till it's the players turn.",
+                "description": "Requesting the board state requires the 'moveidx' parameter. Requesting possible moves of a piece requires 'row' and 'col'. 'turn' holds the request till it's the players turn and accepts the session password as well as the player token.",
                 "consumes": [
                     "application/json"
                 ],
@@ -88,33 +88,45 @@ const docTemplate = `{
                         "schema": {}
                     },
                     "400": {
-                        "description": "Bad request (invalid parameters or out-of-range index)",
+                        "description": "Bad request (invalid parameters or out-of-range index, or game ended while waiting for turn)",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized (missing/invalid token)",
+                        "description": "Unauthorized (missing/invalid/expired token)",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
                         }
                     },
                     "404": {
                         "description": "Not found – Game does not exist",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
                         }
                     },
                     "408": {
                         "description": "Timeout waiting for turn",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "410": {
+                        "description": "Game was removed while waiting for turn",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
                         }
                     },
                     "500": {
                         "description": "Internal server error during move generation",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server restarting, see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
                         }
                     }
                 }
@@ -125,7 +137,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Applies a specified (valid) move, random move, or forfeits the game. Applying a specific move requires the move variable, e.g. \"e2 e4\".",
+                "description": "Applies a specified (valid) move, random move, or forfeits the game. Applying a specific move requires the move variable, e.g. \"e2 e4\".\nPromotions append the piece to the move, e.g. \"e7 e8q\" (q, r, b or k for knight).\nRejected moves return the legal moves of the moved piece in the error details.",
                 "consumes": [
                     "application/json"
                 ],
@@ -157,25 +169,63 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad request (invalid parameters, move, or game state)",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
                         }
                     },
                     "401": {
                         "description": "Unauthorized (missing or invalid token)",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "403": {
+                        "description": "The piece to be moved is not owned",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
                         }
                     },
                     "404": {
                         "description": "Not found – Game does not exist",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "422": {
+                        "description": "Illegal move (no piece, own piece target, illegal pattern, king in check, promotion)",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
                         }
                     },
                     "500": {
                         "description": "Internal server error during move processing",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server restarting, see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v1/info": {
+            "get": {
+                "description": "Returns the build version, uptime, supported API versions, enabled features and the number of active and finished games.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Server information",
+                "responses": {
+                    "200": {
+                        "description": "Server information",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespInfo"
                         }
                     }
                 }
@@ -206,7 +256,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Registers a player (white or black) to an existing game session using the board ID and a session password.",
+                "description": "Registers a player (white or black) to an existing game session using the board ID and a session password.\nWith 'engine' set, a built-in engine (\"random\", \"greedy\" or \"alphabeta\") takes the seat and plays automatically; the seat has no token.\nWith 'bot' set, the server launches that bot of its bots file for the seat; only the bot gets the token.\n'player' is the name the player is shown under. Rated sessions need an account's API key, names alone are not rated.\nWith an API key, the account takes the seat under its name.",
                 "consumes": [
                     "application/json"
                 ],
@@ -226,6 +276,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.ReqPutSessions"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key of the joining account",
+                        "name": "X-API-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -236,33 +292,39 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad request – Invalid JSON or color value",
+                        "description": "Bad request – Invalid JSON, color value, unknown engine or bot, invalid player name",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
                         }
                     },
                     "401": {
-                        "description": "Unauthorized – Missing or invalid bearer token",
+                        "description": "Unauthorized – Missing or invalid bearer token or API key",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
                         }
                     },
                     "403": {
-                        "description": "Forbidden – Game is full or color already taken",
+                        "description": "Forbidden – Game is full, color already taken or player name belongs to an account",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
                         }
                     },
                     "404": {
                         "description": "Not found – Game session does not exist",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server restarting, see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Initializes a new session in the server. The response contains an ID and password.",
+                "description": "Initializes a new session in the server. The response contains an ID and password.\n'white' and 'black' launch bots of the server's bots file as players.\nThe results of rated sessions update the ratings of the players, see /chessserver/v2/ratings.",
                 "consumes": [
                     "application/json"
                 ],
@@ -292,9 +354,15 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad request (invalid JSON body)",
+                        "description": "Bad request (invalid JSON body or unknown bot)",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server restarting, see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
                         }
                     }
                 }
@@ -337,97 +405,2224 @@ const docTemplate = `{
                     "400": {
                         "description": "Bad request (invalid JSON body)",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
                         }
                     },
                     "401": {
                         "description": "Unauthorized (missing or invalid session token)",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
                         }
                     },
                     "404": {
                         "description": "Not found – Game session does not exist",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server restarting, see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "api.GameNameAndID": {
-            "type": "object",
-            "properties": {
-                "boardid": {
-                    "type": "integer"
-                },
-                "name": {
-                    "type": "string"
-                }
-            }
         },
-        "api.ReqDeleteSessions": {
-            "type": "object",
-            "properties": {
-                "boardid": {
-                    "type": "integer"
+        "/chessserver/v2/accounts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "accounts"
+                ],
+                "summary": "Lists all accounts",
+                "responses": {
+                    "200": {
+                        "description": "All accounts",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespAccounts"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "403": {
+                        "description": "Account administration is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
                 }
-            }
-        },
-        "api.ReqPostSessions": {
-            "type": "object",
-            "properties": {
-                "name": {
-                    "type": "string"
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Creates an account with an API key. The key is only returned here; the server keeps a hash of it.\nThe name becomes the account's player name: clients with the key are rated under it, others can't join with it anymore.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "accounts"
+                ],
+                "summary": "Creates an account",
+                "parameters": [
+                    {
+                        "description": "Name of the account",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ReqPostAccount"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Account with its API key",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespPostAccount"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON body or name",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "403": {
+                        "description": "Account administration is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "409": {
+                        "description": "Account already exists",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
                 }
             }
         },
-        "api.ReqPutGame": {
-            "type": "object",
-            "properties": {
-                "boardid": {
-                    "type": "integer"
-                },
-                "color": {
-                    "type": "string"
-                },
-                "move": {
-                    "type": "string"
-                },
-                "reqtype": {
-                    "description": "\"forfeit\" \"move\" \"randommove\"",
-                    "type": "string"
+        "/chessserver/v2/accounts/{name}": {
+            "get": {
+                "description": "Returns the account with its rating. Its games are listed by /chessserver/v2/games?player=\u003cname\u003e.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "accounts"
+                ],
+                "summary": "Returns an account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Account name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Account",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespAccount"
+                        }
+                    },
+                    "404": {
+                        "description": "Account does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
                 }
-            }
-        },
-        "api.ReqPutSessions": {
-            "type": "object",
-            "properties": {
-                "boardid": {
-                    "type": "integer"
-                },
-                "color": {
-                    "type": "string"
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes an account, its key stops working. The rating is kept and the name can be taken again.",
+                "tags": [
+                    "accounts"
+                ],
+                "summary": "Deletes an account",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Account name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Deleted"
+                    },
+                    "401": {
+                        "description": "Missing or invalid admin token",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "403": {
+                        "description": "Account administration is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Account does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
                 }
             }
         },
-        "api.RespGetSessions": {
-            "type": "object",
-            "properties": {
-                "games": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/api.GameNameAndID"
+        "/chessserver/v2/games": {
+            "get": {
+                "description": "Lists all games, with 'player' only the games of that player, e.g. an account.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Lists all games",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player name",
+                        "name": "player",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "All games",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespGames"
+                        }
                     }
                 }
-            }
-        },
-        "api.RespPostSessions": {
-            "type": "object",
-            "properties": {
-                "boardid": {
+            },
+            "post": {
+                "description": "Creates a game. The password is required to join players and to delete the game.\n'white' and 'black' launch bots of the server's bots file as players.\nThe results of rated games update the ratings of the players, see /ratings.\nWith an API key, the account can use its key instead of the password for this game.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Creates a game",
+                "parameters": [
+                    {
+                        "description": "Name of the game",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ReqPostGame"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key of the creating account",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Game ID and password, Location header points to the game",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespPostGame"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request (invalid JSON body or unknown bot)",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server restarting, see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/games/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Returns a game",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Game",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespGame"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid game ID",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Game does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Deletes a game",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid game password",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Game does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server restarting, see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/games/{id}/book-moves": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Looks up a position of the game in a Polyglot opening book of the server and returns the book moves with their weights, the most weighted first.\nAn empty list means the position is out of book. The book can be omitted if the server has only one.\nRequires the game password or a player token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Returns the book moves of a position",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of the book",
+                        "name": "book",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Position after this many plies, default the latest",
+                        "name": "ply",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Book moves",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespBookMoves"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid game ID, ply or unknown book",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Game or position does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/games/{id}/legal-moves": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the legal moves of the piece on the given square, or of the side to move if no square is given.\nRequires the game password or a player token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Returns legal moves",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Square of the piece, e.g. 'e2'",
+                        "name": "square",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Legal moves",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespLegalMoves"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid game ID or square",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Game does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error during move generation",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/games/{id}/moves": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Requires the game password or a player token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Returns the played moves",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Played moves",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespMoves"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid game ID",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Game does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Plays a move for the color of the player token, e.g. \"e2 e4\". Promotions append the piece, e.g. \"e7 e8q\".\nWith \"random\" set, a random legal move is played.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Plays a move",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Move",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ReqPostMove"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Applied move, Location header points to the new position",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespPlayedMove"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid game ID, JSON body or move format",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid player token",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "403": {
+                        "description": "The piece to be moved is not owned",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Game does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "409": {
+                        "description": "Game not started, already ended or not the player's turn",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "422": {
+                        "description": "Illegal move, details contain the legal moves of the piece",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server restarting, see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/games/{id}/players/{color}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Registers the player of the given color. The game starts once both players joined.\nWith 'engine' set, a built-in engine takes the seat and plays automatically; the seat has no token.\nWith 'bot' set, the server launches that bot of its bots file for the seat; only the bot gets the token.\n'player' is the name the player is shown under. Rated games need an account's API key, names alone are not rated. Engines and bots are rated under their own names.\nWith an API key, the account takes the seat under its name and can play with the key instead of the token. The creator's key replaces the password.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Joins a game as a player",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Color ('w' or 'b')",
+                        "name": "color",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Built-in engine, e.g. 'alphabeta'",
+                        "name": "engine",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bot of the bots file",
+                        "name": "bot",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Rating identity of the player",
+                        "name": "player",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key of the joining account",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Player token",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespPutPlayer"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid game ID, color, engine, bot or player name",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid game password or API key",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "403": {
+                        "description": "Player name belongs to an account",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Game does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "409": {
+                        "description": "Color already taken",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server restarting, see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "The player of the given color gives up, the opponent wins.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Forfeits the game",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Color ('w' or 'b')",
+                        "name": "color",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Forfeited",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid game ID or color",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid player token",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Game or player does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "409": {
+                        "description": "Game has already ended",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server restarting, see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/games/{id}/players/{color}/token": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Issues a new token for the player of the given color and revokes the previous one, e.g. after it expired or leaked.\nRequires the current token of the color, which may have expired for as long again as it lived, or the API key of the account playing the color. The game password is not accepted, the opponent knows it too.\nSeats of engines and bots are played by the server and keep their tokens.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Regenerates a player token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Color ('w' or 'b')",
+                        "name": "color",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key of the account playing the color",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "New player token",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespPutPlayer"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid game ID or color",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid player token or API key",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Game or player does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "409": {
+                        "description": "Seat is played by the server",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server restarting, see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/games/{id}/positions/{ply}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Position 0 is the starting position, position n the one after the n-th move. \"latest\" returns the current position.\nRequires the game password or a player token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Returns a position of the game",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ply or 'latest'",
+                        "name": "ply",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Position",
+                        "schema": {
+                            "$ref": "#/definitions/game_logic.BoardState"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid game ID or ply",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid token",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Game or position does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/games/{id}/turn": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Holds the request until it's the turn of the player token's color and returns the game.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "v2"
+                ],
+                "summary": "Waits for the player's turn",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Game ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Game, it's the player's turn",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespGame"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid game ID",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid player token",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Game does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "408": {
+                        "description": "Timeout waiting for turn",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "409": {
+                        "description": "Game has ended",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "410": {
+                        "description": "Game was removed while waiting for turn",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server restarting, see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/matchmaking": {
+            "post": {
+                "description": "Asks for a game against another bot. Tickets are paired with the oldest waiting ticket of the same move time and rated flag whose rating is within the requested range, and vice versa.\nThe server then creates the game with random colors and seats both bots. Poll the ticket with its secret to get the seat; tickets nobody polls for a minute are dropped, matched ones a minute after the match.\nWith 'wait', the request itself waits up to that long for a match, e.g. \"30s\".",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matchmaking"
+                ],
+                "summary": "Enters the matchmaking queue",
+                "parameters": [
+                    {
+                        "description": "Time control and opponent rating range",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ReqPostMatchmaking"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "API key of an account, which plays under its name",
+                        "name": "X-API-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Ticket, Location header points to it",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespTicket"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON body, player name, move time or rating range",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "403": {
+                        "description": "Player name belongs to an account",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server restarting, see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/matchmaking/{ticket}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the ticket with the seat once matched. With 'wait', the request waits up to that long for a match (long-poll), e.g. \"30s\", at most a minute.\nRequires the secret the ticket was created with.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "matchmaking"
+                ],
+                "summary": "Returns a matchmaking ticket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket ID",
+                        "name": "ticket",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Time to wait for a match",
+                        "name": "wait",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Ticket",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespTicket"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid wait",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid ticket secret",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Ticket does not exist or expired",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server restarting, see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Requires the secret the ticket was created with.",
+                "tags": [
+                    "matchmaking"
+                ],
+                "summary": "Leaves the matchmaking queue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Ticket ID",
+                        "name": "ticket",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Ticket removed"
+                    },
+                    "401": {
+                        "description": "Missing or invalid ticket secret",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Ticket does not exist or expired",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "409": {
+                        "description": "Ticket was already paired",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/ratings": {
+            "get": {
+                "description": "Glicko-2 ratings of all players of rated games, highest first. Engines are listed as \"engine:\u003cname\u003e\", bots as \"bot:\u003cname\u003e\", accounts under their names with 'account' set.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ratings"
+                ],
+                "summary": "Returns the leaderboard",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only players with at least this many games",
+                        "name": "mingames",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Leaderboard",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespLeaderboard"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid mingames",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/ratings/{player}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ratings"
+                ],
+                "summary": "Returns the rating of a player",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Player name, e.g. 'bot:mybot'",
+                        "name": "player",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rating, rank is omitted",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespRating"
+                        }
+                    },
+                    "404": {
+                        "description": "Player has no rated games",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/tournaments": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tournaments"
+                ],
+                "summary": "Lists all tournaments",
+                "responses": {
+                    "200": {
+                        "description": "All tournaments",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespTournaments"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Schedules the games of the format between the participants and starts playing them.\nParticipants are built-in engines or bots of the server's bots file. The password is required to cancel the tournament.\nThe sprt format tests the first of two participants against the second in pairs of games until the SPRT accepts H0 or H1.\nWith an opening suite, the games of each pairing start from the same opening with swapped colors.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tournaments"
+                ],
+                "summary": "Starts a tournament",
+                "parameters": [
+                    {
+                        "description": "Format, participants and time control",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ReqPostTournament"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Tournament ID and password, Location header points to the tournament",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespPostTournament"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON body, format, participant, opening suite or time control",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server restarting, see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/tournaments/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tournaments"
+                ],
+                "summary": "Returns a tournament with its standings",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tournament ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tournament",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespTournament"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid tournament ID",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Tournament does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stops scheduling games and removes the games in progress. Finished games keep counting.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tournaments"
+                ],
+                "summary": "Cancels a tournament",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tournament ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Cancelled",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid tournament password",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Tournament does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "409": {
+                        "description": "Tournament has already ended",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "503": {
+                        "description": "Server restarting, see Retry-After header",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/tournaments/{id}/crosstable": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tournaments"
+                ],
+                "summary": "Returns the crosstable of a tournament",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tournament ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Crosstable",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespCrosstable"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid tournament ID",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Tournament does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/tournaments/{id}/games": {
+            "get": {
+                "description": "Games in schedule order with their boards and results.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tournaments"
+                ],
+                "summary": "Lists the games of a tournament",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tournament ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Games",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespTournamentGames"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid tournament ID",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Tournament does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/chessserver/v2/tournaments/{id}/standings": {
+            "get": {
+                "description": "Participants ordered by points, then Buchholz, Sonneborn-Berger and wins. A win counts 1, a draw 0.5 and a bye as much as winning all games of a pairing.\nThe round parameter returns the standings after that round.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tournaments"
+                ],
+                "summary": "Returns the standings of a tournament",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tournament ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Count only the rounds up to this one",
+                        "name": "round",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Standings",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.RespStanding"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid tournament ID or round",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    },
+                    "404": {
+                        "description": "Tournament does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespError"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Returns 200 as long as the server process is able to handle requests.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "Server is alive",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespHealth"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Reports whether persisted games were recovered and the web UI files were loaded. Returns 503 while a check fails or the server shuts down.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "Server is ready",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespHealth"
+                        }
+                    },
+                    "503": {
+                        "description": "At least one check failed",
+                        "schema": {
+                            "$ref": "#/definitions/api.RespHealth"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.GameNameAndID": {
+            "type": "object",
+            "properties": {
+                "boardid": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.InfoFeatures": {
+            "type": "object",
+            "properties": {
+                "books": {
+                    "description": "Polyglot opening books",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "bots": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "clocks": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "engines": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "notations": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "openings": {
+                    "description": "Opening suites for tournaments",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "toggles": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "boolean"
+                    }
+                },
+                "variants": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.InfoGames": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "integer"
+                },
+                "finished": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ReqDeleteSessions": {
+            "type": "object",
+            "properties": {
+                "boardid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ReqPostAccount": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "description": "Player name of the account",
+                    "type": "string"
+                }
+            }
+        },
+        "api.ReqPostGame": {
+            "type": "object",
+            "properties": {
+                "black": {
+                    "description": "Bot launched as black",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rated": {
+                    "description": "The result updates the ratings, players need a name",
+                    "type": "boolean"
+                },
+                "white": {
+                    "description": "Bot launched as white",
+                    "type": "string"
+                }
+            }
+        },
+        "api.ReqPostMatchmaking": {
+            "type": "object",
+            "properties": {
+                "maxrating": {
+                    "description": "Highest rating of the opponent, 0 for no limit",
+                    "type": "number"
+                },
+                "minrating": {
+                    "description": "Lowest rating of the opponent, 0 for no limit",
+                    "type": "number"
+                },
+                "movetime": {
+                    "description": "e.g. \"500ms\", only bots asking for the same are paired",
+                    "type": "string"
+                },
+                "player": {
+                    "description": "Rating identity, rated games need an account instead",
+                    "type": "string"
+                },
+                "rated": {
+                    "description": "Only paired with bots asking for the same",
+                    "type": "boolean"
+                },
+                "wait": {
+                    "description": "Time to wait for a match before responding, e.g. \"30s\"",
+                    "type": "string"
+                }
+            }
+        },
+        "api.ReqPostMove": {
+            "type": "object",
+            "properties": {
+                "move": {
+                    "description": "e.g. \"e2 e4\" or \"e7 e8q\"",
+                    "type": "string"
+                },
+                "random": {
+                    "description": "Play a random legal move instead",
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.ReqPostSessions": {
+            "type": "object",
+            "properties": {
+                "black": {
+                    "description": "Bot launched as black",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rated": {
+                    "description": "The result updates the ratings, players need a name",
+                    "type": "boolean"
+                },
+                "white": {
+                    "description": "Bot launched as white",
+                    "type": "string"
+                }
+            }
+        },
+        "api.ReqPostTournament": {
+            "type": "object",
+            "properties": {
+                "concurrency": {
+                    "description": "Games at the same time, default 1",
+                    "type": "integer"
+                },
+                "format": {
+                    "description": "\"roundrobin\", \"doubleroundrobin\", \"gauntlet\", \"swiss\" or \"sprt\"",
+                    "type": "string"
+                },
+                "gamesperpairing": {
+                    "description": "Default 2, 1 for swiss, colors alternate",
+                    "type": "integer"
+                },
+                "maxplies": {
+                    "description": "Games reaching it are drawn",
+                    "type": "integer"
+                },
+                "movetime": {
+                    "description": "e.g. \"500ms\", exceeding it loses the game",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "openings": {
+                    "description": "Opening suite of the server, each opening is played with both colors",
+                    "type": "string"
+                },
+                "participants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.TournamentParticipant"
+                    }
+                },
+                "rated": {
+                    "description": "Games update the ratings of the engines and bots",
+                    "type": "boolean"
+                },
+                "rounds": {
+                    "description": "Swiss: default log2 of the participants rounded up, sprt: maximum pairs, default 1000",
+                    "type": "integer"
+                },
+                "sprt": {
+                    "description": "sprt only, default elo0 0, elo1 5, alpha and beta 0.05",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/api.TournamentSPRT"
+                        }
+                    ]
+                }
+            }
+        },
+        "api.ReqPutGame": {
+            "type": "object",
+            "properties": {
+                "boardid": {
+                    "type": "integer"
+                },
+                "color": {
+                    "type": "string"
+                },
+                "move": {
+                    "type": "string"
+                },
+                "reqtype": {
+                    "description": "\"forfeit\" \"move\" \"randommove\"",
+                    "type": "string"
+                }
+            }
+        },
+        "api.ReqPutSessions": {
+            "type": "object",
+            "properties": {
+                "boardid": {
+                    "type": "integer"
+                },
+                "bot": {
+                    "description": "Launch a bot of the bots file instead",
+                    "type": "string"
+                },
+                "color": {
+                    "type": "string"
+                },
+                "engine": {
+                    "description": "Seat a built-in engine instead, e.g. \"alphabeta\"",
+                    "type": "string"
+                },
+                "player": {
+                    "description": "Rating identity, rated games need an account instead",
+                    "type": "string"
+                }
+            }
+        },
+        "api.RespAccount": {
+            "type": "object",
+            "properties": {
+                "createdat": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rating": {
+                    "description": "Set once the account played a rated game",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/api.RespRating"
+                        }
+                    ]
+                }
+            }
+        },
+        "api.RespAccounts": {
+            "type": "object",
+            "properties": {
+                "accounts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.RespAccount"
+                    }
+                }
+            }
+        },
+        "api.RespBookMove": {
+            "type": "object",
+            "properties": {
+                "move": {
+                    "type": "string"
+                },
+                "share": {
+                    "description": "Weight relative to all book moves of the position",
+                    "type": "number"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.RespBookMoves": {
+            "type": "object",
+            "properties": {
+                "book": {
+                    "type": "string"
+                },
+                "key": {
+                    "description": "Polyglot key of the position in hex",
+                    "type": "string"
+                },
+                "moves": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.RespBookMove"
+                    }
+                }
+            }
+        },
+        "api.RespBye": {
+            "type": "object",
+            "properties": {
+                "participant": {
+                    "type": "string"
+                },
+                "round": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.RespCrosstable": {
+            "type": "object",
+            "properties": {
+                "participants": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scores": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/api.RespScore"
+                        }
+                    }
+                }
+            }
+        },
+        "api.RespError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Stable machine-readable code, e.g. \"not_your_turn\"",
+                    "type": "string"
+                },
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "message": {
+                    "description": "Human readable description",
+                    "type": "string"
+                }
+            }
+        },
+        "api.RespGame": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "opening": {
+                    "description": "Opening the game started from, its moves count as plies",
+                    "type": "string"
+                },
+                "players": {
+                    "$ref": "#/definitions/api.RespPlayers"
+                },
+                "plies": {
+                    "type": "integer"
+                },
+                "rated": {
+                    "type": "boolean"
+                },
+                "started": {
+                    "type": "boolean"
+                },
+                "termination": {
+                    "type": "string"
+                },
+                "turncolor": {
+                    "description": "\"w\", \"b\" or \"n\" if nobody has to move",
+                    "type": "string"
+                },
+                "winner": {
+                    "description": "\"n\" while ongoing, \"w\", \"b\" or \"r\" for remis",
+                    "type": "string"
+                }
+            }
+        },
+        "api.RespGames": {
+            "type": "object",
+            "properties": {
+                "games": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.RespGame"
+                    }
+                }
+            }
+        },
+        "api.RespGetSessions": {
+            "type": "object",
+            "properties": {
+                "games": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.GameNameAndID"
+                    }
+                }
+            }
+        },
+        "api.RespHealth": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "description": "\"ok\" or \"unavailable\"",
+                    "type": "string"
+                }
+            }
+        },
+        "api.RespInfo": {
+            "type": "object",
+            "properties": {
+                "apiversions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "features": {
+                    "$ref": "#/definitions/api.InfoFeatures"
+                },
+                "games": {
+                    "$ref": "#/definitions/api.InfoGames"
+                },
+                "uptimeseconds": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.RespLeaderboard": {
+            "type": "object",
+            "properties": {
+                "players": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.RespRating"
+                    }
+                }
+            }
+        },
+        "api.RespLegalMoves": {
+            "type": "object",
+            "properties": {
+                "moves": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.RespMatch": {
+            "type": "object",
+            "properties": {
+                "boardid": {
+                    "type": "integer"
+                },
+                "color": {
+                    "type": "string"
+                },
+                "opponent": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.RespMoves": {
+            "type": "object",
+            "properties": {
+                "moves": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.RespPlayedMove"
+                    }
+                }
+            }
+        },
+        "api.RespPlayedMove": {
+            "type": "object",
+            "properties": {
+                "color": {
+                    "type": "string"
+                },
+                "move": {
+                    "type": "string"
+                },
+                "ply": {
+                    "description": "Index of the position the move leads to",
+                    "type": "integer"
+                }
+            }
+        },
+        "api.RespPlayers": {
+            "type": "object",
+            "properties": {
+                "b": {
+                    "type": "boolean"
+                },
+                "baccount": {
+                    "type": "boolean"
+                },
+                "bplayer": {
+                    "type": "string"
+                },
+                "w": {
+                    "type": "boolean"
+                },
+                "waccount": {
+                    "description": "The player is the account of that name",
+                    "type": "boolean"
+                },
+                "wplayer": {
+                    "description": "Rating identity",
+                    "type": "string"
+                }
+            }
+        },
+        "api.RespPostAccount": {
+            "type": "object",
+            "properties": {
+                "createdat": {
+                    "type": "string"
+                },
+                "key": {
+                    "description": "API key, send it in the X-API-Key header",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rating": {
+                    "description": "Set once the account played a rated game",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/api.RespRating"
+                        }
+                    ]
+                }
+            }
+        },
+        "api.RespPostGame": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.RespPostSessions": {
+            "type": "object",
+            "properties": {
+                "boardid": {
+                    "type": "integer"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.RespPostTournament": {
+            "type": "object",
+            "properties": {
+                "id": {
                     "type": "integer"
                 },
                 "password": {
@@ -435,10 +2630,427 @@ const docTemplate = `{
                 }
             }
         },
+        "api.RespPutPlayer": {
+            "type": "object",
+            "properties": {
+                "color": {
+                    "type": "string"
+                },
+                "expiresat": {
+                    "description": "Unset if the token doesn't expire",
+                    "type": "string"
+                },
+                "token": {
+                    "description": "Unset for engine and bot seats",
+                    "type": "string"
+                }
+            }
+        },
         "api.RespPutSessions": {
             "type": "object",
             "properties": {
                 "token": {
+                    "description": "Unset for engine and bot seats",
+                    "type": "string"
+                }
+            }
+        },
+        "api.RespRating": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "description": "The player is an account",
+                    "type": "boolean"
+                },
+                "deviation": {
+                    "description": "Twice the deviation is a 95% confidence interval",
+                    "type": "number"
+                },
+                "draws": {
+                    "type": "integer"
+                },
+                "games": {
+                    "type": "integer"
+                },
+                "lastgame": {
+                    "type": "string"
+                },
+                "losses": {
+                    "type": "integer"
+                },
+                "player": {
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "rating": {
+                    "type": "number"
+                },
+                "volatility": {
+                    "type": "number"
+                },
+                "wins": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.RespSPRT": {
+            "type": "object",
+            "properties": {
+                "alpha": {
+                    "type": "number"
+                },
+                "beta": {
+                    "type": "number"
+                },
+                "decision": {
+                    "description": "\"H0\", \"H1\" or \"\" while running",
+                    "type": "string"
+                },
+                "elo": {
+                    "type": "number"
+                },
+                "elo0": {
+                    "type": "number"
+                },
+                "elo1": {
+                    "type": "number"
+                },
+                "eloerror": {
+                    "description": "95% confidence",
+                    "type": "number"
+                },
+                "llr": {
+                    "type": "number"
+                },
+                "lower": {
+                    "description": "H0 is accepted at or below",
+                    "type": "number"
+                },
+                "pairs": {
+                    "type": "integer"
+                },
+                "pentanomial": {
+                    "description": "Pairs by points of the tested participant: 0, 0.5, 1, 1.5, 2",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "upper": {
+                    "description": "H1 is accepted at or above",
+                    "type": "number"
+                }
+            }
+        },
+        "api.RespScore": {
+            "type": "object",
+            "properties": {
+                "draws": {
+                    "type": "integer"
+                },
+                "games": {
+                    "type": "integer"
+                },
+                "losses": {
+                    "type": "integer"
+                },
+                "points": {
+                    "type": "number"
+                },
+                "wins": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.RespStanding": {
+            "type": "object",
+            "properties": {
+                "buchholz": {
+                    "type": "number"
+                },
+                "byes": {
+                    "type": "integer"
+                },
+                "draws": {
+                    "type": "integer"
+                },
+                "games": {
+                    "type": "integer"
+                },
+                "losses": {
+                    "type": "integer"
+                },
+                "participant": {
+                    "type": "string"
+                },
+                "points": {
+                    "type": "number"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "sonnebornberger": {
+                    "type": "number"
+                },
+                "wins": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.RespTicket": {
+            "type": "object",
+            "properties": {
+                "createdat": {
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Set if failed",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "match": {
+                    "description": "Set once matched",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/api.RespMatch"
+                        }
+                    ]
+                },
+                "maxrating": {
+                    "type": "number"
+                },
+                "minrating": {
+                    "type": "number"
+                },
+                "movetime": {
+                    "type": "string"
+                },
+                "player": {
+                    "type": "string"
+                },
+                "rated": {
+                    "type": "boolean"
+                },
+                "secret": {
+                    "description": "Only returned on creation, sent as Bearer token to poll or leave",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "\"waiting\", \"pairing\", \"matched\" or \"failed\"",
+                    "type": "string"
+                }
+            }
+        },
+        "api.RespTournament": {
+            "type": "object",
+            "properties": {
+                "byes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.RespBye"
+                    }
+                },
+                "concurrency": {
+                    "type": "integer"
+                },
+                "finishedgames": {
+                    "type": "integer"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "games": {
+                    "type": "integer"
+                },
+                "gamesperpairing": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "maxplies": {
+                    "type": "integer"
+                },
+                "movetime": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "openings": {
+                    "type": "string"
+                },
+                "participants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.TournamentParticipant"
+                    }
+                },
+                "rated": {
+                    "type": "boolean"
+                },
+                "round": {
+                    "description": "Highest round paired so far",
+                    "type": "integer"
+                },
+                "rounds": {
+                    "description": "Swiss and sprt only",
+                    "type": "integer"
+                },
+                "sprt": {
+                    "$ref": "#/definitions/api.RespSPRT"
+                },
+                "standings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.RespStanding"
+                    }
+                },
+                "status": {
+                    "description": "\"running\", \"finished\" or \"cancelled\"",
+                    "type": "string"
+                }
+            }
+        },
+        "api.RespTournamentGame": {
+            "type": "object",
+            "properties": {
+                "black": {
+                    "type": "string"
+                },
+                "boardid": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "opening": {
+                    "type": "string"
+                },
+                "round": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "\"pending\", \"running\", \"finished\" or \"failed\"",
+                    "type": "string"
+                },
+                "termination": {
+                    "type": "string"
+                },
+                "white": {
+                    "type": "string"
+                },
+                "winner": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.RespTournamentGames": {
+            "type": "object",
+            "properties": {
+                "games": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.RespTournamentGame"
+                    }
+                }
+            }
+        },
+        "api.RespTournaments": {
+            "type": "object",
+            "properties": {
+                "tournaments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.RespTournament"
+                    }
+                }
+            }
+        },
+        "api.TournamentParticipant": {
+            "type": "object",
+            "properties": {
+                "bot": {
+                    "description": "Bot of the bots file",
+                    "type": "string"
+                },
+                "engine": {
+                    "description": "Built-in engine, e.g. \"alphabeta\"",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Defaults to the engine or bot name",
+                    "type": "string"
+                }
+            }
+        },
+        "api.TournamentSPRT": {
+            "type": "object",
+            "properties": {
+                "alpha": {
+                    "type": "number"
+                },
+                "beta": {
+                    "type": "number"
+                },
+                "elo0": {
+                    "type": "number"
+                },
+                "elo1": {
+                    "type": "number"
+                }
+            }
+        },
+        "game_logic.BoardState": {
+            "type": "object",
+            "properties": {
+                "blackkingmoved": {
+                    "type": "boolean"
+                },
+                "blackkingpos": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "board": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "enpassant": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "lastmove": {
+                    "type": "string"
+                },
+                "turncolor": {
+                    "type": "string"
+                },
+                "whitekingmoved": {
+                    "type": "boolean"
+                },
+                "whitekingpos": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "winner": {
                     "type": "string"
                 }
             }
@@ -0,0 +1,131 @@
+/*
+Accounts of bots and users. An account owns a player name and an API key;
+clients sending the key play under the account's name, which nobody else
+can use. The name is the account's rating identity, so ratings, games and
+the leaderboard refer to it.
+
+Only the SHA-256 hash of a key is kept. Keys are random, so a plain hash
+is enough to make a dumped state useless for authentication.
+*/
+package accounts
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prefix of API keys, to tell them apart from passwords and tokens.
+const KeyPrefix = "cbk_"
+
+var (
+	ErrAccountExists   = errors.New("account already exists")
+	ErrAccountNotFound = errors.New("account not found")
+)
+
+type Account struct {
+	Name      string    `json:"name"`
+	KeyHash   string    `json:"keyhash"` // Hex SHA-256 of the API key
+	CreatedAt time.Time `json:"createdat"`
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]*Account{} // By name
+	byKeyHash  = map[string]*Account{}
+)
+
+// Creates an account and returns its API key. The key is not stored and
+// can't be retrieved later. The name has to be checked by the caller.
+func Create(name string) (Account, string, error) {
+	key, err := newKey()
+	if err != nil {
+		return Account{}, "", err
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[name]; exists {
+		return Account{}, "", ErrAccountExists
+	}
+	account := &Account{Name: name, KeyHash: hashKey(key), CreatedAt: time.Now()}
+	registry[name] = account
+	byKeyHash[account.KeyHash] = account
+	return *account, key, nil
+}
+
+// Deletes an account. Its name can be taken again afterwards; the rating
+// stays.
+func Delete(name string) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	account, exists := registry[name]
+	if !exists {
+		return ErrAccountNotFound
+	}
+	delete(registry, name)
+	delete(byKeyHash, account.KeyHash)
+	return nil
+}
+
+// Returns the account with the given name.
+func Get(name string) (Account, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	account, exists := registry[name]
+	if !exists {
+		return Account{}, false
+	}
+	return *account, true
+}
+
+// Reports whether a player name belongs to an account.
+func Exists(name string) bool {
+	_, exists := Get(name)
+	return exists
+}
+
+// Returns the account of an API key.
+func Authenticate(key string) (Account, bool) {
+	if !strings.HasPrefix(key, KeyPrefix) {
+		return Account{}, false
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	account, exists := byKeyHash[hashKey(key)]
+	if !exists {
+		return Account{}, false
+	}
+	return *account, true
+}
+
+// Returns all accounts sorted by name.
+func List() []Account {
+	registryMu.RLock()
+	list := make([]Account, 0, len(registry))
+	for _, account := range registry {
+		list = append(list, *account)
+	}
+	registryMu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func newKey() (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	return KeyPrefix + base64.RawURLEncoding.EncodeToString(random), nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,69 @@
+/*
+Unittest for the accounts package.
+*/
+package accounts
+
+import (
+	"strings"
+	"testing"
+)
+
+func reset() {
+	registryMu.Lock()
+	registry = map[string]*Account{}
+	byKeyHash = map[string]*Account{}
+	registryMu.Unlock()
+}
+
+func TestCreateAndAuthenticate(t *testing.T) {
+	reset()
+	account, key, err := Create("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, KeyPrefix) || strings.Contains(account.KeyHash, key) {
+		t.Fatalf("unexpected key %q with hash %q", key, account.KeyHash)
+	}
+	if _, _, err := Create("alice"); err != ErrAccountExists {
+		t.Errorf("expected ErrAccountExists, got %v", err)
+	}
+
+	if got, ok := Authenticate(key); !ok || got.Name != "alice" {
+		t.Errorf("expected the key to authenticate alice, got %+v %v", got, ok)
+	}
+	for _, wrong := range []string{"", key[:len(key)-1], strings.TrimPrefix(key, KeyPrefix), account.KeyHash} {
+		if _, ok := Authenticate(wrong); ok {
+			t.Errorf("key %q authenticated", wrong)
+		}
+	}
+
+	if err := Delete("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Authenticate(key); ok || Exists("alice") {
+		t.Error("deleted account still exists")
+	}
+	if err := Delete("alice"); err != ErrAccountNotFound {
+		t.Errorf("expected ErrAccountNotFound, got %v", err)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	reset()
+	_, key, _ := Create("alice")
+	Create("bob")
+	dir := t.TempDir()
+	if err := SaveSnapshot(dir); err != nil {
+		t.Fatal(err)
+	}
+	reset()
+	if n, err := LoadSnapshot(dir); err != nil || n != 2 {
+		t.Fatalf("expected 2 restored accounts, got %d %v", n, err)
+	}
+	if got, ok := Authenticate(key); !ok || got.Name != "alice" {
+		t.Errorf("restored key doesn't authenticate alice, got %+v %v", got, ok)
+	}
+	if names := List(); len(names) != 2 || names[1].Name != "bob" {
+		t.Errorf("unexpected accounts %+v", names)
+	}
+}
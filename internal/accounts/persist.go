@@ -0,0 +1,64 @@
+/*
+Persistence of the accounts next to the game snapshot.
+*/
+package accounts
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+const SnapshotFile = "accounts.json"
+
+// Writes all accounts to dir/SnapshotFile. The file is replaced atomically
+// and only readable by the owner.
+func SaveSnapshot(dir string) error {
+	encoded, err := json.Marshal(List())
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, SnapshotFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(encoded); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, SnapshotFile))
+}
+
+// Restores the accounts from dir/SnapshotFile. A missing file is not an
+// error. Returns the number of restored accounts.
+func LoadSnapshot(dir string) (int, error) {
+	encoded, err := os.ReadFile(filepath.Join(dir, SnapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var list []Account
+	if err := json.Unmarshal(encoded, &list); err != nil {
+		return 0, err
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, account := range list {
+		registry[account.Name] = &account
+		byKeyHash[account.KeyHash] = &account
+	}
+	return len(list), nil
+}
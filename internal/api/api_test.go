@@ -0,0 +1,497 @@
+/*
+Unittest for the api package.
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/matetirpak/chessbot-playground-server/internal/accounts"
+	"github.com/matetirpak/chessbot-playground-server/internal/bots"
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+	"github.com/matetirpak/chessbot-playground-server/internal/engine"
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+)
+
+// Routes of the handlers under test, as registered by pkg/server.
+func newTestRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(NotFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(MethodNotAllowed)
+	for _, route := range []struct {
+		method, path string
+		handler      http.HandlerFunc
+	}{
+		{"GET", "/chessserver/v1/game", GetGame},
+		{"PUT", "/chessserver/v1/sessions", PutSessions},
+		{"PUT", "/chessserver/v1/game", PutGame},
+		{"POST", "/chessserver/v2/games", PostGamesV2},
+		{"GET", "/chessserver/v2/games/{id}", GetGameV2},
+		{"DELETE", "/chessserver/v2/games/{id}", DeleteGameV2},
+		{"PUT", "/chessserver/v2/games/{id}/players/{color}", PutPlayerV2},
+		{"DELETE", "/chessserver/v2/games/{id}/players/{color}", DeletePlayerV2},
+		{"POST", "/chessserver/v2/games/{id}/players/{color}/token", PostPlayerTokenV2},
+		{"GET", "/chessserver/v2/games/{id}/moves", GetMovesV2},
+		{"POST", "/chessserver/v2/games/{id}/moves", PostMovesV2},
+		{"GET", "/chessserver/v2/games/{id}/positions/{ply}", GetPositionV2},
+		{"POST", "/chessserver/v2/matchmaking", PostMatchmakingV2},
+		{"GET", "/chessserver/v2/matchmaking/{ticket}", GetMatchmakingTicketV2},
+		{"DELETE", "/chessserver/v2/matchmaking/{ticket}", DeleteMatchmakingTicketV2},
+	} {
+		router.Methods(route.method).Path(route.path).HandlerFunc(route.handler)
+	}
+	return router
+}
+
+// Sends a request through the test router. headers alternate names and
+// values.
+func call(method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	rec := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(rec, req)
+	return rec
+}
+
+func bearer(token string) []string {
+	return []string{"Authorization", "Bearer " + token}
+}
+
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(rec.Body.Bytes(), &v); err != nil {
+		t.Fatalf("failed to decode %q: %v", rec.Body.String(), err)
+	}
+	return v
+}
+
+// Checks the status and, for errors, the code of a response.
+func expect(t *testing.T, rec *httptest.ResponseRecorder, status int, code string) {
+	t.Helper()
+	if rec.Code != status {
+		t.Fatalf("expected status %d, got %d: %s", status, rec.Code, rec.Body.String())
+	}
+	if code != "" {
+		if got := decode[RespError](t, rec).Code; got != code {
+			t.Fatalf("expected code %q, got %q", code, got)
+		}
+	}
+}
+
+// Creates a game through the API and removes it after the test.
+func newTestGame(t *testing.T, rated bool) (int32, string) {
+	t.Helper()
+	rec := call("POST", "/chessserver/v2/games", fmt.Sprintf(`{"name": "test", "rated": %v}`, rated))
+	expect(t, rec, http.StatusCreated, "")
+	resp := decode[RespPostGame](t, rec)
+	t.Cleanup(func() { data.RemoveGame(resp.ID) })
+	return resp.ID, resp.Password
+}
+
+func playersPath(id int32, color string) string {
+	return fmt.Sprintf("/chessserver/v2/games/%d/players/%s", id, color)
+}
+
+// Joins a game and returns the player token.
+func join(t *testing.T, id int32, password, color string) string {
+	t.Helper()
+	rec := call("PUT", playersPath(id, color), "", bearer(password)...)
+	expect(t, rec, http.StatusCreated, "")
+	return decode[RespPutPlayer](t, rec).Token
+}
+
+func newTestAccount(t *testing.T, name string) string {
+	t.Helper()
+	_, key, err := accounts.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { accounts.Delete(name) })
+	return key
+}
+
+func TestRatedGamesNeedAccounts(t *testing.T) {
+	id, password := newTestGame(t, true)
+	key := newTestAccount(t, "rated-alice")
+
+	rec := call("PUT", playersPath(id, "w")+"?player=mallory", "", bearer(password)...)
+	expect(t, rec, http.StatusBadRequest, CodeInvalidPlayer)
+	rec = call("PUT", playersPath(id, "w")+"?player=rated-alice", "", bearer(password)...)
+	expect(t, rec, http.StatusForbidden, CodePlayerReserved)
+	rec = call("PUT", playersPath(id, "w"), "", append(bearer(password), APIKeyHeader, key)...)
+	expect(t, rec, http.StatusCreated, "")
+	rec = call("PUT", playersPath(id, "b")+"?engine=random", "", bearer(password)...)
+	expect(t, rec, http.StatusCreated, "")
+
+	rec = call("POST", "/chessserver/v2/matchmaking", `{"player": "mallory", "rated": true}`)
+	expect(t, rec, http.StatusBadRequest, CodeInvalidPlayer)
+
+	// Casual games still take any name
+	id, password = newTestGame(t, false)
+	rec = call("PUT", playersPath(id, "w")+"?player=mallory", "", bearer(password)...)
+	expect(t, rec, http.StatusCreated, "")
+}
+
+// Engine that never finds a move, it returns once its context is done.
+type hangingEngine struct {
+	searching chan struct{}
+	cancelled chan struct{}
+}
+
+func (e hangingEngine) BestMove(ctx context.Context, pos gl.BoardState, limits engine.Limits) (gl.Move, error) {
+	close(e.searching)
+	<-ctx.Done()
+	close(e.cancelled)
+	return gl.Move{}, ctx.Err()
+}
+
+// Registers a hanging engine under a name unique to the test.
+func newHangingEngine(t *testing.T) (string, hangingEngine) {
+	eng := hangingEngine{searching: make(chan struct{}), cancelled: make(chan struct{})}
+	name := "hang-" + strings.ToLower(t.Name())
+	engine.Register(name, func() engine.Engine { return eng })
+	return name, eng
+}
+
+func waitFor(t *testing.T, ch <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+func TestHungEngineForfeits(t *testing.T) {
+	defer func(grace time.Duration) { EngineMoveGrace = grace }(EngineMoveGrace)
+	EngineMoveGrace = 50 * time.Millisecond
+	name, eng := newHangingEngine(t)
+
+	id, password := newTestGame(t, false)
+	game, _ := lookupGame(id)
+	game.Mu.Lock()
+	game.MoveTime = 50 * time.Millisecond
+	game.Mu.Unlock()
+	expect(t, call("PUT", playersPath(id, "w")+"?engine="+name, "", bearer(password)...), http.StatusCreated, "")
+	join(t, id, password, "b")
+
+	waitFor(t, eng.cancelled, "the search deadline")
+	deadline := time.Now().Add(5 * time.Second)
+	for !gameFinished(game) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+	if game.Winner != "b" || game.Termination != "forfeit" {
+		t.Errorf("expected the hung engine to forfeit, got winner %q termination %q", game.Winner, game.Termination)
+	}
+}
+
+func TestRemovingGameStopsEngine(t *testing.T) {
+	name, eng := newHangingEngine(t)
+	id, password := newTestGame(t, false)
+	expect(t, call("PUT", playersPath(id, "w")+"?engine="+name, "", bearer(password)...), http.StatusCreated, "")
+	join(t, id, password, "b")
+
+	waitFor(t, eng.searching, "the engine to search")
+	data.RemoveGame(id)
+	waitFor(t, eng.cancelled, "the search to be cancelled")
+}
+
+func tokenPath(id int32, color string) string {
+	return playersPath(id, color) + "/token"
+}
+
+func movesPath(id int32) string {
+	return fmt.Sprintf("/chessserver/v2/games/%d/moves", id)
+}
+
+// Moves the expiry of a seat's token by d.
+func shiftExpiry(t *testing.T, id int32, color string, d time.Duration) {
+	t.Helper()
+	game, _ := lookupGame(id)
+	game.Mu.Lock()
+	defer game.Mu.Unlock()
+	if color == "w" {
+		game.WTokenExpires = game.WTokenExpires.Add(d)
+	} else {
+		game.BTokenExpires = game.BTokenExpires.Add(d)
+	}
+}
+
+func TestRegenerateToken(t *testing.T) {
+	defer func(ttl time.Duration) { TokenTTL = ttl }(TokenTTL)
+	TokenTTL = time.Hour
+	id, password := newTestGame(t, false)
+	white := join(t, id, password, "w")
+	black := join(t, id, password, "b")
+
+	// Neither the opponent nor the shared password regenerate a seat
+	expect(t, call("POST", tokenPath(id, "w"), "", bearer(black)...), http.StatusUnauthorized, CodeInvalidToken)
+	expect(t, call("POST", tokenPath(id, "w"), "", bearer(password)...), http.StatusUnauthorized, CodeInvalidToken)
+	expect(t, call("POST", tokenPath(id, "w"), ""), http.StatusUnauthorized, CodeMissingAuthorization)
+	expect(t, call("GET", movesPath(id), "", bearer(white)...), http.StatusOK, "")
+
+	// An expired token is rejected, but regenerates its own seat
+	shiftExpiry(t, id, "w", -TokenTTL-time.Minute)
+	expect(t, call("GET", movesPath(id), "", bearer(white)...), http.StatusUnauthorized, CodeTokenExpired)
+	rec := call("POST", tokenPath(id, "w"), "", bearer(white)...)
+	expect(t, rec, http.StatusCreated, "")
+	resp := decode[RespPutPlayer](t, rec)
+	if resp.ExpiresAt == nil || time.Until(*resp.ExpiresAt) <= 0 {
+		t.Errorf("expected the new token to expire in the future, got %v", resp.ExpiresAt)
+	}
+	expect(t, call("GET", movesPath(id), "", bearer(resp.Token)...), http.StatusOK, "")
+	expect(t, call("GET", movesPath(id), "", bearer(white)...), http.StatusUnauthorized, CodeInvalidToken)
+
+	// Once expired for longer than it lived, the token is useless
+	shiftExpiry(t, id, "b", -2*TokenTTL-time.Minute)
+	expect(t, call("POST", tokenPath(id, "b"), "", bearer(black)...), http.StatusUnauthorized, CodeInvalidToken)
+
+	expect(t, call("POST", tokenPath(id+1000, "w"), "", bearer(white)...), http.StatusNotFound, CodeBoardNotFound)
+	expect(t, call("POST", tokenPath(id, "x"), "", bearer(white)...), http.StatusBadRequest, CodeInvalidColor)
+}
+
+func TestRegenerateTokenWithAPIKey(t *testing.T) {
+	id, password := newTestGame(t, false)
+	key := newTestAccount(t, "token-alice")
+	other := newTestAccount(t, "token-bob")
+	expect(t, call("PUT", playersPath(id, "w"), "", append(bearer(password), APIKeyHeader, key)...), http.StatusCreated, "")
+	expect(t, call("PUT", playersPath(id, "b"), "", append(bearer(password), APIKeyHeader, other)...), http.StatusCreated, "")
+
+	expect(t, call("POST", tokenPath(id, "w"), "", APIKeyHeader, other), http.StatusUnauthorized, CodeInvalidAPIKey)
+	expect(t, call("POST", tokenPath(id, "w"), "", APIKeyHeader, key), http.StatusCreated, "")
+}
+
+func TestEngineSeatsHaveNoToken(t *testing.T) {
+	name, eng := newHangingEngine(t)
+	id, password := newTestGame(t, false)
+	rec := call("PUT", playersPath(id, "w")+"?engine="+name, "", bearer(password)...)
+	expect(t, rec, http.StatusCreated, "")
+	if resp := decode[RespPutPlayer](t, rec); resp.Token != "" || resp.ExpiresAt != nil {
+		t.Errorf("expected no token for the engine seat, got %+v", resp)
+	}
+	black := join(t, id, password, "b")
+	waitFor(t, eng.searching, "the engine to search")
+
+	// Neither a client nor its credentials act for the engine
+	expect(t, call("POST", tokenPath(id, "w"), "", bearer(password)...), http.StatusConflict, CodeSeatManaged)
+	for _, token := range []string{"", password, black} {
+		expect(t, call("DELETE", playersPath(id, "w"), "", bearer(token)...), http.StatusUnauthorized, CodeInvalidToken)
+		v1 := fmt.Sprintf(`{"boardid": %d, "color": "w", "reqtype": "forfeit"}`, id)
+		expect(t, call("PUT", "/chessserver/v1/game", v1, bearer(token)...), http.StatusUnauthorized, CodeInvalidToken)
+	}
+	game, _ := lookupGame(id)
+	if _, _, apiErr := applyMove(context.Background(), game, "w", "e2 e4", false); apiErr == nil || apiErr.code != CodeSeatManaged {
+		t.Errorf("expected clients' moves for the engine to be rejected, got %v", apiErr)
+	}
+	if apiErr := forfeitGame(context.Background(), game, "w"); apiErr == nil || apiErr.code != CodeSeatManaged {
+		t.Errorf("expected clients' forfeits for the engine to be rejected, got %v", apiErr)
+	}
+	if gameFinished(game) {
+		t.Error("expected the game to go on")
+	}
+}
+
+func TestMatchmakingTokenRefresh(t *testing.T) {
+	defer func(ttl time.Duration) { TokenTTL = ttl }(TokenTTL)
+	TokenTTL = time.Hour
+	body := `{"movetime": "17s"}`
+	expect(t, call("POST", "/chessserver/v2/matchmaking", body), http.StatusCreated, "")
+	rec := call("POST", "/chessserver/v2/matchmaking", body)
+	expect(t, rec, http.StatusCreated, "")
+	match := decode[RespTicket](t, rec).Match
+	if match == nil {
+		t.Fatal("expected the second ticket to be matched")
+	}
+	t.Cleanup(func() { data.RemoveGame(match.BoardID) })
+
+	shiftExpiry(t, match.BoardID, match.Color, -TokenTTL-time.Minute)
+	expect(t, call("GET", movesPath(match.BoardID), "", bearer(match.Token)...), http.StatusUnauthorized, CodeTokenExpired)
+	rec = call("POST", tokenPath(match.BoardID, match.Color), "", bearer(match.Token)...)
+	expect(t, rec, http.StatusCreated, "")
+	token := decode[RespPutPlayer](t, rec).Token
+	expect(t, call("GET", movesPath(match.BoardID), "", bearer(token)...), http.StatusOK, "")
+}
+
+func TestV1TurnWithToken(t *testing.T) {
+	id, password := newTestGame(t, false)
+	white := join(t, id, password, "w")
+	black := join(t, id, password, "b")
+	turn := func(color string) string {
+		return fmt.Sprintf("/chessserver/v1/game?reqtype=turn&boardid=%d&color=%s", id, color)
+	}
+
+	expect(t, call("GET", turn("w"), "", bearer(white)...), http.StatusOK, "")
+	expect(t, call("GET", turn("w"), "", bearer(password)...), http.StatusOK, "")
+	expect(t, call("GET", turn("w"), "", bearer(black)...), http.StatusUnauthorized, CodeInvalidToken)
+}
+
+func TestResumeBotsReissuesTokens(t *testing.T) {
+	id, _ := newTestGame(t, false)
+	game, _ := lookupGame(id)
+	game.Mu.Lock()
+	game.HasWPlayer, game.WBot, game.WTokenHash = true, "missing-bot", data.HashSecret("old")
+	game.Mu.Unlock()
+
+	ResumeBots()
+
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+	if game.WTokenHash == "" || data.MatchesHash(game.WTokenHash, "old") {
+		t.Error("expected the bot seat to get a new token")
+	}
+}
+
+func TestTicketsNeedTheirSecret(t *testing.T) {
+	body := `{"movetime": "19s"}`
+	rec := call("POST", "/chessserver/v2/matchmaking", body)
+	expect(t, rec, http.StatusCreated, "")
+	first := decode[RespTicket](t, rec)
+	if first.Secret == "" || strings.Contains(rec.Header().Get("Location"), first.Secret) {
+		t.Fatalf("expected a secret outside the ticket URL, got %q at %q", first.Secret, rec.Header().Get("Location"))
+	}
+	path := rec.Header().Get("Location")
+
+	expect(t, call("GET", path, ""), http.StatusUnauthorized, CodeMissingAuthorization)
+	expect(t, call("GET", path, "", bearer(first.ID)...), http.StatusUnauthorized, CodeInvalidToken)
+	expect(t, call("DELETE", path, "", bearer(first.ID)...), http.StatusUnauthorized, CodeInvalidToken)
+
+	rec = call("POST", "/chessserver/v2/matchmaking", body)
+	expect(t, rec, http.StatusCreated, "")
+	second := decode[RespTicket](t, rec)
+	if second.Match == nil {
+		t.Fatal("expected the second ticket to be matched")
+	}
+	t.Cleanup(func() { data.RemoveGame(second.Match.BoardID) })
+
+	rec = call("GET", path, "", bearer(first.Secret)...)
+	expect(t, rec, http.StatusOK, "")
+	if resp := decode[RespTicket](t, rec); resp.Match == nil || resp.Match.Token == "" || resp.Secret != "" {
+		t.Errorf("expected the match without the secret, got %+v", resp)
+	}
+	expect(t, call("DELETE", path, "", bearer(first.Secret)...), http.StatusConflict, CodeTicketMatched)
+}
+
+func TestV2GameLifecycle(t *testing.T) {
+	rec := call("POST", "/chessserver/v2/games", `{"name": "lifecycle"}`)
+	expect(t, rec, http.StatusCreated, "")
+	created := decode[RespPostGame](t, rec)
+	t.Cleanup(func() { data.RemoveGame(created.ID) })
+	gamePath := rec.Header().Get("Location")
+	if want := fmt.Sprintf("/chessserver/v2/games/%d", created.ID); gamePath != want {
+		t.Fatalf("expected Location %q, got %q", want, gamePath)
+	}
+	id, password := created.ID, created.Password
+
+	expect(t, call("GET", gamePath, ""), http.StatusOK, "")
+	expect(t, call("GET", "/chessserver/v2/games/abc", ""), http.StatusBadRequest, CodeInvalidRequest)
+	expect(t, call("GET", fmt.Sprintf("/chessserver/v2/games/%d", id+1000), ""), http.StatusNotFound, CodeBoardNotFound)
+
+	// Joining needs the password
+	expect(t, call("PUT", playersPath(id, "w"), ""), http.StatusUnauthorized, CodeMissingAuthorization)
+	expect(t, call("PUT", playersPath(id, "w"), "", bearer("wrong")...), http.StatusUnauthorized, CodeInvalidPassword)
+	expect(t, call("PUT", playersPath(id, "x"), "", bearer(password)...), http.StatusBadRequest, CodeInvalidColor)
+	white := join(t, id, password, "w")
+	expect(t, call("PUT", playersPath(id, "w"), "", bearer(password)...), http.StatusConflict, CodeColorTaken)
+	expect(t, call("POST", movesPath(id), `{"move": "e2 e4"}`, bearer(white)...), http.StatusConflict, CodeGameNotStarted)
+	black := join(t, id, password, "b")
+	expect(t, call("PUT", playersPath(id, "w"), "", bearer(password)...), http.StatusConflict, CodeGameFull)
+
+	// Moves need the token of the side to move
+	expect(t, call("POST", movesPath(id), `{"move": "e2 e4"}`), http.StatusUnauthorized, CodeMissingAuthorization)
+	expect(t, call("POST", movesPath(id), `{"move": "e2 e4"}`, bearer(password)...), http.StatusUnauthorized, CodeInvalidToken)
+	expect(t, call("POST", movesPath(id), `{"move": "e7 e5"}`, bearer(black)...), http.StatusConflict, CodeNotYourTurn)
+	rec = call("POST", movesPath(id), `{"move": "e2 e4"}`, bearer(white)...)
+	expect(t, rec, http.StatusCreated, "")
+	if played := decode[RespPlayedMove](t, rec); played.Ply != 1 || played.Color != "w" || played.Move != "e2 e4" {
+		t.Errorf("unexpected played move %+v", played)
+	}
+	positionPath := rec.Header().Get("Location")
+	if want := fmt.Sprintf("/chessserver/v2/games/%d/positions/1", id); positionPath != want {
+		t.Fatalf("expected Location %q, got %q", want, positionPath)
+	}
+	expect(t, call("GET", positionPath, "", bearer(black)...), http.StatusOK, "")
+	expect(t, call("GET", positionPath, ""), http.StatusUnauthorized, CodeMissingAuthorization)
+	expect(t, call("GET", fmt.Sprintf("/chessserver/v2/games/%d/positions/5", id), "", bearer(password)...), http.StatusNotFound, CodePositionNotFound)
+	if moves := decode[RespMoves](t, call("GET", movesPath(id), "", bearer(password)...)); len(moves.Moves) != 1 {
+		t.Errorf("expected one played move, got %+v", moves)
+	}
+
+	// Only the player forfeits its own seat, and only once
+	expect(t, call("DELETE", playersPath(id, "b"), "", bearer(white)...), http.StatusUnauthorized, CodeInvalidToken)
+	expect(t, call("DELETE", playersPath(id, "b"), "", bearer(black)...), http.StatusNoContent, "")
+	expect(t, call("DELETE", playersPath(id, "b"), "", bearer(black)...), http.StatusConflict, CodeGameOver)
+	expect(t, call("POST", movesPath(id), `{"move": "e7 e5"}`, bearer(black)...), http.StatusConflict, CodeGameOver)
+
+	// Deleting needs the password, not a player token
+	expect(t, call("DELETE", gamePath, "", bearer(white)...), http.StatusUnauthorized, CodeInvalidPassword)
+	expect(t, call("DELETE", gamePath, "", bearer(password)...), http.StatusNoContent, "")
+	expect(t, call("GET", gamePath, ""), http.StatusNotFound, CodeBoardNotFound)
+}
+
+// Registers a bot that sleeps until it is stopped, with its log in a
+// temporary directory.
+func newSleepingBot(t *testing.T) string {
+	dir := BotLogDir
+	t.Cleanup(func() { BotLogDir = dir })
+	BotLogDir = t.TempDir()
+	name := "sleep-" + strings.ToLower(t.Name())
+	bots.Register(bots.Bot{Name: name, Command: []string{"sleep", "60"}})
+	return name
+}
+
+// Whoever seats a bot or engine in a rated game must not be able to act
+// for it, or they could farm wins for their account against it.
+func TestRatedServerSeatsTakeNoOrders(t *testing.T) {
+	bot := newSleepingBot(t)
+	key := newTestAccount(t, "farmer")
+	for _, query := range []string{"?bot=" + bot, "?engine=random"} {
+		id, password := newTestGame(t, true)
+		rec := call("PUT", playersPath(id, "w")+query, "", bearer(password)...)
+		expect(t, rec, http.StatusCreated, "")
+		if token := decode[RespPutPlayer](t, rec).Token; token != "" {
+			t.Fatalf("%s: expected the server seat's token to stay with the server, got %q", query, token)
+		}
+		rec = call("PUT", playersPath(id, "b"), "", append(bearer(password), APIKeyHeader, key)...)
+		expect(t, rec, http.StatusCreated, "")
+		own := decode[RespPutPlayer](t, rec).Token
+
+		for _, token := range []string{password, own} {
+			expect(t, call("DELETE", playersPath(id, "w"), "", bearer(token)...), http.StatusUnauthorized, CodeInvalidToken)
+			expect(t, call("POST", tokenPath(id, "w"), "", bearer(token)...), http.StatusConflict, CodeSeatManaged)
+		}
+		expect(t, call("DELETE", playersPath(id, "w"), "", APIKeyHeader, key), http.StatusUnauthorized, CodeMissingAuthorization)
+		game, _ := lookupGame(id)
+		if gameFinished(game) {
+			t.Errorf("%s: expected the game to go on", query)
+		}
+	}
+}
+
+func TestGameEndHookRunsOnce(t *testing.T) {
+	defer func(hook func(*data.Game)) { data.OnGameEnd = hook }(data.OnGameEnd)
+	var ended []int32
+	data.OnGameEnd = func(game *data.Game) { ended = append(ended, game.ID) }
+
+	id, password := newTestGame(t, false)
+	white := join(t, id, password, "w")
+	join(t, id, password, "b")
+	expect(t, call("DELETE", playersPath(id, "w"), "", bearer(white)...), http.StatusNoContent, "")
+	expect(t, call("DELETE", playersPath(id, "w"), "", bearer(white)...), http.StatusConflict, CodeGameOver)
+	if len(ended) != 1 || ended[0] != id {
+		t.Errorf("expected the game end hook to run once for game %d, got %v", id, ended)
+	}
+}
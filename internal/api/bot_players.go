@@ -0,0 +1,194 @@
+/*
+Bot processes seated as players. The server launches one process per seat
+and stops it once the game ends, is removed or the bot runs too long.
+*/
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/matetirpak/chessbot-playground-server/internal/bots"
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+	"github.com/matetirpak/chessbot-playground-server/internal/logging"
+	"github.com/matetirpak/chessbot-playground-server/internal/ratings"
+)
+
+// API URL passed to launched bots.
+var BotServerURL = "http://localhost:8080"
+
+// Directory of the bot logs, one file per game and color.
+var BotLogDir = filepath.Join(os.TempDir(), "chessbot-botlogs")
+
+// Maximum run time of a bot process. A bot exceeding it forfeits.
+var BotTimeout = time.Hour
+
+// Time a bot gets to exit on its own before it is killed.
+const botStopGrace = 2 * time.Second
+
+// Returns the bot registered under name.
+func lookupBot(name string) (bots.Bot, *apiError) {
+	bot, err := bots.Lookup(name)
+	if err != nil {
+		return bots.Bot{}, &apiError{http.StatusBadRequest, CodeUnknownBot, err.Error(), map[string]any{"bot": name, "bots": bots.Names()}}
+	}
+	return bot, nil
+}
+
+// Registers the bot as the player of the given color and launches it.
+// Only the bot process gets the seat's token, the caller could otherwise
+// play or forfeit for the bot.
+func seatBot(ctx context.Context, game *data.Game, color string, name string) *apiError {
+	bot, apiErr := lookupBot(name)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	if _, apiErr := joinGame(ctx, game, color, ratings.BotID(name)); apiErr != nil {
+		return apiErr
+	}
+	game.Mu.Lock()
+	if color == "w" {
+		game.WBot = name
+	} else {
+		game.BBot = name
+	}
+	// Reissued now that the seat is the bot's, so that it doesn't expire
+	token := issueToken(game, color)
+	seat := botSeat(game, color, token)
+	game.Mu.Unlock()
+
+	go superviseBot(game, color, bot, seat)
+	return nil
+}
+
+// Checks the bots requested for a new game, "" means no bot.
+func checkBots(names ...string) *apiError {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if _, apiErr := lookupBot(name); apiErr != nil {
+			return apiErr
+		}
+	}
+	return nil
+}
+
+// Launches the bots requested for a new game, "" leaves a seat open. On
+// failure the game is removed, which also stops a bot seated before.
+func seatBots(ctx context.Context, game *data.Game, white, black string) *apiError {
+	for color, name := range map[string]string{"w": white, "b": black} {
+		if name == "" {
+			continue
+		}
+		if apiErr := seatBot(ctx, game, color, name); apiErr != nil {
+			data.RemoveGame(game.ID)
+			return apiErr
+		}
+	}
+	return nil
+}
+
+// Returns the seat handed to a bot. Bots get their token in place of the
+// session password too, which v1 turn waiting accepts as well; the
+// password would let them take over the opponent's seat. Expects game.Mu
+// to be held.
+func botSeat(game *data.Game, color string, token string) bots.Seat {
+	return bots.Seat{Server: BotServerURL, BoardID: game.ID, Color: color, Token: token, Password: token, MoveTime: game.MoveTime}
+}
+
+// Relaunches the bots of unfinished games, e.g. after loading a snapshot.
+// Only token hashes are persisted, so each bot gets a new token. Call once
+// the API accepts connections.
+func ResumeBots() {
+	data.GamesMapMu.RLock()
+	defer data.GamesMapMu.RUnlock()
+
+	for _, game := range data.GamesMap {
+		game.Mu.Lock()
+		names := map[string]string{"w": game.WBot, "b": game.BBot}
+		seats := map[string]bots.Seat{}
+		if game.Winner == "n" {
+			for color, name := range names {
+				if name != "" {
+					seats[color] = botSeat(game, color, issueToken(game, color))
+				}
+			}
+		}
+		game.Mu.Unlock()
+
+		for color, seat := range seats {
+			bot, err := bots.Lookup(names[color])
+			if err != nil {
+				slog.Error("failed to resume bot", "boardid", game.ID, "color", color, "bot", names[color], "error", err)
+				continue
+			}
+			go superviseBot(game, color, bot, seat)
+		}
+	}
+}
+
+// Runs a bot process until the game ends, is removed or the server
+// freezes for shutdown. A bot that fails to start, exits early or exceeds
+// BotTimeout forfeits.
+func superviseBot(game *data.Game, color string, bot bots.Bot, seat bots.Seat) {
+	logger := slog.With("boardid", game.ID, "color", color, "bot", bot.Name)
+	ctx := logging.NewContext(context.Background(), logger)
+
+	logPath := filepath.Join(BotLogDir, fmt.Sprintf("%d-%s-%s.log", game.ID, color, bot.Name))
+	proc, err := bots.Launch(bot, seat, logPath)
+	if err != nil {
+		logger.Error("failed to launch bot", "error", err)
+		resignGame(ctx, game, color)
+		return
+	}
+	logger.Info("bot launched", "log", logPath)
+	defer proc.Stop(botStopGrace)
+
+	timeout := time.NewTimer(BotTimeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-game.Done:
+			logger.Info("bot stopped, game removed")
+			return
+		case <-proc.Done():
+			if shutdownStage.Load() >= stageFrozen || gameFinished(game) {
+				return
+			}
+			logger.Error("bot exited before the game ended", "error", proc.Err())
+			resignGame(ctx, game, color)
+			return
+		case <-timeout.C:
+			if !gameFinished(game) {
+				logger.Warn("bot timed out", "timeout", BotTimeout)
+				resignGame(ctx, game, color)
+			}
+			return
+		case <-ticker.C:
+		}
+
+		if shutdownStage.Load() >= stageFrozen {
+			return
+		}
+		if gameFinished(game) {
+			logger.Debug("bot stopped, game ended")
+			return
+		}
+	}
+}
+
+func gameFinished(game *data.Game) bool {
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+	return game.Winner != "n"
+}
@@ -0,0 +1,154 @@
+/*
+Built-in engines seated as players. Each seat runs a goroutine that plays
+whenever it's the engine's turn.
+*/
+package api
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+	"github.com/matetirpak/chessbot-playground-server/internal/engine"
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+	"github.com/matetirpak/chessbot-playground-server/internal/logging"
+	"github.com/matetirpak/chessbot-playground-server/internal/ratings"
+)
+
+// Registers the engine as the player of the given color and starts it.
+// The engine plays in-process, so the seat has no token.
+func seatEngine(ctx context.Context, game *data.Game, color string, name string) *apiError {
+	eng, err := engine.New(name)
+	if err != nil {
+		return &apiError{http.StatusBadRequest, CodeUnknownEngine, err.Error(), map[string]any{"engine": name, "engines": engine.Names()}}
+	}
+
+	if _, apiErr := joinGame(ctx, game, color, ratings.EngineID(name)); apiErr != nil {
+		return apiErr
+	}
+	game.Mu.Lock()
+	if color == "w" {
+		game.WEngine = name
+	} else {
+		game.BEngine = name
+	}
+	revokeToken(game, color)
+	game.Mu.Unlock()
+
+	go playEngine(game, color, name, eng)
+	return nil
+}
+
+// Restarts the engines of unfinished games, e.g. after loading a snapshot.
+func ResumeEngines() {
+	data.GamesMapMu.RLock()
+	defer data.GamesMapMu.RUnlock()
+
+	for _, game := range data.GamesMap {
+		game.Mu.RLock()
+		seats := map[string]string{"w": game.WEngine, "b": game.BEngine}
+		finished := game.Winner != "n"
+		game.Mu.RUnlock()
+		if finished {
+			continue
+		}
+
+		for color, name := range seats {
+			if name == "" {
+				continue
+			}
+			eng, err := engine.New(name)
+			if err != nil {
+				slog.Error("failed to resume engine", "boardid", game.ID, "color", color, "engine", name, "error", err)
+				continue
+			}
+			go playEngine(game, color, name, eng)
+		}
+	}
+}
+
+// Time an engine gets beyond its move time before it forfeits.
+var EngineMoveGrace = 5 * time.Second
+
+// Plays the moves of an engine until the game ends, is removed or the
+// server freezes for shutdown. An engine that fails to move in time
+// forfeits. Engines implementing io.Closer, e.g. external processes, are
+// closed afterwards.
+func playEngine(game *data.Game, color string, name string, eng engine.Engine) {
+	logger := slog.With("boardid", game.ID, "color", color, "engine", name)
+	ctx, cancel := context.WithCancel(logging.NewContext(context.Background(), logger))
+	defer cancel()
+	if closer, ok := eng.(io.Closer); ok {
+		defer closer.Close()
+	}
+	// Removing the game aborts a running search
+	go func() {
+		select {
+		case <-game.Done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-game.Done:
+			return
+		case <-ticker.C:
+		}
+		if shutdownStage.Load() >= stageFrozen {
+			return
+		}
+
+		game.Mu.RLock()
+		positions := append([]gl.BoardState(nil), game.BoardData...)
+		pos := positions[len(positions)-1]
+		winner := game.Winner
+		limits := engine.DefaultLimits
+		if game.MoveTime > 0 {
+			limits.MoveTime = game.MoveTime
+		}
+		game.Mu.RUnlock()
+
+		if winner != "n" {
+			logger.Debug("engine stopped, game ended")
+			return
+		}
+		if pos.TurnColor != color {
+			continue
+		}
+
+		move, err := bestMove(ctx, eng, positions, limits)
+		if ctx.Err() != nil || shutdownStage.Load() >= stageFrozen {
+			return
+		}
+		if err != nil {
+			logger.Error("engine failed to choose a move", "error", err)
+			resignGame(ctx, game, color)
+			return
+		}
+		if _, _, apiErr := playMove(ctx, game, color, gl.MoveToString(&move), false); apiErr != nil {
+			logger.Error("engine move rejected", "move", gl.MoveToString(&move), "code", apiErr.code, "error", apiErr.message)
+			resignGame(ctx, game, color)
+			return
+		}
+	}
+}
+
+// Asks the engine for a move of the last position, with the game history
+// if the engine takes it. The search is bounded by the move time plus
+// EngineMoveGrace.
+func bestMove(ctx context.Context, eng engine.Engine, positions []gl.BoardState, limits engine.Limits) (gl.Move, error) {
+	ctx, cancel := context.WithTimeout(ctx, limits.MoveTime+EngineMoveGrace)
+	defer cancel()
+	if history, ok := eng.(engine.HistoryEngine); ok {
+		return history.BestMoveInGame(ctx, positions, limits)
+	}
+	return eng.BestMove(ctx, positions[len(positions)-1], limits)
+}
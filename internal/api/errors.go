@@ -0,0 +1,80 @@
+/*
+JSON error responses with machine-readable codes.
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Error codes returned in RespError.Code. They are part of the API and
+// don't change, unlike the human readable messages.
+const (
+	CodeInvalidRequest       = "invalid_request"
+	CodeInvalidColor         = "invalid_color"
+	CodeMissingAuthorization = "missing_authorization"
+	CodeInvalidPassword      = "invalid_password"
+	CodeInvalidToken         = "invalid_token"
+	CodeTokenExpired         = "token_expired"
+	CodeInvalidAPIKey        = "invalid_api_key"
+	CodeInvalidAdminToken    = "invalid_admin_token"
+	CodeAdminDisabled        = "admin_disabled"
+	CodeBoardNotFound        = "board_not_found"
+	CodePlayerNotFound       = "player_not_found"
+	CodePositionNotFound     = "position_not_found"
+	CodeRouteNotFound        = "route_not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeGameFull             = "game_full"
+	CodeColorTaken           = "color_taken"
+	CodeSeatManaged          = "seat_managed"
+	CodeInvalidPlayer        = "invalid_player"
+	CodePlayerReserved       = "player_reserved"
+	CodeAccountNotFound      = "account_not_found"
+	CodeAccountExists        = "account_exists"
+	CodeRatingNotFound       = "rating_not_found"
+	CodeUnknownEngine        = "unknown_engine"
+	CodeUnknownBot           = "unknown_bot"
+	CodeUnknownOpenings      = "unknown_openings"
+	CodeUnknownBook          = "unknown_book"
+	CodeTournamentNotFound   = "tournament_not_found"
+	CodeTournamentOver       = "tournament_over"
+	CodeTicketNotFound       = "ticket_not_found"
+	CodeTicketMatched        = "ticket_matched"
+	CodeGameNotStarted       = "game_not_started"
+	CodeGameOver             = "game_over"
+	CodeNotYourTurn          = "not_your_turn"
+	CodeInvalidMoveFormat    = "invalid_move_format"
+	CodeOutOfBounds          = "out_of_bounds"
+	CodeNoPiece              = "no_piece"
+	CodePieceNotOwned        = "piece_not_owned"
+	CodeOwnPieceTarget       = "own_piece_target"
+	CodeIllegalMove          = "illegal_move"
+	CodeKingInCheck          = "king_in_check"
+	CodeMissingPromotion     = "missing_promotion"
+	CodeInvalidPromotion     = "invalid_promotion"
+	CodeNoLegalMoves         = "no_legal_moves"
+	CodeTurnTimeout          = "turn_timeout"
+	CodeRequestCancelled     = "request_cancelled"
+	CodeGameRemoved          = "game_removed"
+	CodeServerRestarting     = "server_restarting"
+	CodeInternal             = "internal_error"
+)
+
+// Writes a RespError with the given status code.
+func writeError(w http.ResponseWriter, status int, code string, message string, details map[string]any) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(RespError{Code: code, Message: message, Details: details})
+}
+
+// Handles requests to unknown routes.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusNotFound, CodeRouteNotFound, "Route not found.", map[string]any{"path": r.URL.Path})
+}
+
+// Handles requests with a method a route does not support.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "Method not allowed.", map[string]any{"method": r.Method})
+}
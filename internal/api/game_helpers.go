@@ -5,6 +5,10 @@ Helper functions for game data conversion.
 package api
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+
 	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
 )
 
@@ -16,7 +20,64 @@ func llToArray(move *gl.Move) []RespMove {
 			To:      current.To,
 			Capture: current.Capture,
 		}
+		if current.Promotion != 0 {
+			apiMove.Promotion = string(current.Promotion)
+		}
 		moves = append(moves, apiMove)
 	}
 	return moves
 }
+
+// Status and error code for each move validation error of game_logic.
+var moveErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{gl.ErrWrongTurn, http.StatusBadRequest, CodeNotYourTurn},
+	{gl.ErrInvalidFormat, http.StatusBadRequest, CodeInvalidMoveFormat},
+	{gl.ErrOutOfBounds, http.StatusBadRequest, CodeOutOfBounds},
+	{gl.ErrNotOwned, http.StatusForbidden, CodePieceNotOwned},
+	{gl.ErrNoPiece, http.StatusUnprocessableEntity, CodeNoPiece},
+	{gl.ErrOwnPieceTarget, http.StatusUnprocessableEntity, CodeOwnPieceTarget},
+	{gl.ErrIllegalPattern, http.StatusUnprocessableEntity, CodeIllegalMove},
+	{gl.ErrKingInCheck, http.StatusUnprocessableEntity, CodeKingInCheck},
+	{gl.ErrMissingPromotion, http.StatusUnprocessableEntity, CodeMissingPromotion},
+	{gl.ErrInvalidPromotion, http.StatusUnprocessableEntity, CodeInvalidPromotion},
+}
+
+// Returns the error response for a rejected move. The details contain the
+// legal moves of the piece on the move's origin square.
+func moveError(err error, moveStr string, move *gl.Move, bstate *gl.BoardState) *apiError {
+	status, code := http.StatusBadRequest, CodeIllegalMove
+	for _, moveErr := range moveErrors {
+		if errors.Is(err, moveErr.err) {
+			status, code = moveErr.status, moveErr.code
+			break
+		}
+	}
+
+	details := map[string]any{"move": moveStr, "legalmoves": []string{}}
+	if move != nil && !errors.Is(err, gl.ErrNotOwned) {
+		details["legalmoves"] = legalMovesFrom(move.From, bstate)
+	}
+	return &apiError{status, code, fmt.Sprintf("Move is invalid with error: %v", err), details}
+}
+
+// Returns the legal moves of the piece on the given square as strings.
+func legalMovesFrom(square [2]int, bstate *gl.BoardState) []string {
+	legal := []string{}
+	if square[0] < 0 || square[0] > 7 || square[1] < 0 || square[1] > 7 {
+		return legal
+	}
+	var moves *gl.Move
+	gl.GenerateMovesForPiece(square[0], square[1], bstate, &moves)
+	validMoves, err := gl.FilterInvalidMoves(moves, bstate)
+	if err != nil {
+		return legal
+	}
+	for current := validMoves; current != nil; current = current.Next {
+		legal = append(legal, gl.MoveToString(current))
+	}
+	return legal
+}
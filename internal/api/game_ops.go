@@ -0,0 +1,324 @@
+/*
+Game operations shared by the v1 and v2 handlers.
+*/
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/matetirpak/chessbot-playground-server/internal/accounts"
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+	"github.com/matetirpak/chessbot-playground-server/internal/logging"
+	"github.com/matetirpak/chessbot-playground-server/internal/metrics"
+	"github.com/matetirpak/chessbot-playground-server/internal/openings"
+)
+
+// Failed game operation. Handlers write it as RespError.
+type apiError struct {
+	status  int
+	code    string
+	message string
+	details map[string]any
+}
+
+func (e *apiError) write(w http.ResponseWriter) {
+	if e.code == CodeServerRestarting {
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", int(RetryAfter.Seconds())))
+	}
+	writeError(w, e.status, e.code, e.message, e.details)
+}
+
+// Returns the game with the given ID.
+func lookupGame(id int32) (*data.Game, *apiError) {
+	data.GamesMapMu.RLock()
+	game, exists := data.GamesMap[id]
+	data.GamesMapMu.RUnlock()
+	if !exists {
+		return nil, &apiError{http.StatusNotFound, CodeBoardNotFound, fmt.Sprintf("Game with index %d doesn't exist.", id), map[string]any{"boardid": id}}
+	}
+	return game, nil
+}
+
+// Creates a game and adds it to data.GamesMap. Returns the game and its
+// session password. The results of rated games update the ratings of the
+// players.
+func createGame(ctx context.Context, name string, rated bool) (*data.Game, string) {
+	newGame, password := initializeNewGame(name)
+	newGame.Rated = rated
+
+	data.GamesMapMu.Lock()
+	data.GamesMap[newGame.ID] = newGame
+	data.GamesMapMu.Unlock()
+	logging.AddAttrs(ctx, "boardid", newGame.ID)
+	logging.FromContext(ctx).Info("session created", "name", newGame.Name, "rated", rated)
+	return newGame, password
+}
+
+// Replaces the start position of a new game with the positions of an
+// opening. The game continues from the last one once both players joined.
+// Expects game.Mu to be held.
+func startFromOpening(game *data.Game, opening openings.Opening) {
+	game.BoardData = append([]gl.BoardState(nil), opening.Positions...)
+	latest := &game.BoardData[len(game.BoardData)-1]
+	game.StartTurn = latest.TurnColor
+	latest.TurnColor = "n"
+	game.Opening = opening.Name
+	game.OpeningPlies = opening.Plies()
+}
+
+// Registers a player of the given color and returns the player's token.
+// player is the rating identity of the player; rated games only take
+// accounts, engines and bots, see checkRatedPlayer.
+// Names of accounts are only let through by resolvePlayer with the
+// account's key, so the player is recorded as the account. The game starts
+// once both players joined.
+func joinGame(ctx context.Context, game *data.Game, color string, player string) (string, *apiError) {
+	if color != "w" && color != "b" {
+		return "", &apiError{http.StatusBadRequest, CodeInvalidColor, "Invalid color. Enter 'w' or 'b'", map[string]any{"color": color}}
+	}
+
+	game.Mu.Lock()
+	defer game.Mu.Unlock()
+
+	if game.Rated {
+		if apiErr := checkRatedPlayer(player); apiErr != nil {
+			return "", apiErr
+		}
+	}
+	if game.HasWPlayer && game.HasBPlayer {
+		return "", &apiError{http.StatusForbidden, CodeGameFull, "Game is already full.", nil}
+	}
+
+	account := ""
+	if player != "" && accounts.Exists(player) {
+		account = player
+	}
+	switch color {
+	case "w":
+		if game.HasWPlayer {
+			return "", &apiError{http.StatusForbidden, CodeColorTaken, "White is already taken.", map[string]any{"color": "w"}}
+		}
+		game.HasWPlayer = true
+		game.WPlayer = player
+		game.WAccount = account
+	case "b":
+		if game.HasBPlayer {
+			return "", &apiError{http.StatusForbidden, CodeColorTaken, "Black is already taken.", map[string]any{"color": "b"}}
+		}
+		game.HasBPlayer = true
+		game.BPlayer = player
+		game.BAccount = account
+	}
+	token := issueToken(game, color)
+	game.LastActivity = time.Now()
+	if game.HasWPlayer && game.HasBPlayer {
+		game.Started = true
+		turn := game.StartTurn
+		if turn == "" {
+			turn = "w"
+		}
+		game.BoardData[len(game.BoardData)-1].TurnColor = turn
+	}
+	logging.FromContext(ctx).Info("player joined", "player", player)
+	return token, nil
+}
+
+// Ends the game in favor of the opponent of the given color on behalf of
+// a client. Seats of built-in engines can't be forfeited that way.
+func forfeitGame(ctx context.Context, game *data.Game, color string) *apiError {
+	if apiErr := checkClientSeat(game, color); apiErr != nil {
+		return apiErr
+	}
+	resignGame(ctx, game, color)
+	return nil
+}
+
+// Ends the game in favor of the opponent of the given color, for seats the
+// server plays.
+func resignGame(ctx context.Context, game *data.Game, color string) {
+	winner := "w"
+	if color == "w" {
+		winner = "b"
+	}
+	if endGame(game, winner, "forfeit") {
+		metrics.Forfeits.Inc()
+		logging.FromContext(ctx).Info("game forfeited")
+	}
+}
+
+// Ends a running game with the given winner ("w", "b" or "r") and
+// termination, see data.EndGame. Returns false if the game had already
+// ended.
+func endGame(game *data.Game, winner string, termination string) bool {
+	game.Mu.Lock()
+	defer game.Mu.Unlock()
+	return data.EndGame(game, winner, termination, time.Now())
+}
+
+// Returns the position after the given number of plies. -1 is the latest position.
+func gamePosition(game *data.Game, ply int) (gl.BoardState, *apiError) {
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+
+	nSteps := len(game.BoardData)
+	if ply == -1 {
+		ply = nSteps - 1
+	}
+	if ply < 0 || ply > nSteps-1 {
+		return gl.BoardState{}, &apiError{http.StatusNotFound, CodePositionNotFound, fmt.Sprintf("Board at index %d does not exist.", ply), map[string]any{"moveidx": ply, "positions": nSteps}}
+	}
+	return game.BoardData[ply], nil
+}
+
+// Returns the legal moves of the piece on the given square in the latest position.
+func pieceLegalMoves(ctx context.Context, game *data.Game, row, col int) (*gl.Move, *apiError) {
+	var moves *gl.Move
+
+	game.Mu.RLock()
+	bstate := game.BoardData[len(game.BoardData)-1]
+	game.Mu.RUnlock()
+
+	gl.GenerateMovesForPiece(row, col, &bstate, &moves)
+	start := time.Now()
+	validMoves, err := gl.FilterInvalidMoves(moves, &bstate)
+	metrics.ObserveValidation("FilterInvalidMoves", start)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to validate generated moves", "row", row, "col", col, "error", err)
+		return nil, &apiError{http.StatusInternalServerError, CodeInternal, "Failed to validate generated moves.", map[string]any{"error": err.Error()}}
+	}
+	return validMoves, nil
+}
+
+// Blocks until it's the given color's turn or the game has ended.
+func waitForTurn(ctx context.Context, game *data.Game, color string) *apiError {
+	if shutdownStage.Load() >= stageDraining {
+		return restartingError()
+	}
+	waitingClients.Add(1)
+	defer waitingClients.Add(-1)
+	longPolls := metrics.OpenConnections.WithLabelValues("longpoll")
+	longPolls.Inc()
+	defer longPolls.Dec()
+
+	timeout := time.After(TurnTimeout)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-timeout:
+			metrics.Timeouts.WithLabelValues("turn").Inc()
+			return &apiError{http.StatusRequestTimeout, CodeTurnTimeout, "Timeout waiting for turn.", nil}
+		case <-ticker.C:
+			// Check for current player's turn
+			game.Mu.RLock()
+			currentTurn := game.BoardData[len(game.BoardData)-1].TurnColor
+			winner := game.Winner
+			game.Mu.RUnlock()
+
+			if currentTurn == color {
+				return nil
+			}
+			if winner != "n" {
+				return &apiError{http.StatusBadRequest, CodeGameOver, "Game has ended.", map[string]any{"winner": winner}}
+			}
+		case <-shutdownChan:
+			return restartingError()
+		case <-game.Done:
+			return &apiError{http.StatusGone, CodeGameRemoved, "Game was removed while waiting for turn.", nil}
+		case <-ctx.Done():
+			return &apiError{http.StatusRequestTimeout, CodeRequestCancelled, "Request was cancelled while waiting for turn.", nil}
+		}
+	}
+}
+
+// Applies a move a client sent for the given color, see playMove. Seats of
+// built-in engines only take the engine's moves.
+func applyMove(ctx context.Context, game *data.Game, color string, moveStr string, random bool) (gl.Move, int, *apiError) {
+	if apiErr := checkClientSeat(game, color); apiErr != nil {
+		return gl.Move{}, 0, apiErr
+	}
+	return playMove(ctx, game, color, moveStr, random)
+}
+
+// Applies a move of the given color. With random set, moveStr is ignored
+// and a random legal move is played. Returns the applied move and the ply
+// of the resulting position.
+func playMove(ctx context.Context, game *data.Game, color string, moveStr string, random bool) (gl.Move, int, *apiError) {
+	logger := logging.FromContext(ctx)
+	reqType := "move"
+	if random {
+		reqType = "randommove"
+	}
+
+	game.Mu.Lock()
+	defer game.Mu.Unlock()
+
+	if !game.Started {
+		return gl.Move{}, 0, &apiError{http.StatusBadRequest, CodeGameNotStarted, "Can't apply move. Game has not started.", nil}
+	}
+	if game.Winner != "n" {
+		return gl.Move{}, 0, &apiError{http.StatusBadRequest, CodeGameOver, "Can't apply move. Game has ended.", map[string]any{"winner": game.Winner}}
+	}
+	latestBoardState := game.BoardData[len(game.BoardData)-1]
+	if latestBoardState.TurnColor != color {
+		return gl.Move{}, 0, &apiError{http.StatusBadRequest, CodeNotYourTurn, "Can't apply move. It's not the players turn.", map[string]any{"turncolor": latestBoardState.TurnColor}}
+	}
+
+	var move gl.Move
+	if !random {
+		var err error
+		move, err = gl.StringToMoveStruct(moveStr, rune(color[0]))
+		if err != nil {
+			return gl.Move{}, 0, moveError(err, moveStr, nil, &latestBoardState)
+		}
+
+		// Check validity of move
+		start := time.Now()
+		err = gl.ValidateMove(&move, &latestBoardState)
+		metrics.ObserveValidation("ValidateMove", start)
+		if err != nil {
+			logger.Debug("move rejected", "error", err)
+			return gl.Move{}, 0, moveError(err, moveStr, &move, &latestBoardState)
+		}
+	} else {
+		moves := gl.AllPossibleMoves(rune(color[0]), &latestBoardState, nil)
+		start := time.Now()
+		validMoves, err := gl.FilterInvalidMoves(moves, &latestBoardState)
+		metrics.ObserveValidation("FilterInvalidMoves", start)
+		if err != nil {
+			logger.Error("failed to validate generated moves", "error", err)
+			return gl.Move{}, 0, &apiError{http.StatusInternalServerError, CodeInternal, "Failed to validate generated moves.", map[string]any{"error": err.Error()}}
+		}
+		nMoves := gl.NMoves(validMoves)
+		if nMoves == 0 {
+			return gl.Move{}, 0, &apiError{http.StatusInternalServerError, CodeNoLegalMoves, "No moves found.", nil}
+		}
+		move = *gl.MoveAt(validMoves, rand.Intn(nMoves))
+	}
+
+	newBstate, err := gl.MakeMove(&move, latestBoardState, true)
+	game.LastActivity = time.Now()
+	game.BoardData = append(game.BoardData, newBstate)
+	metrics.MovesApplied.WithLabelValues(reqType).Inc()
+
+	logger = logger.With("applied", gl.MoveToString(&move))
+	if err != nil {
+		// The move itself is legal, only the game end could not be determined
+		logger.Error("failed to detect game end", "error", err)
+	}
+	if newBstate.Winner != "n" {
+		// Decided on the board, so without termination
+		data.EndGame(game, newBstate.Winner, "", game.LastActivity)
+		logger.Info("game ended", "winner", newBstate.Winner)
+	} else {
+		logger.Debug("move applied")
+	}
+	return move, len(game.BoardData) - 1, nil
+}
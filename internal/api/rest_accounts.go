@@ -0,0 +1,260 @@
+/*
+Account endpoints of the v2 API and the authentication of requests with
+API keys. Accounts are created and deleted with the admin token of the
+server; a client sends its key in the X-API-Key header to join games, enter
+matchmaking and, in v2, play without a player token.
+*/
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/matetirpak/chessbot-playground-server/internal/accounts"
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+	"github.com/matetirpak/chessbot-playground-server/internal/logging"
+	"github.com/matetirpak/chessbot-playground-server/internal/ratings"
+)
+
+// Header carrying the API key of an account.
+const APIKeyHeader = "X-API-Key"
+
+// Token of the account administration, empty disables it.
+var AdminToken string
+
+// Directory the accounts are saved to after every change, so that keys
+// handed out survive a crash. Empty keeps them in memory.
+var AccountsDir string
+
+// PostAccountsV2 godoc
+//
+//	@Summary		Creates an account
+//	@Description	Creates an account with an API key. The key is only returned here; the server keeps a hash of it.
+//	@Description	The name becomes the account's player name: clients with the key are rated under it, others can't join with it anymore.
+//	@Tags			accounts
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		ReqPostAccount		true	"Name of the account"
+//	@Success		201		{object}	RespPostAccount			"Account with its API key"
+//	@Failure		400		{object}	RespError				"Invalid JSON body or name"
+//	@Failure		401		{object}	RespError				"Missing or invalid admin token"
+//	@Failure		403		{object}	RespError				"Account administration is disabled"
+//	@Failure		409		{object}	RespError				"Account already exists"
+//	@Router			/chessserver/v2/accounts [post]
+func PostAccountsV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if apiErr := authorizeAdmin(r); apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+
+	var req ReqPostAccount
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid request payload.", map[string]any{"error": err.Error()})
+		return
+	}
+	if err := ratings.ValidName(req.Name); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidPlayer, err.Error(), map[string]any{"name": req.Name})
+		return
+	}
+
+	account, key, err := accounts.Create(req.Name)
+	if errors.Is(err, accounts.ErrAccountExists) {
+		writeError(w, http.StatusConflict, CodeAccountExists, "Account already exists.", map[string]any{"name": req.Name})
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to create the API key.", map[string]any{"error": err.Error()})
+		return
+	}
+	logging.AddAttrs(r.Context(), "account", account.Name)
+	logging.FromContext(r.Context()).Info("account created")
+	saveAccounts(r.Context())
+
+	w.Header().Set("Location", "/chessserver/v2/accounts/"+account.Name)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(RespPostAccount{RespAccount: accountResource(account), Key: key})
+}
+
+// GetAccountsV2 godoc
+//
+//	@Summary		Lists all accounts
+//	@Tags			accounts
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200		{object}	RespAccounts		"All accounts"
+//	@Failure		401		{object}	RespError			"Missing or invalid admin token"
+//	@Failure		403		{object}	RespError			"Account administration is disabled"
+//	@Router			/chessserver/v2/accounts [get]
+func GetAccountsV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if apiErr := authorizeAdmin(r); apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+
+	resp := RespAccounts{Accounts: []RespAccount{}}
+	for _, account := range accounts.List() {
+		resp.Accounts = append(resp.Accounts, accountResource(account))
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetAccountV2 godoc
+//
+//	@Summary		Returns an account
+//	@Description	Returns the account with its rating. Its games are listed by /chessserver/v2/games?player=<name>.
+//	@Tags			accounts
+//	@Produce		json
+//	@Param			name	path		string		true	"Account name"
+//	@Success		200		{object}	RespAccount			"Account"
+//	@Failure		404		{object}	RespError			"Account does not exist"
+//	@Router			/chessserver/v2/accounts/{name} [get]
+func GetAccountV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	name := mux.Vars(r)["name"]
+	account, exists := accounts.Get(name)
+	if !exists {
+		writeError(w, http.StatusNotFound, CodeAccountNotFound, fmt.Sprintf("Account %q doesn't exist.", name), map[string]any{"name": name})
+		return
+	}
+	json.NewEncoder(w).Encode(accountResource(account))
+}
+
+// DeleteAccountV2 godoc
+//
+//	@Summary		Deletes an account
+//	@Description	Deletes an account, its key stops working. The rating is kept and the name can be taken again.
+//	@Tags			accounts
+//	@Security		BearerAuth
+//	@Param			name	path		string		true	"Account name"
+//	@Success		204		"Deleted"
+//	@Failure		401		{object}	RespError	"Missing or invalid admin token"
+//	@Failure		403		{object}	RespError	"Account administration is disabled"
+//	@Failure		404		{object}	RespError	"Account does not exist"
+//	@Router			/chessserver/v2/accounts/{name} [delete]
+func DeleteAccountV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if apiErr := authorizeAdmin(r); apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	if err := accounts.Delete(name); err != nil {
+		writeError(w, http.StatusNotFound, CodeAccountNotFound, fmt.Sprintf("Account %q doesn't exist.", name), map[string]any{"name": name})
+		return
+	}
+	logging.AddAttrs(r.Context(), "account", name)
+	logging.FromContext(r.Context()).Info("account deleted")
+	saveAccounts(r.Context())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Persists the accounts if AccountsDir is set. Failures are logged only,
+// the accounts are saved again on shutdown.
+func saveAccounts(ctx context.Context) {
+	if AccountsDir == "" {
+		return
+	}
+	if err := accounts.SaveSnapshot(AccountsDir); err != nil {
+		logging.FromContext(ctx).Error("failed to persist accounts", "error", err)
+	}
+}
+
+// Checks that the request carries the admin token.
+func authorizeAdmin(r *http.Request) *apiError {
+	if AdminToken == "" {
+		return &apiError{http.StatusForbidden, CodeAdminDisabled, "Account administration is disabled, the server has no admin token.", nil}
+	}
+	token, apiErr := bearerToken(r)
+	if apiErr != nil {
+		return apiErr
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(AdminToken)) != 1 {
+		return &apiError{http.StatusUnauthorized, CodeInvalidAdminToken, "Invalid admin token.", nil}
+	}
+	return nil
+}
+
+// Returns the account of the request's API key, nil if it has none.
+func requestAccount(r *http.Request) (*accounts.Account, *apiError) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, nil
+	}
+	account, ok := accounts.Authenticate(key)
+	if !ok {
+		return nil, &apiError{http.StatusUnauthorized, CodeInvalidAPIKey, "API key is invalid.", nil}
+	}
+	logging.AddAttrs(r.Context(), "account", account.Name)
+	return &account, nil
+}
+
+// Returns the player name a client joins with: the account of its API key,
+// otherwise the given name, "" being anonymous. Names of accounts need the
+// account's key.
+func resolvePlayer(r *http.Request, player string) (string, *apiError) {
+	account, apiErr := requestAccount(r)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	if account != nil {
+		if player != "" && player != account.Name {
+			return "", &apiError{http.StatusBadRequest, CodeInvalidPlayer, "Player name differs from the account of the API key.", map[string]any{"player": player, "account": account.Name}}
+		}
+		return account.Name, nil
+	}
+	if apiErr := checkPlayer(player); apiErr != nil {
+		return "", apiErr
+	}
+	if accounts.Exists(player) {
+		return "", &apiError{http.StatusForbidden, CodePlayerReserved, "Player name belongs to an account, authenticate with its API key.", map[string]any{"player": player}}
+	}
+	return player, nil
+}
+
+// Returns the color the account of the request's API key plays. Accounts
+// playing both colors have to use the player tokens.
+func accountColor(r *http.Request, game *data.Game) (string, *apiError) {
+	account, apiErr := requestAccount(r)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	if account == nil {
+		return "", &apiError{http.StatusUnauthorized, CodeMissingAuthorization, "Missing or invalid Authorization header", nil}
+	}
+
+	game.Mu.RLock()
+	white, black := game.WAccount == account.Name, game.BAccount == account.Name
+	game.Mu.RUnlock()
+	switch {
+	case white && black:
+		return "", &apiError{http.StatusBadRequest, CodeInvalidToken, "The account plays both colors, use the player token.", nil}
+	case white:
+		return "w", nil
+	case black:
+		return "b", nil
+	}
+	return "", &apiError{http.StatusUnauthorized, CodeInvalidAPIKey, "The account doesn't play in this game.", nil}
+}
+
+func accountResource(account accounts.Account) RespAccount {
+	resp := RespAccount{Name: account.Name, CreatedAt: account.CreatedAt}
+	if rating, exists := ratings.Get(account.Name); exists {
+		entry := ratingResource(rating)
+		resp.Rating = &entry
+	}
+	return resp
+}
@@ -0,0 +1,86 @@
+/*
+Opening book endpoints of the v2 API.
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+	"github.com/matetirpak/chessbot-playground-server/internal/polyglot"
+)
+
+// GetBookMovesV2 godoc
+//
+//	@Summary		Returns the book moves of a position
+//	@Description	Looks up a position of the game in a Polyglot opening book of the server and returns the book moves with their weights, the most weighted first.
+//	@Description	An empty list means the position is out of book. The book can be omitted if the server has only one.
+//	@Description	Requires the game password or a player token.
+//	@Tags			v2
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int			true	"Game ID"
+//	@Param			book	query		string		false	"Name of the book"
+//	@Param			ply		query		int			false	"Position after this many plies, default the latest"
+//	@Success		200		{object}	RespBookMoves		"Book moves"
+//	@Failure		400		{object}	RespError			"Invalid game ID, ply or unknown book"
+//	@Failure		401		{object}	RespError			"Missing or invalid token"
+//	@Failure		404		{object}	RespError			"Game or position does not exist"
+//	@Router			/chessserver/v2/games/{id}/book-moves [get]
+func GetBookMovesV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	game, apiErr := gameFromPath(r)
+	if apiErr == nil {
+		apiErr = authorizeRead(r, game)
+	}
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+
+	name := r.URL.Query().Get("book")
+	if names := polyglot.Names(); name == "" && len(names) == 1 {
+		name = names[0]
+	}
+	book, err := polyglot.Lookup(name)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeUnknownBook, err.Error(), map[string]any{"book": name, "books": polyglot.Names()})
+		return
+	}
+
+	ply := -1
+	if plyStr := r.URL.Query().Get("ply"); plyStr != "" {
+		if ply, err = strconv.Atoi(plyStr); err != nil || ply < 0 {
+			writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Ply has to be a non-negative number.", map[string]any{"ply": plyStr})
+			return
+		}
+	}
+	pos, apiErr := gamePosition(game, ply)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+
+	entries, err := book.Moves(&pos)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to validate book moves.", map[string]any{"error": err.Error()})
+		return
+	}
+	total := 0
+	for _, entry := range entries {
+		total += int(entry.Weight)
+	}
+	resp := RespBookMoves{Book: name, Key: fmt.Sprintf("%016x", polyglot.Key(&pos)), Moves: []RespBookMove{}}
+	for _, entry := range entries {
+		move := RespBookMove{Move: gl.MoveToString(&entry.Move), Weight: int(entry.Weight)}
+		if total > 0 {
+			move.Share = float64(entry.Weight) / float64(total)
+		}
+		resp.Moves = append(resp.Moves, move)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
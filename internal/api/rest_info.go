@@ -0,0 +1,95 @@
+/*
+API for server health and information.
+*/
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/matetirpak/chessbot-playground-server/internal/bots"
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+	"github.com/matetirpak/chessbot-playground-server/internal/engine"
+	"github.com/matetirpak/chessbot-playground-server/internal/openings"
+	"github.com/matetirpak/chessbot-playground-server/internal/polyglot"
+	"github.com/matetirpak/chessbot-playground-server/internal/status"
+)
+
+// Optional server features and whether they are enabled, reported by GetInfo.
+var FeatureToggles = map[string]bool{}
+
+// GetHealthz godoc
+//
+//	@Summary		Liveness probe
+//	@Description	Returns 200 as long as the server process is able to handle requests.
+//	@Tags			health
+//	@Produce		json
+//	@Success		200		{object}	RespHealth		"Server is alive"
+//	@Router			/healthz [get]
+func GetHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	json.NewEncoder(w).Encode(RespHealth{Status: "ok"})
+}
+
+// GetReadyz godoc
+//
+//	@Summary		Readiness probe
+//	@Description	Reports whether persisted games were recovered and the web UI files were loaded. Returns 503 while a check fails or the server shuts down.
+//	@Tags			health
+//	@Produce		json
+//	@Success		200		{object}	RespHealth		"Server is ready"
+//	@Failure		503		{object}	RespHealth		"At least one check failed"
+//	@Router			/readyz [get]
+func GetReadyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	checks, ready := status.Checks()
+	resp := RespHealth{Status: "ok", Checks: checks}
+	if !ready {
+		resp.Status = "unavailable"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetInfo godoc
+//
+//	@Summary		Server information
+//	@Description	Returns the build version, uptime, supported API versions, enabled features and the number of active and finished games.
+//	@Tags			health
+//	@Produce		json
+//	@Success		200		{object}	RespInfo		"Server information"
+//	@Router			/chessserver/v1/info [get]
+func GetInfo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	resp := RespInfo{
+		Version:       status.BuildVersion(),
+		UptimeSeconds: int64(status.Uptime().Seconds()),
+		APIVersions:   []string{"v1", "v2"},
+		Features: InfoFeatures{
+			Variants:  []string{"standard"},
+			Clocks:    []string{},
+			Notations: []string{"coordinate"},
+			Engines:   engine.Names(),
+			Bots:      bots.Names(),
+			Openings:  openings.Names(),
+			Books:     polyglot.Names(),
+			Toggles:   FeatureToggles,
+		},
+	}
+
+	data.GamesMapMu.RLock()
+	for _, game := range data.GamesMap {
+		game.Mu.RLock()
+		if game.Winner == "n" {
+			resp.Games.Active++
+		} else {
+			resp.Games.Finished++
+		}
+		game.Mu.RUnlock()
+	}
+	data.GamesMapMu.RUnlock()
+
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,304 @@
+/*
+Matchmaking endpoints of the v2 API. Bots enter a queue instead of
+exchanging board IDs and passwords out of band; the server pairs them,
+creates the game and seats both.
+*/
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+	"github.com/matetirpak/chessbot-playground-server/internal/logging"
+	"github.com/matetirpak/chessbot-playground-server/internal/matchmaking"
+	"github.com/matetirpak/chessbot-playground-server/internal/metrics"
+	"github.com/matetirpak/chessbot-playground-server/internal/ratings"
+)
+
+// Longest a request waits for a match.
+const maxMatchmakingWait = time.Minute
+
+// PostMatchmakingV2 godoc
+//
+//	@Summary		Enters the matchmaking queue
+//	@Description	Asks for a game against another bot. Tickets are paired with the oldest waiting ticket of the same move time and rated flag whose rating is within the requested range, and vice versa.
+//	@Description	The server then creates the game with random colors and seats both bots. Poll the ticket with its secret to get the seat; tickets nobody polls for a minute are dropped, matched ones a minute after the match.
+//	@Description	With 'wait', the request itself waits up to that long for a match, e.g. "30s".
+//	@Tags			matchmaking
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		ReqPostMatchmaking	true	"Time control and opponent rating range"
+//	@Param			X-API-Key	header		string				false	"API key of an account, which plays under its name"
+//	@Success		201		{object}	RespTicket				"Ticket, Location header points to it"
+//	@Failure		400		{object}	RespError				"Invalid JSON body, player name, move time or rating range"
+//	@Failure		401		{object}	RespError				"Invalid API key"
+//	@Failure		403		{object}	RespError				"Player name belongs to an account"
+//	@Failure		503		{object}	RespError				"Server restarting, see Retry-After header"
+//	@Router			/chessserver/v2/matchmaking [post]
+func PostMatchmakingV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if rejectDuringShutdown(w, stageDraining) {
+		return
+	}
+
+	var req ReqPostMatchmaking
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid request payload.", map[string]any{"error": err.Error()})
+		return
+	}
+	player, apiErr := resolvePlayer(r, req.Player)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	req.Player = player
+	if req.Rated {
+		if apiErr := checkRatedPlayer(req.Player); apiErr != nil {
+			apiErr.write(w)
+			return
+		}
+	}
+	if req.MinRating < 0 || req.MaxRating < 0 || (req.MaxRating > 0 && req.MinRating > req.MaxRating) {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid rating range.", map[string]any{"minrating": req.MinRating, "maxrating": req.MaxRating})
+		return
+	}
+	request := matchmaking.Request{Player: req.Player, Rated: req.Rated, MinRating: req.MinRating, MaxRating: req.MaxRating}
+	if req.MoveTime != "" {
+		moveTime, err := time.ParseDuration(req.MoveTime)
+		if err != nil || moveTime < 0 {
+			writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid move time.", map[string]any{"movetime": req.MoveTime})
+			return
+		}
+		request.MoveTime = moveTime
+	}
+	wait, apiErr := parseWait(req.Wait)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	request.Rating = ratings.Initial(req.Player).Rating
+	if rating, exists := ratings.Get(req.Player); exists {
+		request.Rating = rating.Rating
+	}
+
+	secret := generateToken()
+	ticket, opponent := matchmaking.Join(generateToken(), data.HashSecret(secret), request)
+	logging.AddAttrs(r.Context(), "player", req.Player)
+	if opponent != nil {
+		startMatch(opponent, ticket)
+	} else {
+		logging.FromContext(r.Context()).Info("entered matchmaking queue")
+	}
+	state := ticket.State()
+	if wait > 0 {
+		if state, apiErr = waitForMatch(r.Context(), ticket, wait); apiErr != nil {
+			apiErr.write(w)
+			return
+		}
+	}
+
+	resp := ticketResource(state)
+	resp.Secret = secret
+	w.Header().Set("Location", "/chessserver/v2/matchmaking/"+state.ID)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetMatchmakingTicketV2 godoc
+//
+//	@Summary		Returns a matchmaking ticket
+//	@Description	Returns the ticket with the seat once matched. With 'wait', the request waits up to that long for a match (long-poll), e.g. "30s", at most a minute.
+//	@Description	Requires the secret the ticket was created with.
+//	@Tags			matchmaking
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			ticket	path		string		true	"Ticket ID"
+//	@Param			wait	query		string		false	"Time to wait for a match"
+//	@Success		200		{object}	RespTicket			"Ticket"
+//	@Failure		400		{object}	RespError			"Invalid wait"
+//	@Failure		401		{object}	RespError			"Missing or invalid ticket secret"
+//	@Failure		404		{object}	RespError			"Ticket does not exist or expired"
+//	@Failure		503		{object}	RespError			"Server restarting, see Retry-After header"
+//	@Router			/chessserver/v2/matchmaking/{ticket} [get]
+func GetMatchmakingTicketV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	ticket, apiErr := ticketFromPath(r)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	wait, apiErr := parseWait(r.URL.Query().Get("wait"))
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+
+	state := ticket.State()
+	if wait > 0 {
+		if state, apiErr = waitForMatch(r.Context(), ticket, wait); apiErr != nil {
+			apiErr.write(w)
+			return
+		}
+	}
+	json.NewEncoder(w).Encode(ticketResource(state))
+}
+
+// DeleteMatchmakingTicketV2 godoc
+//
+//	@Summary		Leaves the matchmaking queue
+//	@Description	Requires the secret the ticket was created with.
+//	@Tags			matchmaking
+//	@Security		BearerAuth
+//	@Param			ticket	path		string		true	"Ticket ID"
+//	@Success		204		"Ticket removed"
+//	@Failure		401		{object}	RespError	"Missing or invalid ticket secret"
+//	@Failure		404		{object}	RespError	"Ticket does not exist or expired"
+//	@Failure		409		{object}	RespError	"Ticket was already paired"
+//	@Router			/chessserver/v2/matchmaking/{ticket} [delete]
+func DeleteMatchmakingTicketV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	ticket, apiErr := ticketFromPath(r)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	id := ticket.State().ID
+	left, err := matchmaking.Leave(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, CodeTicketNotFound, "Ticket doesn't exist.", map[string]any{"ticket": id})
+		return
+	}
+	if !left {
+		writeError(w, http.StatusConflict, CodeTicketMatched, "Ticket was already paired.", map[string]any{"ticket": id})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Returns the ticket of the path if the request carries its secret. The
+// secret isn't part of the path, which ends up in the request log.
+func ticketFromPath(r *http.Request) (*matchmaking.Ticket, *apiError) {
+	id := mux.Vars(r)["ticket"]
+	ticket, err := matchmaking.Lookup(id)
+	if err != nil {
+		return nil, &apiError{http.StatusNotFound, CodeTicketNotFound, "Ticket doesn't exist.", map[string]any{"ticket": id}}
+	}
+	secret, apiErr := bearerToken(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if !data.MatchesHash(ticket.State().SecretHash, secret) {
+		return nil, &apiError{http.StatusUnauthorized, CodeInvalidToken, "Invalid ticket secret.", nil}
+	}
+	return ticket, nil
+}
+
+// Parses the time a request waits for a match, "" for none.
+func parseWait(waitStr string) (time.Duration, *apiError) {
+	if waitStr == "" {
+		return 0, nil
+	}
+	wait, err := time.ParseDuration(waitStr)
+	if err != nil || wait < 0 {
+		return 0, &apiError{http.StatusBadRequest, CodeInvalidRequest, "Invalid wait.", map[string]any{"wait": waitStr}}
+	}
+	return min(wait, maxMatchmakingWait), nil
+}
+
+// Waits until the ticket is resolved or the wait is over. Like waiting for
+// the turn, it gives way to a shutdown.
+func waitForMatch(ctx context.Context, ticket *matchmaking.Ticket, wait time.Duration) (matchmaking.State, *apiError) {
+	if shutdownStage.Load() >= stageDraining {
+		return matchmaking.State{}, restartingError()
+	}
+	waitingClients.Add(1)
+	defer waitingClients.Add(-1)
+	longPolls := metrics.OpenConnections.WithLabelValues("longpoll")
+	longPolls.Inc()
+	defer longPolls.Dec()
+
+	state := ticket.Wait(ctx, wait, shutdownChan)
+	if state.Status == matchmaking.StatusWaiting && shutdownStage.Load() >= stageDraining {
+		return matchmaking.State{}, restartingError()
+	}
+	return state, nil
+}
+
+// Creates the game of two paired tickets and seats both players.
+func startMatch(first, second *matchmaking.Ticket) {
+	a, b := first.State(), second.State()
+	if rand.Intn(2) == 1 {
+		a, b = b, a
+		first, second = second, first
+	}
+	logger := slog.With("white", a.Request.Player, "black", b.Request.Player)
+	ctx := logging.NewContext(context.Background(), logger)
+
+	name := fmt.Sprintf("Matchmaking: %s - %s", displayName(a.Request.Player), displayName(b.Request.Player))
+	game, _ := createGame(ctx, name, a.Request.Rated)
+	game.Mu.Lock()
+	game.MoveTime = a.Request.MoveTime
+	game.Mu.Unlock()
+
+	whiteToken, apiErr := joinGame(ctx, game, "w", a.Request.Player)
+	var blackToken string
+	if apiErr == nil {
+		blackToken, apiErr = joinGame(ctx, game, "b", b.Request.Player)
+	}
+	if apiErr != nil {
+		data.RemoveGame(game.ID)
+		err := errors.New(apiErr.message)
+		first.Fail(err)
+		second.Fail(err)
+		logger.Error("failed to start matchmaking game", "error", err)
+		return
+	}
+
+	first.Resolve(matchmaking.Match{BoardID: game.ID, Color: "w", Token: whiteToken, Opponent: b.Request.Player})
+	second.Resolve(matchmaking.Match{BoardID: game.ID, Color: "b", Token: blackToken, Opponent: a.Request.Player})
+	logger.Info("matchmaking game started", "boardid", game.ID, "movetime", a.Request.MoveTime)
+
+	if a.Request.MoveTime > 0 {
+		go watchGame(ctx, game, a.Request.MoveTime, 0)
+	}
+}
+
+func displayName(player string) string {
+	if player == "" {
+		return "anonymous"
+	}
+	return player
+}
+
+func ticketResource(state matchmaking.State) RespTicket {
+	resp := RespTicket{
+		ID:        state.ID,
+		Status:    string(state.Status),
+		Player:    state.Request.Player,
+		Rated:     state.Request.Rated,
+		MinRating: state.Request.MinRating,
+		MaxRating: state.Request.MaxRating,
+		Error:     state.Error,
+		CreatedAt: state.CreatedAt,
+	}
+	if state.Request.MoveTime > 0 {
+		resp.MoveTime = state.Request.MoveTime.String()
+	}
+	if state.Status == matchmaking.StatusMatched {
+		m := state.Match
+		resp.Match = &RespMatch{BoardID: m.BoardID, Color: m.Color, Token: m.Token, Opponent: m.Opponent}
+	}
+	return resp
+}
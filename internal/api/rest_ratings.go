@@ -0,0 +1,108 @@
+/*
+Rating endpoints of the v2 API.
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/matetirpak/chessbot-playground-server/internal/accounts"
+	"github.com/matetirpak/chessbot-playground-server/internal/ratings"
+)
+
+// GetRatingsV2 godoc
+//
+//	@Summary		Returns the leaderboard
+//	@Description	Glicko-2 ratings of all players of rated games, highest first. Engines are listed as "engine:<name>", bots as "bot:<name>", accounts under their names with 'account' set.
+//	@Tags			ratings
+//	@Produce		json
+//	@Param			mingames	query		int			false	"Only players with at least this many games"
+//	@Success		200			{object}	RespLeaderboard		"Leaderboard"
+//	@Failure		400			{object}	RespError			"Invalid mingames"
+//	@Router			/chessserver/v2/ratings [get]
+func GetRatingsV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	minGames := 0
+	if minStr := r.URL.Query().Get("mingames"); minStr != "" {
+		var err error
+		if minGames, err = strconv.Atoi(minStr); err != nil || minGames < 0 {
+			writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid mingames.", map[string]any{"mingames": minStr})
+			return
+		}
+	}
+
+	resp := RespLeaderboard{Players: []RespRating{}}
+	for _, rating := range ratings.Leaderboard() {
+		if rating.Games < minGames {
+			continue
+		}
+		entry := ratingResource(rating)
+		entry.Rank = len(resp.Players) + 1
+		resp.Players = append(resp.Players, entry)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetRatingV2 godoc
+//
+//	@Summary		Returns the rating of a player
+//	@Tags			ratings
+//	@Produce		json
+//	@Param			player	path		string		true	"Player name, e.g. 'bot:mybot'"
+//	@Success		200		{object}	RespRating			"Rating, rank is omitted"
+//	@Failure		404		{object}	RespError			"Player has no rated games"
+//	@Router			/chessserver/v2/ratings/{player} [get]
+func GetRatingV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	player := mux.Vars(r)["player"]
+	rating, exists := ratings.Get(player)
+	if !exists {
+		writeError(w, http.StatusNotFound, CodeRatingNotFound, fmt.Sprintf("Player %q has no rated games.", player), map[string]any{"player": player})
+		return
+	}
+	json.NewEncoder(w).Encode(ratingResource(rating))
+}
+
+// Checks the player name a client joins with, "" is anonymous.
+func checkPlayer(name string) *apiError {
+	if name == "" {
+		return nil
+	}
+	if err := ratings.ValidName(name); err != nil {
+		return &apiError{http.StatusBadRequest, CodeInvalidPlayer, err.Error(), map[string]any{"player": name}}
+	}
+	return nil
+}
+
+// Checks that a player may play rated games. Names clients choose are not
+// authenticated, so only accounts, engines and bots are rated; account
+// names only get here with the account's key, see resolvePlayer.
+func checkRatedPlayer(player string) *apiError {
+	if strings.HasPrefix(player, ratings.EngineID("")) || strings.HasPrefix(player, ratings.BotID("")) || accounts.Exists(player) {
+		return nil
+	}
+	return &apiError{http.StatusBadRequest, CodeInvalidPlayer, "Rated games need an account, join with its API key.", map[string]any{"player": player}}
+}
+
+func ratingResource(rating ratings.Rating) RespRating {
+	return RespRating{
+		Player:     rating.Player,
+		Rating:     rating.Rating,
+		Deviation:  rating.Deviation,
+		Volatility: rating.Volatility,
+		Games:      rating.Games,
+		Wins:       rating.Wins,
+		Draws:      rating.Draws,
+		Losses:     rating.Losses,
+		LastGame:   rating.LastGame,
+		Account:    accounts.Exists(rating.Player),
+	}
+}
@@ -5,11 +5,11 @@ package api
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"strings"
 
 	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+	"github.com/matetirpak/chessbot-playground-server/internal/logging"
 )
 
 // DeleteSessions godoc
@@ -22,17 +22,22 @@ import (
 //	@Security		BearerAuth
 //	@Param			request	body		ReqDeleteSessions	true	"Request payload with board-id"
 //	@Success		200		{string}	string						"Success (No Content)"
-//	@Failure		400		{string}	string						"Bad request (invalid JSON body)"
-//	@Failure		401		{string}	string						"Unauthorized (missing or invalid session token)"
-//	@Failure		404		{string}	string						"Not found – Game session does not exist"
+//	@Failure		400		{object}	RespError			"Bad request (invalid JSON body)"
+//	@Failure		401		{object}	RespError			"Unauthorized (missing or invalid session token)"
+//	@Failure		404		{object}	RespError			"Not found – Game session does not exist"
+//	@Failure		503		{object}	RespError			"Server restarting, see Retry-After header"
 //	@Router			/chessserver/v1/sessions [delete]
 func DeleteSessions(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
+	if rejectDuringShutdown(w, stageFrozen) {
+		return
+	}
+
 	// Get Bearer token from header
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-		http.Error(w, "Missing or invalid Authorization header", http.StatusUnauthorized)
+		writeError(w, http.StatusUnauthorized, CodeMissingAuthorization, "Missing or invalid Authorization header", nil)
 		return
 	}
 	password := strings.TrimPrefix(authHeader, "Bearer ")
@@ -40,18 +45,17 @@ func DeleteSessions(w http.ResponseWriter, r *http.Request) {
 	var req ReqDeleteSessions
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		fmt.Printf("Decoding failed.\n")
-		http.Error(w, fmt.Sprintf("Invalid request payload: %v", err), http.StatusBadRequest)
+		logging.FromContext(r.Context()).Debug("decoding failed", "error", err)
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid request payload.", map[string]any{"error": err.Error()})
 		return
 	}
+	logging.AddAttrs(r.Context(), "boardid", req.BoardID)
 
-	success := verifyGameAccess(w, req.BoardID, password)
-	if !success {
+	if verifyGameAccess(w, req.BoardID, password) == nil {
 		return
 	}
-	data.GamesMapMu.Lock()
-	delete(data.GamesMap, req.BoardID)
-	data.GamesMapMu.Unlock()
+	data.RemoveGame(req.BoardID)
+	logging.FromContext(r.Context()).Info("session deleted")
 
 	w.WriteHeader(http.StatusOK)
 }
@@ -88,30 +92,42 @@ func GetSessions(w http.ResponseWriter, r *http.Request) {
 //
 //	@Summary		Creates a new session
 //	@Description	Initializes a new session in the server. The response contains an ID and password.
+//	@Description	'white' and 'black' launch bots of the server's bots file as players.
+//	@Description	The results of rated sessions update the ratings of the players, see /chessserver/v2/ratings.
 //	@Tags			sessions
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		ReqPostSessions		true	"Request payload with desired session name"
 //	@Success 		200 	{object} 	RespPostSessions 			"Session/Board ID and password"
-//	@Failure		400		{string}	string						"Bad request (invalid JSON body)"
+//	@Failure		400		{object}	RespError			"Bad request (invalid JSON body or unknown bot)"
+//	@Failure		503		{object}	RespError			"Server restarting, see Retry-After header"
 //	@Router			/chessserver/v1/sessions [post]
 func PostSessions(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
+	if rejectDuringShutdown(w, stageDraining) {
+		return
+	}
+
 	var req ReqPostSessions
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid request payload: %v", err), http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid request payload.", map[string]any{"error": err.Error()})
 		return
 	}
 
-	newGame := initializeNewGame(req.Name)
+	if apiErr := checkBots(req.White, req.Black); apiErr != nil {
+		apiErr.write(w)
+		return
+	}
 
-	data.GamesMapMu.Lock()
-	data.GamesMap[newGame.ID] = newGame
-	data.GamesMapMu.Unlock()
+	newGame, password := createGame(r.Context(), req.Name, req.Rated)
+	if apiErr := seatBots(r.Context(), newGame, req.White, req.Black); apiErr != nil {
+		apiErr.write(w)
+		return
+	}
 
-	resp := RespPostSessions{BoardID: newGame.ID, Password: newGame.Password}
+	resp := RespPostSessions{BoardID: newGame.ID, Password: password}
 	json.NewEncoder(w).Encode(resp)
 }
 
@@ -119,92 +135,74 @@ func PostSessions(w http.ResponseWriter, r *http.Request) {
 //
 //	@Summary		Register as a player in a session
 //	@Description	Registers a player (white or black) to an existing game session using the board ID and a session password.
+//	@Description	With 'engine' set, a built-in engine ("random", "greedy" or "alphabeta") takes the seat and plays automatically; the seat has no token.
+//	@Description	With 'bot' set, the server launches that bot of its bots file for the seat; only the bot gets the token.
+//	@Description	'player' is the name the player is shown under. Rated sessions need an account's API key, names alone are not rated.
+//	@Description	With an API key, the account takes the seat under its name.
 //	@Tags			sessions
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			request	body		ReqPutSessions		true	"Request payload with session access data and desired color"
+//	@Param			request		body		ReqPutSessions		true	"Request payload with session access data and desired color"
+//	@Param			X-API-Key	header		string				false	"API key of the joining account"
 //	@Success 		200 	{object} 	RespPutSessions 			"Color-specific Player token"
-//	@Failure		400		{string}	string				"Bad request – Invalid JSON or color value"
-//	@Failure		401		{string}	string				"Unauthorized – Missing or invalid bearer token"
-//	@Failure		403		{string}	string				"Forbidden – Game is full or color already taken"
-//	@Failure		404		{string}	string				"Not found – Game session does not exist"
+//	@Failure		400		{object}	RespError	"Bad request – Invalid JSON, color value, unknown engine or bot, invalid player name"
+//	@Failure		401		{object}	RespError	"Unauthorized – Missing or invalid bearer token or API key"
+//	@Failure		403		{object}	RespError	"Forbidden – Game is full, color already taken or player name belongs to an account"
+//	@Failure		404		{object}	RespError	"Not found – Game session does not exist"
+//	@Failure		503		{object}	RespError	"Server restarting, see Retry-After header"
 //	@Router			/chessserver/v1/sessions [put]
 func PutSessions(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
+	if rejectDuringShutdown(w, stageDraining) {
+		return
+	}
+
 	var req ReqPutSessions
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Invalid request payload: %v", err), http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid request payload.", map[string]any{"error": err.Error()})
 		return
 	}
+	logging.AddAttrs(r.Context(), "boardid", req.BoardID, "color", req.Color)
 
 	// Get Bearer token from header
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-		http.Error(w, "Missing or invalid Authorization header", http.StatusUnauthorized)
+		writeError(w, http.StatusUnauthorized, CodeMissingAuthorization, "Missing or invalid Authorization header", nil)
 		return
 	}
 	password := strings.TrimPrefix(authHeader, "Bearer ")
 
-	success := verifyGameAccess(w, req.BoardID, password)
-	if !success {
-		return
-	}
-
-	data.GamesMapMu.RLock()
-	var game *data.Game = data.GamesMap[req.BoardID]
-	data.GamesMapMu.RUnlock()
-
-	game.Mu.RLock()
-	hasWPlayer := game.HasWPlayer
-	hasBPlayer := game.HasBPlayer
-	game.Mu.RUnlock()
-
-	if hasWPlayer && hasBPlayer {
-		http.Error(w, "Game is already full.", http.StatusForbidden)
+	game := verifyGameAccess(w, req.BoardID, password)
+	if game == nil {
 		return
 	}
 
-	token := generateToken()
-	resp := RespPutSessions{Token: token}
-
-	switch req.Color {
-	case "w":
-		if hasWPlayer {
-			http.Error(w, "White is already taken.", http.StatusForbidden)
-			return
-		}
-		game.Mu.Lock()
-		game.HasWPlayer = true
-		game.WPlayerToken = token
-		if game.HasBPlayer {
-			game.Started = true
-			game.BoardData[0].TurnColor = "w"
-		}
-		game.Mu.Unlock()
-
-		json.NewEncoder(w).Encode(resp)
-
-	case "b":
-		if hasBPlayer {
-			http.Error(w, "Black is already taken.", http.StatusForbidden)
-			return
-		}
-		game.Mu.Lock()
-		game.HasBPlayer = true
-		game.BPlayerToken = token
-		if game.HasWPlayer {
-			game.Started = true
-			game.BoardData[0].TurnColor = "w"
-		}
-		game.Mu.Unlock()
-
-		json.NewEncoder(w).Encode(resp)
-
+	var token string
+	var apiErr *apiError
+	switch {
+	case req.Engine != "" && req.Bot != "":
+		apiErr = &apiError{http.StatusBadRequest, CodeInvalidRequest, "Set either engine or bot.", nil}
+	case req.Player != "" && (req.Engine != "" || req.Bot != ""):
+		apiErr = &apiError{http.StatusBadRequest, CodeInvalidPlayer, "Engines and bots are rated under their own names.", nil}
+	case req.Engine != "":
+		logging.AddAttrs(r.Context(), "engine", req.Engine)
+		apiErr = seatEngine(r.Context(), game, req.Color, req.Engine)
+	case req.Bot != "":
+		logging.AddAttrs(r.Context(), "bot", req.Bot)
+		apiErr = seatBot(r.Context(), game, req.Color, req.Bot)
 	default:
-		http.Error(w, "Invalid color. Enter 'w' or 'b'", http.StatusBadRequest)
+		var player string
+		if player, apiErr = resolvePlayer(r, req.Player); apiErr == nil {
+			token, apiErr = joinGame(r.Context(), game, req.Color, player)
+		}
+	}
+	if apiErr != nil {
+		apiErr.write(w)
 		return
 	}
+
+	json.NewEncoder(w).Encode(RespPutSessions{Token: token})
 }
@@ -0,0 +1,387 @@
+/*
+Tournament endpoints of the v2 API.
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+	"github.com/matetirpak/chessbot-playground-server/internal/logging"
+	"github.com/matetirpak/chessbot-playground-server/internal/sprt"
+	"github.com/matetirpak/chessbot-playground-server/internal/tournament"
+)
+
+// GetTournamentsV2 godoc
+//
+//	@Summary		Lists all tournaments
+//	@Tags			tournaments
+//	@Produce		json
+//	@Success		200		{object}	RespTournaments		"All tournaments"
+//	@Router			/chessserver/v2/tournaments [get]
+func GetTournamentsV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	resp := RespTournaments{Tournaments: []RespTournament{}}
+	for _, t := range tournament.List() {
+		state := t.State()
+		resp.Tournaments = append(resp.Tournaments, tournamentResource(&state))
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// PostTournamentsV2 godoc
+//
+//	@Summary		Starts a tournament
+//	@Description	Schedules the games of the format between the participants and starts playing them.
+//	@Description	Participants are built-in engines or bots of the server's bots file. The password is required to cancel the tournament.
+//	@Description	The sprt format tests the first of two participants against the second in pairs of games until the SPRT accepts H0 or H1.
+//	@Description	With an opening suite, the games of each pairing start from the same opening with swapped colors.
+//	@Tags			tournaments
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		ReqPostTournament	true	"Format, participants and time control"
+//	@Success		201		{object}	RespPostTournament		"Tournament ID and password, Location header points to the tournament"
+//	@Failure		400		{object}	RespError				"Invalid JSON body, format, participant, opening suite or time control"
+//	@Failure		503		{object}	RespError				"Server restarting, see Retry-After header"
+//	@Router			/chessserver/v2/tournaments [post]
+func PostTournamentsV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if rejectDuringShutdown(w, stageDraining) {
+		return
+	}
+
+	var req ReqPostTournament
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid request payload.", map[string]any{"error": err.Error()})
+		return
+	}
+
+	spec := tournament.Spec{
+		Name:            req.Name,
+		Format:          tournament.Format(req.Format),
+		GamesPerPairing: req.GamesPerPairing,
+		Rounds:          req.Rounds,
+		MaxPlies:        req.MaxPlies,
+		Concurrency:     req.Concurrency,
+		Rated:           req.Rated,
+		Openings:        req.Openings,
+	}
+	if req.MoveTime != "" {
+		moveTime, err := time.ParseDuration(req.MoveTime)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid move time.", map[string]any{"movetime": req.MoveTime, "error": err.Error()})
+			return
+		}
+		spec.MoveTime = moveTime
+	}
+	if req.SPRT != nil {
+		bounds := sprt.DefaultBounds
+		if req.SPRT.Elo0 != 0 || req.SPRT.Elo1 != 0 {
+			bounds.Elo0, bounds.Elo1 = req.SPRT.Elo0, req.SPRT.Elo1
+		}
+		if req.SPRT.Alpha != 0 {
+			bounds.Alpha = req.SPRT.Alpha
+		}
+		if req.SPRT.Beta != 0 {
+			bounds.Beta = req.SPRT.Beta
+		}
+		spec.SPRT = &bounds
+	}
+	for _, p := range req.Participants {
+		spec.Participants = append(spec.Participants, tournament.Participant{Name: p.Name, Engine: p.Engine, Bot: p.Bot})
+	}
+	if apiErr := checkParticipants(spec.Participants); apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	if spec.Openings != "" {
+		if _, apiErr := lookupOpenings(spec.Openings); apiErr != nil {
+			apiErr.write(w)
+			return
+		}
+	}
+
+	password := generateToken()
+	t, err := tournament.New(spec, password)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, err.Error(), nil)
+		return
+	}
+	state := t.State()
+	logging.AddAttrs(r.Context(), "tournament", state.ID)
+	logging.FromContext(r.Context()).Info("tournament created", "format", state.Spec.Format, "games", len(state.Games))
+	go t.Run(playTournamentGame)
+
+	w.Header().Set("Location", fmt.Sprintf("/chessserver/v2/tournaments/%d", state.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(RespPostTournament{ID: state.ID, Password: password})
+}
+
+// GetTournamentV2 godoc
+//
+//	@Summary		Returns a tournament with its standings
+//	@Tags			tournaments
+//	@Produce		json
+//	@Param			id		path		int			true	"Tournament ID"
+//	@Success		200		{object}	RespTournament		"Tournament"
+//	@Failure		400		{object}	RespError			"Invalid tournament ID"
+//	@Failure		404		{object}	RespError			"Tournament does not exist"
+//	@Router			/chessserver/v2/tournaments/{id} [get]
+func GetTournamentV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	t, apiErr := tournamentFromPath(r)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	state := t.State()
+	json.NewEncoder(w).Encode(tournamentResource(&state))
+}
+
+// DeleteTournamentV2 godoc
+//
+//	@Summary		Cancels a tournament
+//	@Description	Stops scheduling games and removes the games in progress. Finished games keep counting.
+//	@Tags			tournaments
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int			true	"Tournament ID"
+//	@Success		204		{string}	string				"Cancelled"
+//	@Failure		401		{object}	RespError			"Missing or invalid tournament password"
+//	@Failure		404		{object}	RespError			"Tournament does not exist"
+//	@Failure		409		{object}	RespError			"Tournament has already ended"
+//	@Failure		503		{object}	RespError			"Server restarting, see Retry-After header"
+//	@Router			/chessserver/v2/tournaments/{id} [delete]
+func DeleteTournamentV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if rejectDuringShutdown(w, stageFrozen) {
+		return
+	}
+
+	t, apiErr := tournamentFromPath(r)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	token, apiErr := bearerToken(r)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	if !t.PasswordMatches(token) {
+		writeError(w, http.StatusUnauthorized, CodeInvalidPassword, "Invalid password", nil)
+		return
+	}
+
+	boards, cancelled := t.Cancel()
+	if !cancelled {
+		writeError(w, http.StatusConflict, CodeTournamentOver, "Tournament has already ended.", nil)
+		return
+	}
+	for _, id := range boards {
+		data.RemoveGame(id)
+	}
+	logging.FromContext(r.Context()).Info("tournament cancelled", "removedgames", len(boards))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTournamentGamesV2 godoc
+//
+//	@Summary		Lists the games of a tournament
+//	@Description	Games in schedule order with their boards and results.
+//	@Tags			tournaments
+//	@Produce		json
+//	@Param			id		path		int			true	"Tournament ID"
+//	@Success		200		{object}	RespTournamentGames	"Games"
+//	@Failure		400		{object}	RespError			"Invalid tournament ID"
+//	@Failure		404		{object}	RespError			"Tournament does not exist"
+//	@Router			/chessserver/v2/tournaments/{id}/games [get]
+func GetTournamentGamesV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	t, apiErr := tournamentFromPath(r)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	state := t.State()
+	resp := RespTournamentGames{Games: make([]RespTournamentGame, len(state.Games))}
+	for i, game := range state.Games {
+		var opening string
+		if state.Spec.Openings != "" {
+			if o, apiErr := tournamentOpening(&state.Spec, &game); apiErr == nil {
+				opening = o.Name
+			}
+		}
+		resp.Games[i] = RespTournamentGame{
+			Index:       game.Index,
+			Round:       game.Round,
+			White:       state.Spec.Participants[game.White].Name,
+			Black:       state.Spec.Participants[game.Black].Name,
+			Status:      string(game.Status),
+			Opening:     opening,
+			BoardID:     game.BoardID,
+			Winner:      game.Winner,
+			Termination: game.Termination,
+			Error:       game.Error,
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetTournamentStandingsV2 godoc
+//
+//	@Summary		Returns the standings of a tournament
+//	@Description	Participants ordered by points, then Buchholz, Sonneborn-Berger and wins. A win counts 1, a draw 0.5 and a bye as much as winning all games of a pairing.
+//	@Description	The round parameter returns the standings after that round.
+//	@Tags			tournaments
+//	@Produce		json
+//	@Param			id		path		int			true	"Tournament ID"
+//	@Param			round	query		int			false	"Count only the rounds up to this one"
+//	@Success		200		{array}		RespStanding		"Standings"
+//	@Failure		400		{object}	RespError			"Invalid tournament ID or round"
+//	@Failure		404		{object}	RespError			"Tournament does not exist"
+//	@Router			/chessserver/v2/tournaments/{id}/standings [get]
+func GetTournamentStandingsV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	t, apiErr := tournamentFromPath(r)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	round := 0
+	if roundStr := r.URL.Query().Get("round"); roundStr != "" {
+		var err error
+		if round, err = strconv.Atoi(roundStr); err != nil || round < 1 {
+			writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid round.", map[string]any{"round": roundStr})
+			return
+		}
+	}
+	state := t.State()
+	json.NewEncoder(w).Encode(standingsResource(&state, round))
+}
+
+// GetTournamentCrosstableV2 godoc
+//
+//	@Summary		Returns the crosstable of a tournament
+//	@Tags			tournaments
+//	@Produce		json
+//	@Param			id		path		int			true	"Tournament ID"
+//	@Success		200		{object}	RespCrosstable		"Crosstable"
+//	@Failure		400		{object}	RespError			"Invalid tournament ID"
+//	@Failure		404		{object}	RespError			"Tournament does not exist"
+//	@Router			/chessserver/v2/tournaments/{id}/crosstable [get]
+func GetTournamentCrosstableV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	t, apiErr := tournamentFromPath(r)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	state := t.State()
+	table := state.Crosstable()
+	resp := RespCrosstable{Participants: table.Participants, Scores: make([][]*RespScore, len(table.Scores))}
+	for i, row := range table.Scores {
+		resp.Scores[i] = make([]*RespScore, len(row))
+		for j, score := range row {
+			if score != nil {
+				resp.Scores[i][j] = scoreResource(score)
+			}
+		}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func tournamentFromPath(r *http.Request) (*tournament.Tournament, *apiError) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return nil, &apiError{http.StatusBadRequest, CodeInvalidRequest, "Invalid tournament ID.", map[string]any{"id": idStr}}
+	}
+	logging.AddAttrs(r.Context(), "tournament", id)
+	t, exists := tournament.Get(int32(id))
+	if !exists {
+		return nil, &apiError{http.StatusNotFound, CodeTournamentNotFound, fmt.Sprintf("Tournament %d doesn't exist.", id), map[string]any{"id": id}}
+	}
+	return t, nil
+}
+
+func tournamentResource(state *tournament.State) RespTournament {
+	spec := state.Spec
+	resp := RespTournament{
+		ID:              state.ID,
+		Name:            spec.Name,
+		Format:          string(spec.Format),
+		Status:          string(state.Status),
+		GamesPerPairing: spec.GamesPerPairing,
+		Rounds:          spec.Rounds,
+		Round:           state.Round(),
+		MaxPlies:        spec.MaxPlies,
+		Concurrency:     spec.Concurrency,
+		Rated:           spec.Rated,
+		Openings:        spec.Openings,
+		Games:           len(state.Games),
+		Standings:       standingsResource(state, 0),
+	}
+	if spec.MoveTime > 0 {
+		resp.MoveTime = spec.MoveTime.String()
+	}
+	for _, p := range spec.Participants {
+		resp.Participants = append(resp.Participants, TournamentParticipant{Name: p.Name, Engine: p.Engine, Bot: p.Bot})
+	}
+	for _, game := range state.Games {
+		if game.Status == tournament.GameFinished {
+			resp.FinishedGames++
+		}
+	}
+	for _, bye := range state.Byes {
+		resp.Byes = append(resp.Byes, RespBye{Round: bye.Round, Participant: spec.Participants[bye.Participant].Name})
+	}
+	if spec.Format == tournament.SPRT {
+		result := state.SPRT()
+		resp.SPRT = &RespSPRT{
+			TournamentSPRT: TournamentSPRT{Elo0: spec.SPRT.Elo0, Elo1: spec.SPRT.Elo1, Alpha: spec.SPRT.Alpha, Beta: spec.SPRT.Beta},
+			Pairs:          result.Pentanomial.Pairs(),
+			Pentanomial:    result.Pentanomial,
+			LLR:            result.LLR,
+			Lower:          result.Lower,
+			Upper:          result.Upper,
+			Decision:       string(result.Decision),
+			Elo:            result.Elo,
+			EloError:       result.EloError,
+		}
+	}
+	return resp
+}
+
+func standingsResource(state *tournament.State, round int) []RespStanding {
+	standings := state.StandingsAfter(round)
+	resp := make([]RespStanding, len(standings))
+	for i, s := range standings {
+		resp[i] = RespStanding{
+			Rank:            s.Rank,
+			Participant:     s.Participant,
+			RespScore:       *scoreResource(&s.Score),
+			Byes:            s.Byes,
+			Buchholz:        s.Buchholz,
+			SonnebornBerger: s.SonnebornBerger,
+		}
+	}
+	return resp
+}
+
+func scoreResource(score *tournament.Score) *RespScore {
+	return &RespScore{Games: score.Games, Wins: score.Wins, Draws: score.Draws, Losses: score.Losses, Points: score.Points}
+}
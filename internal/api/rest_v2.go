@@ -0,0 +1,743 @@
+/*
+Resource oriented v2 API. Games, players, moves and positions have their
+own paths, the player's token identifies its color.
+*/
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+	"github.com/matetirpak/chessbot-playground-server/internal/logging"
+)
+
+// GetGamesV2 godoc
+//
+//	@Summary		Lists all games
+//	@Description	Lists all games, with 'player' only the games of that player, e.g. an account.
+//	@Tags			v2
+//	@Produce		json
+//	@Param			player	query		string		false	"Player name"
+//	@Success		200		{object}	RespGames		"All games"
+//	@Router			/chessserver/v2/games [get]
+func GetGamesV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	player := r.URL.Query().Get("player")
+	data.GamesMapMu.RLock()
+	games := make([]*data.Game, 0, len(data.GamesMap))
+	for _, game := range data.GamesMap {
+		if player != "" {
+			game.Mu.RLock()
+			plays := game.WPlayer == player || game.BPlayer == player
+			game.Mu.RUnlock()
+			if !plays {
+				continue
+			}
+		}
+		games = append(games, game)
+	}
+	data.GamesMapMu.RUnlock()
+	sort.Slice(games, func(i, j int) bool { return games[i].ID < games[j].ID })
+
+	resp := RespGames{Games: []RespGame{}}
+	for _, game := range games {
+		resp.Games = append(resp.Games, gameResource(game))
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// PostGamesV2 godoc
+//
+//	@Summary		Creates a game
+//	@Description	Creates a game. The password is required to join players and to delete the game.
+//	@Description	'white' and 'black' launch bots of the server's bots file as players.
+//	@Description	The results of rated games update the ratings of the players, see /ratings.
+//	@Description	With an API key, the account can use its key instead of the password for this game.
+//	@Tags			v2
+//	@Accept			json
+//	@Produce		json
+//	@Param			request		body		ReqPostGame		true	"Name of the game"
+//	@Param			X-API-Key	header		string			false	"API key of the creating account"
+//	@Success		201		{object}	RespPostGame			"Game ID and password, Location header points to the game"
+//	@Failure		400		{object}	RespError				"Bad request (invalid JSON body or unknown bot)"
+//	@Failure		401		{object}	RespError				"Invalid API key"
+//	@Failure		503		{object}	RespError				"Server restarting, see Retry-After header"
+//	@Router			/chessserver/v2/games [post]
+func PostGamesV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if rejectDuringShutdown(w, stageDraining) {
+		return
+	}
+
+	var req ReqPostGame
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid request payload.", map[string]any{"error": err.Error()})
+		return
+	}
+
+	if apiErr := checkBots(req.White, req.Black); apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	account, apiErr := requestAccount(r)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+
+	newGame, password := createGame(r.Context(), req.Name, req.Rated)
+	if account != nil {
+		newGame.Mu.Lock()
+		newGame.CreatedBy = account.Name
+		newGame.Mu.Unlock()
+	}
+	if apiErr := seatBots(r.Context(), newGame, req.White, req.Black); apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/chessserver/v2/games/%d", newGame.ID))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(RespPostGame{ID: newGame.ID, Password: password})
+}
+
+// GetGameV2 godoc
+//
+//	@Summary		Returns a game
+//	@Tags			v2
+//	@Produce		json
+//	@Param			id		path		int			true	"Game ID"
+//	@Success		200		{object}	RespGame			"Game"
+//	@Failure		400		{object}	RespError			"Invalid game ID"
+//	@Failure		404		{object}	RespError			"Game does not exist"
+//	@Router			/chessserver/v2/games/{id} [get]
+func GetGameV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	game, apiErr := gameFromPath(r)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	json.NewEncoder(w).Encode(gameResource(game))
+}
+
+// DeleteGameV2 godoc
+//
+//	@Summary		Deletes a game
+//	@Tags			v2
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int			true	"Game ID"
+//	@Success		204		{string}	string				"Deleted"
+//	@Failure		401		{object}	RespError			"Missing or invalid game password"
+//	@Failure		404		{object}	RespError			"Game does not exist"
+//	@Failure		503		{object}	RespError			"Server restarting, see Retry-After header"
+//	@Router			/chessserver/v2/games/{id} [delete]
+func DeleteGameV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if rejectDuringShutdown(w, stageFrozen) {
+		return
+	}
+
+	game, apiErr := gameFromPath(r)
+	if apiErr == nil {
+		apiErr = authorizePassword(r, game)
+	}
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+
+	data.RemoveGame(game.ID)
+	logging.FromContext(r.Context()).Info("session deleted")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PutPlayerV2 godoc
+//
+//	@Summary		Joins a game as a player
+//	@Description	Registers the player of the given color. The game starts once both players joined.
+//	@Description	With 'engine' set, a built-in engine takes the seat and plays automatically; the seat has no token.
+//	@Description	With 'bot' set, the server launches that bot of its bots file for the seat; only the bot gets the token.
+//	@Description	'player' is the name the player is shown under. Rated games need an account's API key, names alone are not rated. Engines and bots are rated under their own names.
+//	@Description	With an API key, the account takes the seat under its name and can play with the key instead of the token. The creator's key replaces the password.
+//	@Tags			v2
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		int			true	"Game ID"
+//	@Param			color		path		string		true	"Color ('w' or 'b')"
+//	@Param			engine		query		string		false	"Built-in engine, e.g. 'alphabeta'"
+//	@Param			bot			query		string		false	"Bot of the bots file"
+//	@Param			player		query		string		false	"Rating identity of the player"
+//	@Param			X-API-Key	header		string		false	"API key of the joining account"
+//	@Success		201		{object}	RespPutPlayer		"Player token"
+//	@Failure		400		{object}	RespError			"Invalid game ID, color, engine, bot or player name"
+//	@Failure		401		{object}	RespError			"Missing or invalid game password or API key"
+//	@Failure		403		{object}	RespError			"Player name belongs to an account"
+//	@Failure		404		{object}	RespError			"Game does not exist"
+//	@Failure		409		{object}	RespError			"Color already taken"
+//	@Failure		503		{object}	RespError			"Server restarting, see Retry-After header"
+//	@Router			/chessserver/v2/games/{id}/players/{color} [put]
+func PutPlayerV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if rejectDuringShutdown(w, stageDraining) {
+		return
+	}
+
+	color := mux.Vars(r)["color"]
+	logging.AddAttrs(r.Context(), "color", color)
+	game, apiErr := gameFromPath(r)
+	if apiErr == nil {
+		apiErr = authorizePassword(r, game)
+	}
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+
+	var token string
+	engineName, botName := r.URL.Query().Get("engine"), r.URL.Query().Get("bot")
+	player := r.URL.Query().Get("player")
+	switch {
+	case engineName != "" && botName != "":
+		apiErr = &apiError{http.StatusBadRequest, CodeInvalidRequest, "Set either engine or bot.", nil}
+	case player != "" && (engineName != "" || botName != ""):
+		apiErr = &apiError{http.StatusBadRequest, CodeInvalidPlayer, "Engines and bots are rated under their own names.", nil}
+	case engineName != "":
+		logging.AddAttrs(r.Context(), "engine", engineName)
+		apiErr = seatEngine(r.Context(), game, color, engineName)
+	case botName != "":
+		logging.AddAttrs(r.Context(), "bot", botName)
+		apiErr = seatBot(r.Context(), game, color, botName)
+	default:
+		if player, apiErr = resolvePlayer(r, player); apiErr == nil {
+			token, apiErr = joinGame(r.Context(), game, color, player)
+		}
+	}
+	if apiErr != nil {
+		conflict(apiErr).write(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(RespPutPlayer{Color: color, Token: token, ExpiresAt: tokenExpiry(game, color)})
+}
+
+// PostPlayerTokenV2 godoc
+//
+//	@Summary		Regenerates a player token
+//	@Description	Issues a new token for the player of the given color and revokes the previous one, e.g. after it expired or leaked.
+//	@Description	Requires the current token of the color, which may have expired for as long again as it lived, or the API key of the account playing the color. The game password is not accepted, the opponent knows it too.
+//	@Description	Seats of engines and bots are played by the server and keep their tokens.
+//	@Tags			v2
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id			path		int			true	"Game ID"
+//	@Param			color		path		string		true	"Color ('w' or 'b')"
+//	@Param			X-API-Key	header		string		false	"API key of the account playing the color"
+//	@Success		201		{object}	RespPutPlayer		"New player token"
+//	@Failure		400		{object}	RespError			"Invalid game ID or color"
+//	@Failure		401		{object}	RespError			"Missing or invalid player token or API key"
+//	@Failure		404		{object}	RespError			"Game or player does not exist"
+//	@Failure		409		{object}	RespError			"Seat is played by the server"
+//	@Failure		503		{object}	RespError			"Server restarting, see Retry-After header"
+//	@Router			/chessserver/v2/games/{id}/players/{color}/token [post]
+func PostPlayerTokenV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if rejectDuringShutdown(w, stageFrozen) {
+		return
+	}
+
+	color := mux.Vars(r)["color"]
+	logging.AddAttrs(r.Context(), "color", color)
+	game, apiErr := gameFromPath(r)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	if color != "w" && color != "b" {
+		writeError(w, http.StatusBadRequest, CodeInvalidColor, "Invalid color. Enter 'w' or 'b'", map[string]any{"color": color})
+		return
+	}
+	// Only the seat itself may regenerate its token, the session password
+	// is shared with the opponent.
+	var token string
+	withKey := usesAPIKey(r)
+	if withKey {
+		var seat string
+		if seat, apiErr = accountColor(r, game); apiErr == nil && seat != color {
+			apiErr = &apiError{http.StatusUnauthorized, CodeInvalidAPIKey, "The account doesn't play this color.", nil}
+		}
+	} else {
+		token, apiErr = bearerToken(r)
+	}
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+
+	game.Mu.Lock()
+	joined := game.HasWPlayer
+	if color == "b" {
+		joined = game.HasBPlayer
+	}
+	switch {
+	case !joined:
+		apiErr = &apiError{http.StatusNotFound, CodePlayerNotFound, "Player does not exist.", map[string]any{"color": color}}
+	case serverSeat(game, color):
+		apiErr = &apiError{http.StatusConflict, CodeSeatManaged, "The seat is played by the server.", map[string]any{"color": color}}
+	case !withKey && !refreshableToken(game, color, token):
+		apiErr = &apiError{http.StatusUnauthorized, CodeInvalidToken, "Token is invalid.", nil}
+	default:
+		token = issueToken(game, color)
+	}
+	game.Mu.Unlock()
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	logging.FromContext(r.Context()).Info("player token regenerated")
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(RespPutPlayer{Color: color, Token: token, ExpiresAt: tokenExpiry(game, color)})
+}
+
+// DeletePlayerV2 godoc
+//
+//	@Summary		Forfeits the game
+//	@Description	The player of the given color gives up, the opponent wins.
+//	@Tags			v2
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int			true	"Game ID"
+//	@Param			color	path		string		true	"Color ('w' or 'b')"
+//	@Success		204		{string}	string				"Forfeited"
+//	@Failure		400		{object}	RespError			"Invalid game ID or color"
+//	@Failure		401		{object}	RespError			"Missing or invalid player token"
+//	@Failure		404		{object}	RespError			"Game or player does not exist"
+//	@Failure		409		{object}	RespError			"Game has already ended"
+//	@Failure		503		{object}	RespError			"Server restarting, see Retry-After header"
+//	@Router			/chessserver/v2/games/{id}/players/{color} [delete]
+func DeletePlayerV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if rejectDuringShutdown(w, stageFrozen) {
+		return
+	}
+
+	color := mux.Vars(r)["color"]
+	logging.AddAttrs(r.Context(), "color", color)
+	game, apiErr := gameFromPath(r)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	token, apiErr := bearerToken(r)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	if !verifyBoardAccess(w, game, color, token) {
+		return
+	}
+
+	game.Mu.RLock()
+	winner := game.Winner
+	game.Mu.RUnlock()
+	if winner != "n" {
+		writeError(w, http.StatusConflict, CodeGameOver, "Game has already ended.", map[string]any{"winner": winner})
+		return
+	}
+
+	if apiErr := forfeitGame(r.Context(), game, color); apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetMovesV2 godoc
+//
+//	@Summary		Returns the played moves
+//	@Description	Requires the game password or a player token.
+//	@Tags			v2
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int			true	"Game ID"
+//	@Success		200		{object}	RespMoves			"Played moves"
+//	@Failure		400		{object}	RespError			"Invalid game ID"
+//	@Failure		401		{object}	RespError			"Missing or invalid token"
+//	@Failure		404		{object}	RespError			"Game does not exist"
+//	@Router			/chessserver/v2/games/{id}/moves [get]
+func GetMovesV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	game, apiErr := gameFromPath(r)
+	if apiErr == nil {
+		apiErr = authorizeRead(r, game)
+	}
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+
+	resp := RespMoves{Moves: []RespPlayedMove{}}
+	game.Mu.RLock()
+	for ply := 1; ply < len(game.BoardData); ply++ {
+		// Games from an opening may start with black to move
+		color := game.BoardData[ply-1].TurnColor
+		resp.Moves = append(resp.Moves, RespPlayedMove{Ply: ply, Color: color, Move: game.BoardData[ply].LastMove})
+	}
+	game.Mu.RUnlock()
+
+	json.NewEncoder(w).Encode(resp)
+}
+
+// PostMovesV2 godoc
+//
+//	@Summary		Plays a move
+//	@Description	Plays a move for the color of the player token, e.g. "e2 e4". Promotions append the piece, e.g. "e7 e8q".
+//	@Description	With "random" set, a random legal move is played.
+//	@Tags			v2
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int			true	"Game ID"
+//	@Param			request	body		ReqPostMove	true	"Move"
+//	@Success		201		{object}	RespPlayedMove		"Applied move, Location header points to the new position"
+//	@Failure		400		{object}	RespError			"Invalid game ID, JSON body or move format"
+//	@Failure		401		{object}	RespError			"Missing or invalid player token"
+//	@Failure		403		{object}	RespError			"The piece to be moved is not owned"
+//	@Failure		404		{object}	RespError			"Game does not exist"
+//	@Failure		409		{object}	RespError			"Game not started, already ended or not the player's turn"
+//	@Failure		422		{object}	RespError			"Illegal move, details contain the legal moves of the piece"
+//	@Failure		503		{object}	RespError			"Server restarting, see Retry-After header"
+//	@Router			/chessserver/v2/games/{id}/moves [post]
+func PostMovesV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	if rejectDuringShutdown(w, stageFrozen) {
+		return
+	}
+
+	game, apiErr := gameFromPath(r)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	color, apiErr := authorizePlayer(r, game)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+
+	var req ReqPostMove
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid request payload.", map[string]any{"error": err.Error()})
+		return
+	}
+	logging.AddAttrs(r.Context(), "move", req.Move, "random", req.Random)
+
+	move, ply, apiErr := applyMove(r.Context(), game, color, req.Move, req.Random)
+	if apiErr != nil {
+		conflict(apiErr).write(w)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/chessserver/v2/games/%d/positions/%d", game.ID, ply))
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(RespPlayedMove{Ply: ply, Color: color, Move: gl.MoveToString(&move)})
+}
+
+// GetPositionV2 godoc
+//
+//	@Summary		Returns a position of the game
+//	@Description	Position 0 is the starting position, position n the one after the n-th move. "latest" returns the current position.
+//	@Description	Requires the game password or a player token.
+//	@Tags			v2
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int			true	"Game ID"
+//	@Param			ply		path		string		true	"Ply or 'latest'"
+//	@Success		200		{object}	game_logic.BoardState	"Position"
+//	@Failure		400		{object}	RespError			"Invalid game ID or ply"
+//	@Failure		401		{object}	RespError			"Missing or invalid token"
+//	@Failure		404		{object}	RespError			"Game or position does not exist"
+//	@Router			/chessserver/v2/games/{id}/positions/{ply} [get]
+func GetPositionV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	game, apiErr := gameFromPath(r)
+	if apiErr == nil {
+		apiErr = authorizeRead(r, game)
+	}
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+
+	plyStr := mux.Vars(r)["ply"]
+	ply := -1
+	if plyStr != "latest" {
+		var err error
+		ply, err = strconv.Atoi(plyStr)
+		if err != nil || ply < 0 {
+			writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Ply has to be a non-negative number or \"latest\".", map[string]any{"ply": plyStr})
+			return
+		}
+	}
+
+	resp, apiErr := gamePosition(game, ply)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetLegalMovesV2 godoc
+//
+//	@Summary		Returns legal moves
+//	@Description	Returns the legal moves of the piece on the given square, or of the side to move if no square is given.
+//	@Description	Requires the game password or a player token.
+//	@Tags			v2
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int			true	"Game ID"
+//	@Param			square	query		string		false	"Square of the piece, e.g. 'e2'"
+//	@Success		200		{object}	RespLegalMoves		"Legal moves"
+//	@Failure		400		{object}	RespError			"Invalid game ID or square"
+//	@Failure		401		{object}	RespError			"Missing or invalid token"
+//	@Failure		404		{object}	RespError			"Game does not exist"
+//	@Failure		500		{object}	RespError			"Internal server error during move generation"
+//	@Router			/chessserver/v2/games/{id}/legal-moves [get]
+func GetLegalMovesV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	game, apiErr := gameFromPath(r)
+	if apiErr == nil {
+		apiErr = authorizeRead(r, game)
+	}
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+
+	resp := RespLegalMoves{Moves: []string{}}
+	square := r.URL.Query().Get("square")
+	if square == "" {
+		game.Mu.RLock()
+		bstate := game.BoardData[len(game.BoardData)-1]
+		game.Mu.RUnlock()
+
+		if bstate.TurnColor == "w" || bstate.TurnColor == "b" {
+			moves := gl.AllPossibleMoves(rune(bstate.TurnColor[0]), &bstate, nil)
+			validMoves, err := gl.FilterInvalidMoves(moves, &bstate)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, CodeInternal, "Failed to validate generated moves.", map[string]any{"error": err.Error()})
+				return
+			}
+			for current := validMoves; current != nil; current = current.Next {
+				resp.Moves = append(resp.Moves, gl.MoveToString(current))
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	pos, err := gl.SquareToRowCol(square)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidRequest, "Invalid square.", map[string]any{"square": square})
+		return
+	}
+	validMoves, apiErr := pieceLegalMoves(r.Context(), game, pos[0], pos[1])
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	for current := validMoves; current != nil; current = current.Next {
+		resp.Moves = append(resp.Moves, gl.MoveToString(current))
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetTurnV2 godoc
+//
+//	@Summary		Waits for the player's turn
+//	@Description	Holds the request until it's the turn of the player token's color and returns the game.
+//	@Tags			v2
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int			true	"Game ID"
+//	@Success		200		{object}	RespGame			"Game, it's the player's turn"
+//	@Failure		400		{object}	RespError			"Invalid game ID"
+//	@Failure		401		{object}	RespError			"Missing or invalid player token"
+//	@Failure		404		{object}	RespError			"Game does not exist"
+//	@Failure		408		{object}	RespError			"Timeout waiting for turn"
+//	@Failure		409		{object}	RespError			"Game has ended"
+//	@Failure		410		{object}	RespError			"Game was removed while waiting for turn"
+//	@Failure		503		{object}	RespError			"Server restarting, see Retry-After header"
+//	@Router			/chessserver/v2/games/{id}/turn [get]
+func GetTurnV2(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	game, apiErr := gameFromPath(r)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+	color, apiErr := authorizePlayer(r, game)
+	if apiErr != nil {
+		apiErr.write(w)
+		return
+	}
+
+	w.Header().Set("Connection", "keep-alive")
+	if apiErr := waitForTurn(r.Context(), game, color); apiErr != nil {
+		conflict(apiErr).write(w)
+		return
+	}
+	json.NewEncoder(w).Encode(gameResource(game))
+}
+
+// Returns the game of the {id} path variable.
+func gameFromPath(r *http.Request) (*data.Game, *apiError) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return nil, &apiError{http.StatusBadRequest, CodeInvalidRequest, "Invalid game ID.", map[string]any{"id": idStr}}
+	}
+	logging.AddAttrs(r.Context(), "boardid", id)
+	return lookupGame(int32(id))
+}
+
+func bearerToken(r *http.Request) (string, *apiError) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		return "", &apiError{http.StatusUnauthorized, CodeMissingAuthorization, "Missing or invalid Authorization header", nil}
+	}
+	return strings.TrimPrefix(authHeader, "Bearer "), nil
+}
+
+// Checks that the request carries the game password, or instead the API
+// key of the account that created the game.
+func authorizePassword(r *http.Request, game *data.Game) *apiError {
+	if usesAPIKey(r) {
+		account, apiErr := requestAccount(r)
+		if apiErr != nil {
+			return apiErr
+		}
+		game.Mu.RLock()
+		defer game.Mu.RUnlock()
+		if game.CreatedBy == "" || game.CreatedBy != account.Name {
+			return &apiError{http.StatusUnauthorized, CodeInvalidAPIKey, "The account didn't create this game, use the password.", nil}
+		}
+		return nil
+	}
+	token, apiErr := bearerToken(r)
+	if apiErr != nil {
+		return apiErr
+	}
+	if !passwordMatches(game, token) {
+		return &apiError{http.StatusUnauthorized, CodeInvalidPassword, "Invalid password", nil}
+	}
+	return nil
+}
+
+// Returns the color of the player whose token the request carries, or
+// instead the color of the account whose API key it carries.
+func authorizePlayer(r *http.Request, game *data.Game) (string, *apiError) {
+	if usesAPIKey(r) {
+		color, apiErr := accountColor(r, game)
+		if apiErr == nil {
+			logging.AddAttrs(r.Context(), "color", color)
+		}
+		return color, apiErr
+	}
+	token, apiErr := bearerToken(r)
+	if apiErr != nil {
+		return "", apiErr
+	}
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+	for _, seat := range []struct {
+		color  string
+		joined bool
+	}{{"w", game.HasWPlayer}, {"b", game.HasBPlayer}} {
+		if !seat.joined {
+			continue
+		}
+		apiErr := checkToken(game, seat.color, token)
+		if apiErr != nil && apiErr.code == CodeInvalidToken {
+			continue
+		}
+		if apiErr == nil {
+			logging.AddAttrs(r.Context(), "color", seat.color)
+		}
+		return seat.color, apiErr
+	}
+	return "", &apiError{http.StatusUnauthorized, CodeInvalidToken, "Token is invalid.", nil}
+}
+
+// Reports whether the request authenticates with an API key only.
+func usesAPIKey(r *http.Request) bool {
+	return r.Header.Get("Authorization") == "" && r.Header.Get(APIKeyHeader) != ""
+}
+
+// Checks that the request carries the game password or a player token.
+func authorizeRead(r *http.Request, game *data.Game) *apiError {
+	if authorizePassword(r, game) == nil {
+		return nil
+	}
+	_, apiErr := authorizePlayer(r, game)
+	return apiErr
+}
+
+// Turns errors caused by the game state into 409 Conflict, as v2 reports them.
+func conflict(apiErr *apiError) *apiError {
+	switch apiErr.code {
+	case CodeGameFull, CodeColorTaken, CodeGameNotStarted, CodeGameOver, CodeNotYourTurn:
+		apiErr.status = http.StatusConflict
+	}
+	return apiErr
+}
+
+func gameResource(game *data.Game) RespGame {
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+
+	players := RespPlayers{
+		White:        game.HasWPlayer,
+		Black:        game.HasBPlayer,
+		WhitePlayer:  game.WPlayer,
+		BlackPlayer:  game.BPlayer,
+		WhiteAccount: game.WAccount != "",
+		BlackAccount: game.BAccount != "",
+	}
+	return RespGame{
+		ID:          game.ID,
+		Name:        game.Name,
+		Started:     game.Started,
+		Rated:       game.Rated,
+		Players:     players,
+		TurnColor:   game.BoardData[len(game.BoardData)-1].TurnColor,
+		Winner:      game.Winner,
+		Termination: game.Termination,
+		Opening:     game.Opening,
+		Plies:       len(game.BoardData) - 1,
+	}
+}
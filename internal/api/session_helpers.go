@@ -5,6 +5,7 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -12,72 +13,164 @@ import (
 	"github.com/matetirpak/chessbot-playground-server/internal/game_logic"
 )
 
-// Verifies whether a user has access to a session.
-func verifyGameAccess(w http.ResponseWriter, id int32, password string) bool {
-	data.GamesMapMu.RLock()
-	game, exists := data.GamesMap[id]
-	data.GamesMapMu.RUnlock()
+// Verifies whether a user has access to a session. Returns the game, nil
+// after writing the error. Callers use the returned game rather than
+// looking it up again, the reaper may remove it in between.
+func verifyGameAccess(w http.ResponseWriter, id int32, password string) *data.Game {
+	game, apiErr := lookupGame(id)
+	if apiErr != nil {
+		apiErr.write(w)
+		return nil
+	}
 
-	if !exists {
-		http.Error(w, "Board not found", http.StatusNotFound)
-		return false
+	if !passwordMatches(game, password) {
+		writeError(w, http.StatusUnauthorized, CodeInvalidPassword, "Invalid password", nil)
+		return nil
 	}
+	return game
+}
 
+// Reports whether password is the session password of the game.
+func passwordMatches(game *data.Game, password string) bool {
 	game.Mu.RLock()
-	gamePassword := game.Password
-	game.Mu.RUnlock()
-
-	if password != gamePassword {
-		http.Error(w, "Invalid password", http.StatusUnauthorized)
-		return false
-	}
-	return true
+	defer game.Mu.RUnlock()
+	return data.MatchesHash(game.PasswordHash, password)
 }
 
 // Verifies whether a user is registered as a player and has access to a session.
 func verifyBoardAccess(w http.ResponseWriter, game *data.Game, color string, token string) bool {
-	// Both ip and token have to match with the database, session password is not required.
+	// The token has to match the seat, session password is not required.
 	game.Mu.RLock()
 	hasWPlayer := game.HasWPlayer
-	wToken := game.WPlayerToken
 	hasBPlayer := game.HasBPlayer
-	bToken := game.BPlayerToken
+	apiErr := checkToken(game, color, token)
 	game.Mu.RUnlock()
 
-	switch color {
-	case "w":
-		if !hasWPlayer {
-			http.Error(w, "White player does not exist.", http.StatusNotFound)
-			return false
-		}
-		if wToken != token {
-			http.Error(w, "Token is invalid.", http.StatusUnauthorized)
-			return false
-		}
+	switch {
+	case color != "w" && color != "b":
+		writeError(w, http.StatusBadRequest, CodeInvalidColor, "Invalid color. Enter 'w' or 'b'", map[string]any{"color": color})
+	case color == "w" && !hasWPlayer:
+		writeError(w, http.StatusNotFound, CodePlayerNotFound, "White player does not exist.", map[string]any{"color": color})
+	case color == "b" && !hasBPlayer:
+		writeError(w, http.StatusNotFound, CodePlayerNotFound, "Black player does not exist.", map[string]any{"color": color})
+	case apiErr != nil:
+		apiErr.write(w)
+	default:
 		return true
+	}
+	return false
+}
 
-	case "b":
-		if !hasBPlayer {
-			http.Error(w, "Black player does not exist.", http.StatusNotFound)
-			return false
-		}
-		if bToken != token {
-			http.Error(w, "Token is invalid.", http.StatusUnauthorized)
-			return false
-		}
-		return true
+// Lifetime of player tokens, 0 for tokens that don't expire.
+var TokenTTL time.Duration
 
-	default:
-		http.Error(w, "Invalid color. Enter 'w' or 'b'", http.StatusBadRequest)
-		return false
+// Checks a player token against the seat of the given color. Expects
+// game.Mu to be held.
+func checkToken(game *data.Game, color string, token string) *apiError {
+	hash, expires := game.WTokenHash, game.WTokenExpires
+	if color == "b" {
+		hash, expires = game.BTokenHash, game.BTokenExpires
+	}
+	if !data.MatchesHash(hash, token) {
+		return &apiError{http.StatusUnauthorized, CodeInvalidToken, "Token is invalid.", nil}
+	}
+	if !expires.IsZero() && time.Now().After(expires) {
+		return &apiError{http.StatusUnauthorized, CodeTokenExpired, "Token has expired, regenerate it.", map[string]any{"expiresat": expires}}
+	}
+	return nil
+}
+
+// Reports whether token is the current token of the given color. Expired
+// tokens still count for as long again as they lived, so that their player
+// can regenerate them. Expects game.Mu to be held.
+func refreshableToken(game *data.Game, color string, token string) bool {
+	apiErr := checkToken(game, color, token)
+	if apiErr == nil || apiErr.code != CodeTokenExpired {
+		return apiErr == nil
+	}
+	expires := game.WTokenExpires
+	if color == "b" {
+		expires = game.BTokenExpires
+	}
+	return time.Since(expires) <= TokenTTL
+}
+
+// Issues a new token for the seat of the given color, revoking the
+// previous one. Tokens of seats played by the server don't expire. Expects
+// game.Mu to be held for writing.
+func issueToken(game *data.Game, color string) string {
+	token := generateToken()
+	var expires time.Time
+	if TokenTTL > 0 && !serverSeat(game, color) {
+		expires = time.Now().Add(TokenTTL)
+	}
+	if color == "w" {
+		game.WTokenHash, game.WTokenExpires = data.HashSecret(token), expires
+	} else {
+		game.BTokenHash, game.BTokenExpires = data.HashSecret(token), expires
+	}
+	return token
+}
+
+// Reports whether an engine or bot plays the given color. Expects game.Mu
+// to be held.
+func serverSeat(game *data.Game, color string) bool {
+	if color == "w" {
+		return game.WEngine != "" || game.WBot != ""
+	}
+	return game.BEngine != "" || game.BBot != ""
+}
+
+// Reports whether a built-in engine plays the given color. Engines play
+// in-process, their seats have no token. Expects game.Mu to be held.
+func engineSeat(game *data.Game, color string) bool {
+	if color == "w" {
+		return game.WEngine != ""
+	}
+	return game.BEngine != ""
+}
+
+// Rejects a client acting for a seat a built-in engine plays.
+func checkClientSeat(game *data.Game, color string) *apiError {
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+	if engineSeat(game, color) {
+		return &apiError{http.StatusConflict, CodeSeatManaged, "The seat is played by the server.", map[string]any{"color": color}}
+	}
+	return nil
+}
+
+// Removes the token of the seat of the given color, so that no token
+// matches it. Expects game.Mu to be held for writing.
+func revokeToken(game *data.Game, color string) {
+	if color == "w" {
+		game.WTokenHash, game.WTokenExpires = "", time.Time{}
+	} else {
+		game.BTokenHash, game.BTokenExpires = "", time.Time{}
+	}
+}
+
+// Returns the expiry of the token of the given color, nil if it doesn't
+// expire.
+func tokenExpiry(game *data.Game, color string) *time.Time {
+	game.Mu.RLock()
+	defer game.Mu.RUnlock()
+	expires := game.WTokenExpires
+	if color == "b" {
+		expires = game.BTokenExpires
+	}
+	if expires.IsZero() {
+		return nil
 	}
+	return &expires
 }
 
 func generateToken() string {
 	return uuid.New().String()
 }
 
-func initializeNewGame(name string) *data.Game {
+// Returns a new game and its session password, which is only kept hashed.
+func initializeNewGame(name string) (*data.Game, string) {
 	var game data.Game
 
 	data.NextFreeBoardIDMu.Lock()
@@ -86,10 +179,14 @@ func initializeNewGame(name string) *data.Game {
 	data.NextFreeBoardIDMu.Unlock()
 
 	game.Name = name
-	game.Password = generateToken()
+	password := generateToken()
+	game.PasswordHash = data.HashSecret(password)
 	game.HasWPlayer = false
 	game.HasBPlayer = false
 	game.Winner = "n"
+	game.CreatedAt = time.Now()
+	game.LastActivity = game.CreatedAt
+	game.Done = make(chan struct{})
 	game_logic.InitializeBoard(&game.BoardData)
-	return &game
+	return &game, password
 }
@@ -0,0 +1,72 @@
+/*
+Shutdown stages of the API.
+*/
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	stageRunning int32 = iota
+	stageDraining
+	stageFrozen
+)
+
+// Time clients are told to wait before retrying while the server restarts.
+var RetryAfter = 10 * time.Second
+
+var shutdownStage atomic.Int32
+var shutdownChan = make(chan struct{})
+var waitingClients atomic.Int64
+
+// Stops accepting new sessions and wakes all clients waiting for their turn.
+// Running games can still be played.
+func Drain() {
+	if shutdownStage.CompareAndSwap(stageRunning, stageDraining) {
+		close(shutdownChan)
+	}
+}
+
+// Rejects every request that changes game state, so a snapshot taken
+// afterwards is final.
+func Freeze() {
+	Drain()
+	shutdownStage.Store(stageFrozen)
+}
+
+// Waits until no client is blocked in a 'turn' request anymore.
+func WaitForClients(ctx context.Context) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for waitingClients.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d clients still waiting: %w", waitingClients.Load(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// Writes a 'server restarting' response if the given stage is reached.
+func rejectDuringShutdown(w http.ResponseWriter, from int32) bool {
+	if shutdownStage.Load() < from {
+		return false
+	}
+	writeRestarting(w)
+	return true
+}
+
+func writeRestarting(w http.ResponseWriter) {
+	restartingError().write(w)
+}
+
+func restartingError() *apiError {
+	return &apiError{http.StatusServiceUnavailable, CodeServerRestarting, "Server restarting, retry later.", map[string]any{"retryafter": int(RetryAfter.Seconds())}}
+}
@@ -1,8 +1,13 @@
 package api
 
+import "time"
+
 // Create new game
 type ReqPostSessions struct {
-	Name string `json:"name"`
+	Name  string `json:"name"`
+	White string `json:"white,omitempty"` // Bot launched as white
+	Black string `json:"black,omitempty"` // Bot launched as black
+	Rated bool   `json:"rated,omitempty"` // The result updates the ratings, players need a name
 }
 type RespPostSessions struct {
 	BoardID  int32  `json:"boardid"`
@@ -27,9 +32,12 @@ type GameNameAndID struct {
 type ReqPutSessions struct {
 	BoardID int32  `json:"boardid"`
 	Color   string `json:"color"`
+	Engine  string `json:"engine,omitempty"` // Seat a built-in engine instead, e.g. "alphabeta"
+	Bot     string `json:"bot,omitempty"`    // Launch a bot of the bots file instead
+	Player  string `json:"player,omitempty"` // Rating identity, rated games need an account instead
 }
 type RespPutSessions struct {
-	Token string `json:"token"`
+	Token string `json:"token,omitempty"` // Unset for engine and bot seats
 }
 
 // Get game data
@@ -43,9 +51,10 @@ type ReqGetGame struct {
 }
 
 type RespMove struct {
-	From    [2]int `json:"from"`
-	To      [2]int `json:"to"`
-	Capture bool   `json:"capture"`
+	From      [2]int `json:"from"`
+	To        [2]int `json:"to"`
+	Capture   bool   `json:"capture"`
+	Promotion string `json:"promotion,omitempty"`
 }
 
 // Apply move
@@ -55,3 +64,288 @@ type ReqPutGame struct {
 	Move    string `json:"move,omitempty"`
 	ReqType string `json:"reqtype"` // "forfeit" "move" "randommove"
 }
+
+// Liveness and readiness
+type RespHealth struct {
+	Status string            `json:"status"` // "ok" or "unavailable"
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Server information
+type RespInfo struct {
+	Version       string       `json:"version"`
+	UptimeSeconds int64        `json:"uptimeseconds"`
+	APIVersions   []string     `json:"apiversions"`
+	Features      InfoFeatures `json:"features"`
+	Games         InfoGames    `json:"games"`
+}
+type InfoFeatures struct {
+	Variants  []string        `json:"variants"`
+	Clocks    []string        `json:"clocks"`
+	Notations []string        `json:"notations"`
+	Engines   []string        `json:"engines"`
+	Bots      []string        `json:"bots"`
+	Openings  []string        `json:"openings"` // Opening suites for tournaments
+	Books     []string        `json:"books"`    // Polyglot opening books
+	Toggles   map[string]bool `json:"toggles"`
+}
+type InfoGames struct {
+	Active   int `json:"active"`
+	Finished int `json:"finished"`
+}
+
+// Error returned by all endpoints on failure
+type RespError struct {
+	Code    string         `json:"code"`    // Stable machine-readable code, e.g. "not_your_turn"
+	Message string         `json:"message"` // Human readable description
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// v2: Game resource
+type RespGame struct {
+	ID          int32       `json:"id"`
+	Name        string      `json:"name"`
+	Started     bool        `json:"started"`
+	Rated       bool        `json:"rated"`
+	Players     RespPlayers `json:"players"`
+	TurnColor   string      `json:"turncolor"` // "w", "b" or "n" if nobody has to move
+	Winner      string      `json:"winner"`    // "n" while ongoing, "w", "b" or "r" for remis
+	Termination string      `json:"termination,omitempty"`
+	Opening     string      `json:"opening,omitempty"` // Opening the game started from, its moves count as plies
+	Plies       int         `json:"plies"`
+}
+type RespPlayers struct {
+	White        bool   `json:"w"`
+	Black        bool   `json:"b"`
+	WhitePlayer  string `json:"wplayer,omitempty"` // Rating identity
+	BlackPlayer  string `json:"bplayer,omitempty"`
+	WhiteAccount bool   `json:"waccount,omitempty"` // The player is the account of that name
+	BlackAccount bool   `json:"baccount,omitempty"`
+}
+type RespGames struct {
+	Games []RespGame `json:"games"`
+}
+
+// v2: Create a game
+type ReqPostGame struct {
+	Name  string `json:"name"`
+	White string `json:"white,omitempty"` // Bot launched as white
+	Black string `json:"black,omitempty"` // Bot launched as black
+	Rated bool   `json:"rated,omitempty"` // The result updates the ratings, players need a name
+}
+type RespPostGame struct {
+	ID       int32  `json:"id"`
+	Password string `json:"password"`
+}
+
+// v2: Join a game
+type RespPutPlayer struct {
+	Color     string     `json:"color"`
+	Token     string     `json:"token,omitempty"`     // Unset for engine and bot seats
+	ExpiresAt *time.Time `json:"expiresat,omitempty"` // Unset if the token doesn't expire
+}
+
+// v2: Play a move
+type ReqPostMove struct {
+	Move   string `json:"move,omitempty"`   // e.g. "e2 e4" or "e7 e8q"
+	Random bool   `json:"random,omitempty"` // Play a random legal move instead
+}
+
+// v2: Move of the game history
+type RespPlayedMove struct {
+	Ply   int    `json:"ply"` // Index of the position the move leads to
+	Color string `json:"color"`
+	Move  string `json:"move"`
+}
+type RespMoves struct {
+	Moves []RespPlayedMove `json:"moves"`
+}
+
+// v2: Legal moves of a piece or of the side to move
+type RespLegalMoves struct {
+	Moves []string `json:"moves"`
+}
+
+// v2: Book moves of a position, empty if it's out of book
+type RespBookMoves struct {
+	Book  string         `json:"book"`
+	Key   string         `json:"key"` // Polyglot key of the position in hex
+	Moves []RespBookMove `json:"moves"`
+}
+type RespBookMove struct {
+	Move   string  `json:"move"`
+	Weight int     `json:"weight"`
+	Share  float64 `json:"share"` // Weight relative to all book moves of the position
+}
+
+// v2: Matchmaking
+type ReqPostMatchmaking struct {
+	Player    string  `json:"player,omitempty"`    // Rating identity, rated games need an account instead
+	MoveTime  string  `json:"movetime,omitempty"`  // e.g. "500ms", only bots asking for the same are paired
+	Rated     bool    `json:"rated,omitempty"`     // Only paired with bots asking for the same
+	MinRating float64 `json:"minrating,omitempty"` // Lowest rating of the opponent, 0 for no limit
+	MaxRating float64 `json:"maxrating,omitempty"` // Highest rating of the opponent, 0 for no limit
+	Wait      string  `json:"wait,omitempty"`      // Time to wait for a match before responding, e.g. "30s"
+}
+type RespTicket struct {
+	ID        string     `json:"id"`
+	Secret    string     `json:"secret,omitempty"` // Only returned on creation, sent as Bearer token to poll or leave
+	Status    string     `json:"status"`           // "waiting", "pairing", "matched" or "failed"
+	Player    string     `json:"player,omitempty"`
+	MoveTime  string     `json:"movetime,omitempty"`
+	Rated     bool       `json:"rated"`
+	MinRating float64    `json:"minrating,omitempty"`
+	MaxRating float64    `json:"maxrating,omitempty"`
+	CreatedAt time.Time  `json:"createdat"`
+	Match     *RespMatch `json:"match,omitempty"` // Set once matched
+	Error     string     `json:"error,omitempty"` // Set if failed
+}
+
+// Seat of a matched bot, play it like a joined game
+type RespMatch struct {
+	BoardID  int32  `json:"boardid"`
+	Color    string `json:"color"`
+	Token    string `json:"token"`
+	Opponent string `json:"opponent,omitempty"`
+}
+
+// v2: Tournaments
+type TournamentParticipant struct {
+	Name   string `json:"name,omitempty"`   // Defaults to the engine or bot name
+	Engine string `json:"engine,omitempty"` // Built-in engine, e.g. "alphabeta"
+	Bot    string `json:"bot,omitempty"`    // Bot of the bots file
+}
+type ReqPostTournament struct {
+	Name            string                  `json:"name"`
+	Format          string                  `json:"format"` // "roundrobin", "doubleroundrobin", "gauntlet", "swiss" or "sprt"
+	Participants    []TournamentParticipant `json:"participants"`
+	GamesPerPairing int                     `json:"gamesperpairing,omitempty"` // Default 2, 1 for swiss, colors alternate
+	Rounds          int                     `json:"rounds,omitempty"`          // Swiss: default log2 of the participants rounded up, sprt: maximum pairs, default 1000
+	SPRT            *TournamentSPRT         `json:"sprt,omitempty"`            // sprt only, default elo0 0, elo1 5, alpha and beta 0.05
+	MoveTime        string                  `json:"movetime,omitempty"`        // e.g. "500ms", exceeding it loses the game
+	MaxPlies        int                     `json:"maxplies,omitempty"`        // Games reaching it are drawn
+	Concurrency     int                     `json:"concurrency,omitempty"`     // Games at the same time, default 1
+	Rated           bool                    `json:"rated,omitempty"`           // Games update the ratings of the engines and bots
+	Openings        string                  `json:"openings,omitempty"`        // Opening suite of the server, each opening is played with both colors
+}
+type RespPostTournament struct {
+	ID       int32  `json:"id"`
+	Password string `json:"password"`
+}
+type RespTournament struct {
+	ID              int32                   `json:"id"`
+	Name            string                  `json:"name"`
+	Format          string                  `json:"format"`
+	Status          string                  `json:"status"` // "running", "finished" or "cancelled"
+	Participants    []TournamentParticipant `json:"participants"`
+	GamesPerPairing int                     `json:"gamesperpairing"`
+	Rounds          int                     `json:"rounds,omitempty"` // Swiss and sprt only
+	Round           int                     `json:"round"`            // Highest round paired so far
+	MoveTime        string                  `json:"movetime,omitempty"`
+	MaxPlies        int                     `json:"maxplies,omitempty"`
+	Concurrency     int                     `json:"concurrency"`
+	Rated           bool                    `json:"rated"`
+	Openings        string                  `json:"openings,omitempty"`
+	Games           int                     `json:"games"`
+	FinishedGames   int                     `json:"finishedgames"`
+	Byes            []RespBye               `json:"byes,omitempty"`
+	SPRT            *RespSPRT               `json:"sprt,omitempty"`
+	Standings       []RespStanding          `json:"standings"`
+}
+type TournamentSPRT struct {
+	Elo0  float64 `json:"elo0"`
+	Elo1  float64 `json:"elo1"`
+	Alpha float64 `json:"alpha,omitempty"`
+	Beta  float64 `json:"beta,omitempty"`
+}
+
+// Test of the first participant against the second one
+type RespSPRT struct {
+	TournamentSPRT
+	Pairs       int     `json:"pairs"`
+	Pentanomial [5]int  `json:"pentanomial"` // Pairs by points of the tested participant: 0, 0.5, 1, 1.5, 2
+	LLR         float64 `json:"llr"`
+	Lower       float64 `json:"lower"`    // H0 is accepted at or below
+	Upper       float64 `json:"upper"`    // H1 is accepted at or above
+	Decision    string  `json:"decision"` // "H0", "H1" or "" while running
+	Elo         float64 `json:"elo"`
+	EloError    float64 `json:"eloerror"` // 95% confidence
+}
+type RespBye struct {
+	Round       int    `json:"round"`
+	Participant string `json:"participant"`
+}
+type RespTournaments struct {
+	Tournaments []RespTournament `json:"tournaments"`
+}
+type RespScore struct {
+	Games  int     `json:"games"`
+	Wins   int     `json:"wins"`
+	Draws  int     `json:"draws"`
+	Losses int     `json:"losses"`
+	Points float64 `json:"points"`
+}
+type RespStanding struct {
+	Rank        int    `json:"rank"`
+	Participant string `json:"participant"`
+	RespScore
+	Byes            int     `json:"byes"`
+	Buchholz        float64 `json:"buchholz"`
+	SonnebornBerger float64 `json:"sonnebornberger"`
+}
+type RespTournamentGame struct {
+	Index       int    `json:"index"`
+	Round       int    `json:"round"`
+	White       string `json:"white"`
+	Black       string `json:"black"`
+	Status      string `json:"status"` // "pending", "running", "finished" or "failed"
+	Opening     string `json:"opening,omitempty"`
+	BoardID     int32  `json:"boardid,omitempty"`
+	Winner      string `json:"winner,omitempty"`
+	Termination string `json:"termination,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+type RespTournamentGames struct {
+	Games []RespTournamentGame `json:"games"`
+}
+
+// Scores[i][j] is the score of participant i against j, null if they don't meet
+type RespCrosstable struct {
+	Participants []string       `json:"participants"`
+	Scores       [][]*RespScore `json:"scores"`
+}
+
+// v2: Ratings
+type RespRating struct {
+	Rank       int       `json:"rank,omitempty"`
+	Player     string    `json:"player"`
+	Rating     float64   `json:"rating"`
+	Deviation  float64   `json:"deviation"` // Twice the deviation is a 95% confidence interval
+	Volatility float64   `json:"volatility"`
+	Games      int       `json:"games"`
+	Wins       int       `json:"wins"`
+	Draws      int       `json:"draws"`
+	Losses     int       `json:"losses"`
+	LastGame   time.Time `json:"lastgame"`
+	Account    bool      `json:"account,omitempty"` // The player is an account
+}
+type RespLeaderboard struct {
+	Players []RespRating `json:"players"`
+}
+
+// v2: Accounts
+type ReqPostAccount struct {
+	Name string `json:"name"` // Player name of the account
+}
+type RespAccount struct {
+	Name      string      `json:"name"`
+	CreatedAt time.Time   `json:"createdat"`
+	Rating    *RespRating `json:"rating,omitempty"` // Set once the account played a rated game
+}
+type RespPostAccount struct {
+	RespAccount
+	Key string `json:"key"` // API key, send it in the X-API-Key header
+}
+type RespAccounts struct {
+	Accounts []RespAccount `json:"accounts"`
+}
@@ -0,0 +1,179 @@
+/*
+Tournament games on the server. Each game of a tournament is a normal
+session whose seats are taken by engines or bots.
+*/
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+	"github.com/matetirpak/chessbot-playground-server/internal/engine"
+	"github.com/matetirpak/chessbot-playground-server/internal/logging"
+	"github.com/matetirpak/chessbot-playground-server/internal/openings"
+	"github.com/matetirpak/chessbot-playground-server/internal/tournament"
+)
+
+// Time a move may take longer than the move time of a tournament before
+// the side to move loses on time, covering polling and network latency.
+var MoveTimeMargin = time.Second
+
+// Extra time of the first move of each side, for launching bots.
+const startupMargin = 5 * time.Second
+
+// Checks that the engines and bots of the participants exist.
+func checkParticipants(participants []tournament.Participant) *apiError {
+	for _, p := range participants {
+		if p.Bot != "" {
+			if _, apiErr := lookupBot(p.Bot); apiErr != nil {
+				return apiErr
+			}
+		}
+		if p.Engine != "" {
+			if _, err := engine.New(p.Engine); err != nil {
+				return &apiError{http.StatusBadRequest, CodeUnknownEngine, err.Error(), map[string]any{"engine": p.Engine, "engines": engine.Names()}}
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the opening suite registered under name.
+func lookupOpenings(name string) ([]openings.Opening, *apiError) {
+	suite, err := openings.Lookup(name)
+	if err != nil {
+		return nil, &apiError{http.StatusBadRequest, CodeUnknownOpenings, err.Error(), map[string]any{"openings": name, "suites": openings.Names()}}
+	}
+	return suite, nil
+}
+
+// Returns the opening a tournament game starts from. All games of a
+// pairing play the same opening.
+func tournamentOpening(spec *tournament.Spec, tgame *tournament.Game) (openings.Opening, *apiError) {
+	suite, apiErr := lookupOpenings(spec.Openings)
+	if apiErr != nil {
+		return openings.Opening{}, apiErr
+	}
+	return suite[spec.Pairing(tgame)%len(suite)], nil
+}
+
+// Takes a seat with the engine or bot of a participant.
+func seatParticipant(ctx context.Context, game *data.Game, color string, p tournament.Participant) *apiError {
+	var apiErr *apiError
+	if p.Bot != "" {
+		apiErr = seatBot(ctx, game, color, p.Bot)
+	} else {
+		apiErr = seatEngine(ctx, game, color, p.Engine)
+	}
+	return apiErr
+}
+
+// Restarts the running tournaments, e.g. after loading a snapshot.
+func ResumeTournaments(running []*tournament.Tournament) {
+	for _, t := range running {
+		go t.Run(playTournamentGame)
+	}
+}
+
+// Plays a game of a tournament. Implements tournament.PlayFunc.
+func playTournamentGame(ctx context.Context, t *tournament.Tournament, tgame tournament.Game) (tournament.Result, error) {
+	state := t.State()
+	spec := state.Spec
+	white, black := spec.Participants[tgame.White], spec.Participants[tgame.Black]
+	logger := slog.With("tournament", state.ID, "game", tgame.Index, "white", white.Name, "black", black.Name)
+	ctx = logging.NewContext(ctx, logger)
+
+	var game *data.Game
+	if tgame.BoardID != 0 {
+		var apiErr *apiError
+		if game, apiErr = lookupGame(tgame.BoardID); apiErr != nil {
+			return tournament.Result{}, errors.New(apiErr.message)
+		}
+	} else {
+		if shutdownStage.Load() >= stageDraining {
+			return tournament.Result{}, tournament.ErrInterrupted
+		}
+		var opening openings.Opening
+		if spec.Openings != "" {
+			var apiErr *apiError
+			if opening, apiErr = tournamentOpening(&spec, &tgame); apiErr != nil {
+				return tournament.Result{}, errors.New(apiErr.message)
+			}
+		}
+		game, _ = createGame(ctx, fmt.Sprintf("%s #%d: %s - %s", spec.Name, tgame.Index+1, white.Name, black.Name), spec.Rated)
+		game.Mu.Lock()
+		game.MoveTime = spec.MoveTime
+		if spec.Openings != "" {
+			startFromOpening(game, opening)
+		}
+		game.Mu.Unlock()
+		t.Started(tgame.Index, game.ID)
+
+		for color, p := range map[string]tournament.Participant{"w": white, "b": black} {
+			if apiErr := seatParticipant(ctx, game, color, p); apiErr != nil {
+				data.RemoveGame(game.ID)
+				return tournament.Result{}, fmt.Errorf("seating %s: %s", p.Name, apiErr.message)
+			}
+		}
+	}
+	return watchGame(ctx, game, spec.MoveTime, spec.MaxPlies)
+}
+
+// Waits for the end of a game and enforces a move time and ply limit,
+// 0 for none. Used for tournament and matchmaking games.
+func watchGame(ctx context.Context, game *data.Game, moveTime time.Duration, maxPlies int) (tournament.Result, error) {
+	logger := logging.FromContext(ctx)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	plies := -1
+	var turnStart time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return tournament.Result{}, ctx.Err()
+		case <-game.Done:
+			return tournament.Result{}, errors.New("game was removed")
+		case <-ticker.C:
+		}
+		if shutdownStage.Load() >= stageFrozen {
+			return tournament.Result{}, tournament.ErrInterrupted
+		}
+
+		game.Mu.RLock()
+		result := tournament.Result{Winner: game.Winner, Termination: game.Termination}
+		// Moves of the opening don't count
+		n := len(game.BoardData) - 1 - game.OpeningPlies
+		turnColor := game.BoardData[len(game.BoardData)-1].TurnColor
+		game.Mu.RUnlock()
+
+		if result.Winner != "n" {
+			logger.Info("game ended", "winner", result.Winner, "termination", result.Termination)
+			return result, nil
+		}
+		if n != plies {
+			plies, turnStart = n, time.Now()
+		}
+		if maxPlies > 0 && plies >= maxPlies {
+			endGame(game, "r", "adjudicated")
+			continue
+		}
+		limit := moveTime + MoveTimeMargin
+		if plies < 2 {
+			limit += startupMargin
+		}
+		if moveTime > 0 && turnColor != "n" && time.Since(turnStart) > limit {
+			winner := "w"
+			if turnColor == "w" {
+				winner = "b"
+			}
+			logger.Info("move time exceeded", "color", turnColor)
+			endGame(game, winner, "time")
+		}
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Local bot programs the server can launch as players. A bot is a command
+line plus environment, read from a YAML or JSON file:
+
+	mybot:
+	  command: ["python3", "bot.py", "--board", "{boardid}"]
+	  env:
+	    BOT_DEPTH: "3"
+	  dir: /opt/mybot
+
+Each seat runs its own process. The seat is passed in the environment
+variables CHESSBOT_SERVER, CHESSBOT_BOARDID, CHESSBOT_COLOR, CHESSBOT_TOKEN,
+CHESSBOT_PASSWORD and CHESSBOT_MOVETIME (milliseconds, 0 for no limit), and
+as the placeholders {server}, {boardid}, {color}, {token}, {password} and
+{movetime} in the command. Bots should read their token from
+CHESSBOT_TOKEN only: command lines show up in process listings, which is
+why the placeholders {token} and {password} are redacted in the bot log.
+*/
+package bots
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A bot program.
+type Bot struct {
+	Name    string            `json:"-" yaml:"-"`
+	Command []string          `json:"command" yaml:"command"`
+	Env     map[string]string `json:"env,omitempty" yaml:"env,omitempty"`
+	Dir     string            `json:"dir,omitempty" yaml:"dir,omitempty"` // Working directory
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Bot{}
+)
+
+// Reads the bots of a YAML or JSON file, keyed by name.
+func LoadFile(path string) ([]Bot, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]Bot)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(content, &byName)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &byName)
+	default:
+		return nil, errors.New("unsupported file type, use .yaml, .yml or .json")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("bots file %s: %w", path, err)
+	}
+
+	bots := make([]Bot, 0, len(byName))
+	for name, bot := range byName {
+		if len(bot.Command) == 0 {
+			return nil, fmt.Errorf("bots file %s: bot %q has no command", path, name)
+		}
+		bot.Name = name
+		bots = append(bots, bot)
+	}
+	sort.Slice(bots, func(i, j int) bool { return bots[i].Name < bots[j].Name })
+	return bots, nil
+}
+
+// Makes a bot available under its name. Registering a name again replaces
+// the previous bot.
+func Register(bot Bot) {
+	registryMu.Lock()
+	registry[bot.Name] = bot
+	registryMu.Unlock()
+}
+
+// Returns the bot registered under name.
+func Lookup(name string) (Bot, error) {
+	registryMu.RLock()
+	bot, exists := registry[name]
+	registryMu.RUnlock()
+	if !exists {
+		return Bot{}, fmt.Errorf("unknown bot %q", name)
+	}
+	return bot, nil
+}
+
+// Returns the sorted names of all registered bots.
+func Names() []string {
+	registryMu.RLock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	registryMu.RUnlock()
+	sort.Strings(names)
+	return names
+}
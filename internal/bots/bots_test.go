@@ -0,0 +1,162 @@
+/*
+Unittest for the bots package.
+*/
+package bots
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The test binary doubles as bot program, see TestMain.
+const helperEnv = "BOTS_TEST_HELPER"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(helperEnv) {
+	case "":
+		os.Exit(m.Run())
+	case "print":
+		os.Stdout.WriteString("seat " + os.Getenv("CHESSBOT_BOARDID") + " " + os.Getenv("CHESSBOT_COLOR") + " " + os.Getenv("CHESSBOT_TOKEN") + "\n")
+		os.Stderr.WriteString("args " + strings.Join(os.Args[1:], " ") + "\n")
+		os.Exit(0)
+	case "sleep":
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	}
+}
+
+func helperBot(t *testing.T, mode string, args ...string) Bot {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Bot{Name: mode, Command: append([]string{exe}, args...), Env: map[string]string{helperEnv: mode}}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "bots.yaml")
+	os.WriteFile(yamlPath, []byte(`
+minimax:
+  command: ["./minimax", "--depth", "4"]
+  env:
+    LEVEL: "2"
+random:
+  command: ["python3", "random_bot.py"]
+  dir: /opt/bots
+`), 0o644)
+
+	bots, err := LoadFile(yamlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bots) != 2 || bots[0].Name != "minimax" || bots[1].Name != "random" {
+		t.Fatalf("unexpected bots %+v", bots)
+	}
+	if !slices.Equal(bots[0].Command, []string{"./minimax", "--depth", "4"}) || bots[0].Env["LEVEL"] != "2" {
+		t.Errorf("unexpected minimax bot %+v", bots[0])
+	}
+	if bots[1].Dir != "/opt/bots" {
+		t.Errorf("unexpected dir %q", bots[1].Dir)
+	}
+
+	jsonPath := filepath.Join(dir, "bots.json")
+	os.WriteFile(jsonPath, []byte(`{"empty": {"command": []}}`), 0o644)
+	if _, err := LoadFile(jsonPath); err == nil {
+		t.Error("expected error for bot without command")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	Register(Bot{Name: "registered", Command: []string{"true"}})
+	if _, err := Lookup("registered"); err != nil {
+		t.Error(err)
+	}
+	if _, err := Lookup("missing"); err == nil {
+		t.Error("expected error for unknown bot")
+	}
+	if !slices.Contains(Names(), "registered") {
+		t.Errorf("registered bot missing in %v", Names())
+	}
+}
+
+func TestLaunchPassesSeat(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "logs", "1-w-print.log")
+	seat := Seat{Server: "http://localhost:8080", BoardID: 7, Color: "w", Token: "secret"}
+
+	proc, err := Launch(helperBot(t, "print", "--board", "{boardid}", "--color", "{color}", "--token", "{token}"), seat, logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-proc.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("bot did not exit")
+	}
+	if err := proc.Err(); err != nil {
+		t.Errorf("unexpected exit error %v", err)
+	}
+
+	log, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"seat 7 w secret", "args --board 7 --color w", "--token [redacted]", "exited"} {
+		if !strings.Contains(string(log), want) {
+			t.Errorf("log misses %q:\n%s", want, log)
+		}
+	}
+	for _, line := range strings.Split(string(log), "\n") {
+		if strings.HasPrefix(line, "===") && strings.Contains(line, "secret") {
+			t.Errorf("launch line shows the token: %s", line)
+		}
+	}
+	for path, mode := range map[string]os.FileMode{logPath: 0o600, filepath.Dir(logPath): 0o700 | os.ModeDir} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode() != mode {
+			t.Errorf("expected mode %v for %s, got %v", mode, path, info.Mode())
+		}
+	}
+}
+
+func TestStop(t *testing.T) {
+	dir := t.TempDir()
+	first, err := Launch(helperBot(t, "sleep"), Seat{Color: "w"}, filepath.Join(dir, "w.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Launch(helperBot(t, "sleep"), Seat{Color: "b"}, filepath.Join(dir, "b.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	first.Stop(100 * time.Millisecond)
+	if first.Err() == nil {
+		t.Error("expected exit error of stopped bot")
+	}
+	StopAll(100 * time.Millisecond)
+	select {
+	case <-second.Done():
+	default:
+		t.Error("StopAll returned before the bot exited")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("stopping took %v", elapsed)
+	}
+}
+
+func TestLaunchFails(t *testing.T) {
+	bot := Bot{Name: "missing", Command: []string{filepath.Join(t.TempDir(), "missing")}}
+	if _, err := Launch(bot, Seat{}, filepath.Join(t.TempDir(), "missing.log")); err == nil {
+		t.Error("expected error for missing executable")
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Running bot processes.
+*/
+package bots
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The seat a bot process plays.
+type Seat struct {
+	Server   string // Base URL of the API, e.g. "http://localhost:8080"
+	BoardID  int32
+	Color    string
+	Token    string
+	Password string        // Credential for v1 turn waiting, the API passes the token
+	MoveTime time.Duration // Time per move, 0 if the bot may choose
+}
+
+// A launched bot. The process is gone once Done is closed.
+type Process struct {
+	Bot  Bot
+	Seat Seat
+
+	cmd  *exec.Cmd
+	log  *os.File
+	done chan struct{}
+	err  error
+}
+
+var (
+	runningMu sync.Mutex
+	running   = map[*Process]struct{}{}
+)
+
+// Starts a bot for the given seat. Its stdout and stderr are appended to
+// the file at logPath, which only the owner can read: bots may log their
+// token.
+func Launch(bot Bot, seat Seat, logPath string) (*Process, error) {
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o700); err != nil {
+		return nil, err
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := logFile.Chmod(0o600); err != nil {
+		logFile.Close()
+		return nil, err
+	}
+
+	args := expand(bot.Command, seat)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = bot.Dir
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.Env = os.Environ()
+	for name, value := range bot.Env {
+		cmd.Env = append(cmd.Env, name+"="+expand([]string{value}, seat)[0])
+	}
+	cmd.Env = append(cmd.Env,
+		"CHESSBOT_SERVER="+seat.Server,
+		"CHESSBOT_BOARDID="+strconv.Itoa(int(seat.BoardID)),
+		"CHESSBOT_COLOR="+seat.Color,
+		"CHESSBOT_TOKEN="+seat.Token,
+		"CHESSBOT_PASSWORD="+seat.Password,
+		"CHESSBOT_MOVETIME="+strconv.FormatInt(seat.MoveTime.Milliseconds(), 10),
+	)
+
+	redacted := seat
+	redacted.Token, redacted.Password = "[redacted]", "[redacted]"
+	fmt.Fprintf(logFile, "=== %s starting %s as %s on board %d: %s\n",
+		time.Now().Format(time.RFC3339), bot.Name, seat.Color, seat.BoardID, strings.Join(expand(bot.Command, redacted), " "))
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(logFile, "=== failed to start: %v\n", err)
+		logFile.Close()
+		return nil, fmt.Errorf("starting bot %s: %w", bot.Name, err)
+	}
+
+	p := &Process{Bot: bot, Seat: seat, cmd: cmd, log: logFile, done: make(chan struct{})}
+	runningMu.Lock()
+	running[p] = struct{}{}
+	runningMu.Unlock()
+
+	go func() {
+		p.err = cmd.Wait()
+		fmt.Fprintf(logFile, "=== %s exited: %s\n", time.Now().Format(time.RFC3339), cmd.ProcessState)
+		logFile.Close()
+
+		runningMu.Lock()
+		delete(running, p)
+		runningMu.Unlock()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// Closed once the process has exited.
+func (p *Process) Done() <-chan struct{} {
+	return p.done
+}
+
+// Returns the exit error of the process once Done is closed.
+func (p *Process) Err() error {
+	<-p.done
+	return p.err
+}
+
+// Asks the process to exit and kills it if it is still running after
+// the grace period. Returns once the process is gone.
+func (p *Process) Stop(grace time.Duration) {
+	select {
+	case <-p.done:
+		return
+	default:
+	}
+
+	if err := p.cmd.Process.Signal(os.Interrupt); err != nil {
+		p.cmd.Process.Kill()
+	}
+	select {
+	case <-p.done:
+	case <-time.After(grace):
+		p.cmd.Process.Kill()
+		<-p.done
+	}
+}
+
+// Stops all running bot processes, e.g. on server shutdown.
+func StopAll(grace time.Duration) {
+	runningMu.Lock()
+	procs := make([]*Process, 0, len(running))
+	for p := range running {
+		procs = append(procs, p)
+	}
+	runningMu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, p := range procs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Stop(grace)
+		}()
+	}
+	wg.Wait()
+}
+
+// Replaces the seat placeholders in args.
+func expand(args []string, seat Seat) []string {
+	replacer := strings.NewReplacer(
+		"{server}", seat.Server,
+		"{boardid}", strconv.Itoa(int(seat.BoardID)),
+		"{color}", seat.Color,
+		"{token}", seat.Token,
+		"{password}", seat.Password,
+		"{movetime}", strconv.FormatInt(seat.MoveTime.Milliseconds(), 10),
+	)
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		expanded[i] = replacer.Replace(arg)
+	}
+	return expanded
+}
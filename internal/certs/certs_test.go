@@ -0,0 +1,42 @@
+/*
+Unittest for the certs package.
+*/
+package certs
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOrCreateSelfSigned(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls", "cert.pem")
+	keyFile := filepath.Join(dir, "tls", "key.pem")
+	hosts := []string{"localhost", "127.0.0.1"}
+
+	created, err := LoadOrCreateSelfSigned(certFile, keyFile, hosts)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	if err := created.Leaf.VerifyHostname("localhost"); err != nil {
+		t.Errorf("certificate not valid for localhost: %v", err)
+	}
+	if err := created.Leaf.VerifyHostname("127.0.0.1"); err != nil {
+		t.Errorf("certificate not valid for 127.0.0.1: %v", err)
+	}
+
+	cached, err := LoadOrCreateSelfSigned(certFile, keyFile, hosts)
+	if err != nil {
+		t.Fatalf("failed to load cached certificate: %v", err)
+	}
+	if !bytes.Equal(created.Certificate[0], cached.Certificate[0]) {
+		t.Errorf("expected the cached certificate to be reused")
+	}
+}
+
+func TestGenerateSelfSignedWithoutHosts(t *testing.T) {
+	if _, _, err := GenerateSelfSigned(nil, ValidFor); err == nil {
+		t.Errorf("expected error when no hosts are given")
+	}
+}
@@ -0,0 +1,398 @@
+/*
+Server configuration from command-line flags, environment variables
+and an optional YAML or JSON file.
+
+Every setting has a flag name (e.g. "api-addr"), which is also its key
+in the config file. The matching environment variable is the flag name
+in upper case with dashes replaced by underscores and prefixed with
+"CHESSBOT_" (e.g. CHESSBOT_API_ADDR).
+
+Precedence, from lowest to highest:
+ 1. built-in defaults
+ 2. config file (-config or CHESSBOT_CONFIG)
+ 3. environment variables
+ 4. command-line flags
+*/
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/matetirpak/chessbot-playground-server/internal/reaper"
+)
+
+const EnvPrefix = "CHESSBOT_"
+
+var LogLevels = []string{"debug", "info", "warn", "error"}
+var LogFormats = []string{"text", "json"}
+
+// Settings whose values are not logged.
+var secrets = []string{"admin-token"}
+
+// Config holds the effective server settings.
+type Config struct {
+	ConfigFile      string
+	APIAddr         string
+	WebAddr         string
+	SinglePort      bool
+	CORSOrigins     []string
+	TurnTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	DrainTimeout    time.Duration
+	RetryAfter      time.Duration
+	PersistencePath string
+	LogLevel        string
+	LogFormat       string
+	TLS             TLS
+	Features        Features
+	Reaper          reaper.Policy
+	UCIEngines      map[string]string // Engine name to executable path
+	OpeningSuites   map[string]string // Suite name to EPD, FEN or PGN file
+	Books           map[string]string // Book name to Polyglot .bin file
+	EngineBook      string            // Book the built-in engines play from
+	AdminToken      string            // Token of the account administration, empty disables it
+	TokenTTL        time.Duration     // Lifetime of player tokens, 0 for no expiry
+	Bots            Bots
+
+	flags   *flag.FlagSet
+	sources map[string]string
+}
+
+// TLS settings shared by the API and web UI servers.
+type TLS struct {
+	CertFile     string
+	KeyFile      string
+	SelfSigned   bool
+	Hosts        []string
+	RedirectAddr string
+}
+
+// Reports whether the servers use HTTPS.
+func (t TLS) Enabled() bool {
+	return t.SelfSigned || t.CertFile != ""
+}
+
+// Local bot programs the server launches as players.
+type Bots struct {
+	File      string        // YAML or JSON file defining the bots
+	LogDir    string        // Directory of the per-game bot logs
+	Timeout   time.Duration // Maximum run time of a bot process
+	ServerURL string        // API URL passed to the bots
+}
+
+// Features that can be switched on or off.
+type Features struct {
+	WebUI   bool
+	Swagger bool
+	Metrics bool
+	Reaper  bool
+}
+
+// Loads the configuration. args are the command-line arguments without
+// the program name. Returns flag.ErrHelp if help was requested.
+func Load(args []string, output io.Writer) (*Config, error) {
+	cfg := &Config{sources: make(map[string]string)}
+	fs := cfg.newFlagSet(output)
+	cfg.flags = fs
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	fs.VisitAll(func(f *flag.Flag) { cfg.sources[f.Name] = "default" })
+	fs.Visit(func(f *flag.Flag) { cfg.sources[f.Name] = "flag" })
+
+	if cfg.sources["config"] != "flag" {
+		if path, ok := os.LookupEnv(envName("config")); ok {
+			cfg.ConfigFile = path
+			cfg.sources["config"] = "env"
+		}
+	}
+
+	if cfg.ConfigFile != "" {
+		values, err := readFile(cfg.ConfigFile)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: %w", cfg.ConfigFile, err)
+		}
+		for name, value := range values {
+			if name == "config" || fs.Lookup(name) == nil {
+				return nil, fmt.Errorf("config file %s: unknown setting %q", cfg.ConfigFile, name)
+			}
+			if err := cfg.set(name, value, "file"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var envErr error
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" || envErr != nil {
+			return
+		}
+		if value, ok := os.LookupEnv(envName(f.Name)); ok {
+			envErr = cfg.set(f.Name, value, "env")
+		}
+	})
+	if envErr != nil {
+		return nil, envErr
+	}
+
+	if cfg.Reaper.ArchiveDir == "" && cfg.PersistencePath != "" {
+		cfg.Reaper.ArchiveDir = filepath.Join(cfg.PersistencePath, "archive")
+	}
+	if cfg.TLS.SelfSigned && cfg.TLS.CertFile == "" && cfg.TLS.KeyFile == "" {
+		dir := cfg.PersistencePath
+		if dir == "" {
+			cacheDir, err := os.UserCacheDir()
+			if err != nil {
+				return nil, fmt.Errorf("no directory to cache the self-signed certificate: %w", err)
+			}
+			dir = filepath.Join(cacheDir, "chessbot-playground")
+		}
+		cfg.TLS.CertFile = filepath.Join(dir, "tls", "cert.pem")
+		cfg.TLS.KeyFile = filepath.Join(dir, "tls", "key.pem")
+	}
+	if cfg.Bots.LogDir == "" {
+		if cfg.PersistencePath != "" {
+			cfg.Bots.LogDir = filepath.Join(cfg.PersistencePath, "botlogs")
+		} else {
+			cfg.Bots.LogDir = filepath.Join(os.TempDir(), "chessbot-botlogs")
+		}
+	}
+	if cfg.Bots.ServerURL == "" {
+		scheme := "http"
+		if cfg.TLS.Enabled() {
+			scheme = "https"
+		}
+		host := cfg.APIAddr
+		if strings.HasPrefix(host, ":") {
+			host = "localhost" + host
+		}
+		cfg.Bots.ServerURL = scheme + "://" + host
+	}
+	if cfg.TLS.Enabled() && cfg.sources["cors-origins"] == "default" {
+		cfg.CORSOrigins = []string{"https://localhost:8081"}
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Returns the effective settings with the source of each value as log attributes.
+func (cfg *Config) LogAttrs() []any {
+	var attrs []any
+	cfg.flags.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if value != "" && slices.Contains(secrets, f.Name) {
+			value = "[redacted]"
+		}
+		attrs = append(attrs, slog.Group(f.Name, "value", value, "source", cfg.sources[f.Name]))
+	})
+	return attrs
+}
+
+func (cfg *Config) newFlagSet(output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		fmt.Fprintf(output, "Usage of server:\n\n")
+		fmt.Fprintf(output, "Settings are read from defaults, the config file, %s* environment\n", EnvPrefix)
+		fmt.Fprintf(output, "variables and flags, in increasing order of precedence.\n\n")
+		fs.PrintDefaults()
+	}
+
+	defaultPolicy := reaper.DefaultPolicy()
+	cfg.CORSOrigins = []string{"http://localhost:8081"}
+
+	fs.StringVar(&cfg.ConfigFile, "config", "", "Path to a YAML or JSON config file")
+	fs.StringVar(&cfg.APIAddr, "api-addr", ":8080", "Listen address of the API server")
+	fs.StringVar(&cfg.WebAddr, "web-addr", ":8081", "Listen address of the web UI server")
+	fs.BoolVar(&cfg.SinglePort, "single-port", false, "Serve API, documentation and web UI together on api-addr")
+	fs.Var((*listValue)(&cfg.CORSOrigins), "cors-origins", "Comma separated origins allowed to call the API")
+	fs.DurationVar(&cfg.TurnTimeout, "turn-timeout", time.Hour, "Maximum time a client waits for its turn")
+	fs.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", time.Second, "Time each server gets to close its connections")
+	fs.DurationVar(&cfg.DrainTimeout, "drain-timeout", 10*time.Second, "Time waiting clients get to disconnect before state is persisted")
+	fs.DurationVar(&cfg.RetryAfter, "restart-retry-after", 10*time.Second, "Retry hint sent to clients while the server restarts")
+	fs.StringVar(&cfg.PersistencePath, "persistence-path", "", "Directory for server state, empty to keep everything in memory")
+	fs.StringVar(&cfg.LogLevel, "log-level", "info", "Logging level: "+strings.Join(LogLevels, ", "))
+	fs.StringVar(&cfg.LogFormat, "log-format", "text", "Log output format: "+strings.Join(LogFormats, ", "))
+
+	cfg.TLS.Hosts = []string{"localhost", "127.0.0.1", "::1"}
+	fs.StringVar(&cfg.TLS.CertFile, "tls-cert", "", "PEM certificate file, enables HTTPS")
+	fs.StringVar(&cfg.TLS.KeyFile, "tls-key", "", "PEM private key file belonging to tls-cert")
+	fs.BoolVar(&cfg.TLS.SelfSigned, "tls-self-signed", false, "Use a self-signed certificate, generated on first start and cached in tls-cert/tls-key")
+	fs.Var((*listValue)(&cfg.TLS.Hosts), "tls-hosts", "Comma separated host names and IPs of the self-signed certificate")
+	fs.StringVar(&cfg.TLS.RedirectAddr, "tls-redirect-addr", "", "If set, listen on this address with plain HTTP and redirect to HTTPS")
+
+	fs.BoolVar(&cfg.Features.WebUI, "feature-webui", true, "Serve the embedded web UI")
+	fs.BoolVar(&cfg.Features.Swagger, "feature-swagger", true, "Serve the API documentation")
+	fs.BoolVar(&cfg.Features.Metrics, "feature-metrics", true, "Serve Prometheus metrics under /metrics")
+	fs.BoolVar(&cfg.Features.Reaper, "feature-reaper", true, "Clean up finished and abandoned sessions")
+
+	fs.Var((*pathList)(&cfg.UCIEngines), "uci-engines", "Comma separated name=path pairs of UCI engine executables that can be seated like built-in engines")
+	fs.Var((*pathList)(&cfg.OpeningSuites), "opening-suites", "Comma separated name=path pairs of EPD, FEN or PGN files with openings for tournaments")
+	fs.Var((*pathList)(&cfg.Books), "books", "Comma separated name=path pairs of Polyglot .bin opening books")
+	fs.StringVar(&cfg.EngineBook, "engine-book", "", "Name of a book from books the built-in engines play from while the position is in it")
+
+	fs.StringVar(&cfg.AdminToken, "admin-token", "", "Bearer token for creating and deleting accounts, empty disables account administration")
+	fs.DurationVar(&cfg.TokenTTL, "token-ttl", 0, "Lifetime of player tokens, regenerated with POST .../players/{color}/token; 0 for no expiry")
+
+	fs.StringVar(&cfg.Bots.File, "bots-file", "", "YAML or JSON file with bot programs that can be launched as players")
+	fs.StringVar(&cfg.Bots.LogDir, "bot-log-dir", "", "Directory for bot output, defaults to <persistence-path>/botlogs")
+	fs.DurationVar(&cfg.Bots.Timeout, "bot-timeout", time.Hour, "Time after which a bot process is killed and its game forfeited")
+	fs.StringVar(&cfg.Bots.ServerURL, "bot-server-url", "", "API URL passed to bots, derived from api-addr by default")
+
+	fs.DurationVar(&cfg.Reaper.Interval, "reaper-interval", defaultPolicy.Interval, "Time between two cleanup runs")
+	fs.DurationVar(&cfg.Reaper.FinishedRetention, "reaper-finished-retention", defaultPolicy.FinishedRetention, "How long finished games are kept, 0 keeps them forever")
+	fs.StringVar(&cfg.Reaper.FinishedAction, "reaper-finished-action", defaultPolicy.FinishedAction, "What happens to finished games: delete or archive")
+	fs.StringVar(&cfg.Reaper.ArchiveDir, "reaper-archive-dir", "", "Archive directory, defaults to <persistence-path>/archive")
+	fs.DurationVar(&cfg.Reaper.UnstartedTTL, "reaper-unstarted-ttl", defaultPolicy.UnstartedTTL, "How long sessions nobody joined are kept, 0 disables")
+	fs.DurationVar(&cfg.Reaper.HalfJoinedTTL, "reaper-half-joined-ttl", defaultPolicy.HalfJoinedTTL, "How long sessions with one player may wait for the opponent, 0 disables")
+	fs.DurationVar(&cfg.Reaper.AbandonAfter, "reaper-abandon-after", defaultPolicy.AbandonAfter, "Idle time after which a started game is abandoned, 0 disables")
+
+	return fs
+}
+
+func (cfg *Config) validate() error {
+	if !slices.Contains(LogLevels, cfg.LogLevel) {
+		return fmt.Errorf("log-level has to be one of %s", strings.Join(LogLevels, ", "))
+	}
+	if !slices.Contains(LogFormats, cfg.LogFormat) {
+		return fmt.Errorf("log-format has to be one of %s", strings.Join(LogFormats, ", "))
+	}
+	if cfg.Reaper.FinishedAction != reaper.ActionDelete && cfg.Reaper.FinishedAction != reaper.ActionArchive {
+		return fmt.Errorf("reaper-finished-action has to be %q or %q", reaper.ActionDelete, reaper.ActionArchive)
+	}
+	if cfg.Features.Reaper && cfg.Reaper.FinishedAction == reaper.ActionArchive && cfg.Reaper.ArchiveDir == "" {
+		return errors.New("archiving finished games requires persistence-path or reaper-archive-dir")
+	}
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		return errors.New("tls-cert and tls-key have to be set together")
+	}
+	if cfg.Bots.Timeout <= 0 {
+		return errors.New("bot-timeout has to be positive")
+	}
+	if _, exists := cfg.Books[cfg.EngineBook]; cfg.EngineBook != "" && !exists {
+		return fmt.Errorf("engine-book %q is not one of books", cfg.EngineBook)
+	}
+	if cfg.TLS.RedirectAddr != "" && !cfg.TLS.Enabled() {
+		return errors.New("tls-redirect-addr requires tls-cert/tls-key or tls-self-signed")
+	}
+	return nil
+}
+
+// Applies a value unless a flag with higher precedence already set it.
+func (cfg *Config) set(name, value, source string) error {
+	if cfg.sources[name] == "flag" {
+		return nil
+	}
+	if err := cfg.flags.Set(name, value); err != nil {
+		return fmt.Errorf("invalid value %q for %s from %s: %w", value, name, source, err)
+	}
+	cfg.sources[name] = source
+	return nil
+}
+
+func envName(flagName string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// Reads a flat YAML or JSON object and converts its values to flag strings.
+func readFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]any)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(content, &raw)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &raw)
+	default:
+		return nil, errors.New("unsupported file type, use .yaml, .yml or .json")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(raw))
+	for name, value := range raw {
+		switch v := value.(type) {
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[name] = strings.Join(items, ",")
+		case map[string]any:
+			return nil, fmt.Errorf("setting %q must not be a nested object", name)
+		default:
+			values[name] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
+
+// flag.Value for comma separated lists.
+type listValue []string
+
+func (l *listValue) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listValue) Set(value string) error {
+	*l = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// flag.Value for comma separated name=path pairs.
+type pathList map[string]string
+
+func (l *pathList) String() string {
+	pairs := make([]string, 0, len(*l))
+	for name, path := range *l {
+		pairs = append(pairs, name+"="+path)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (l *pathList) Set(value string) error {
+	*l = make(map[string]string)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		name, path, found := strings.Cut(item, "=")
+		name, path = strings.TrimSpace(name), strings.TrimSpace(path)
+		if !found || name == "" || path == "" {
+			return fmt.Errorf("%q is not of the form name=path", item)
+		}
+		(*l)[name] = path
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+/*
+Unittest for the config package.
+*/
+package config
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(nil, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIAddr != ":8080" || cfg.WebAddr != ":8081" {
+		t.Errorf("unexpected default addresses %q %q", cfg.APIAddr, cfg.WebAddr)
+	}
+	if !slices.Equal(cfg.CORSOrigins, []string{"http://localhost:8081"}) {
+		t.Errorf("unexpected default CORS origins %v", cfg.CORSOrigins)
+	}
+	if cfg.TurnTimeout != time.Hour {
+		t.Errorf("unexpected default turn timeout %v", cfg.TurnTimeout)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, "server.yaml", `
+api-addr: ":9000"
+web-addr: ":9001"
+turn-timeout: 10m
+cors-origins:
+  - https://a.example
+  - https://b.example
+`)
+	t.Setenv("CHESSBOT_CONFIG", path)
+	t.Setenv("CHESSBOT_WEB_ADDR", ":9101")
+	t.Setenv("CHESSBOT_TURN_TIMEOUT", "20m")
+
+	cfg, err := Load([]string{"-turn-timeout", "30m"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIAddr != ":9000" {
+		t.Errorf("expected api-addr from file, got %q", cfg.APIAddr)
+	}
+	if cfg.WebAddr != ":9101" {
+		t.Errorf("expected web-addr from environment, got %q", cfg.WebAddr)
+	}
+	if cfg.TurnTimeout != 30*time.Minute {
+		t.Errorf("expected turn-timeout from flag, got %v", cfg.TurnTimeout)
+	}
+	if !slices.Equal(cfg.CORSOrigins, []string{"https://a.example", "https://b.example"}) {
+		t.Errorf("expected CORS origins from file, got %v", cfg.CORSOrigins)
+	}
+	sources := map[string]string{"api-addr": "file", "web-addr": "env", "turn-timeout": "flag", "log-level": "default"}
+	for name, source := range sources {
+		if cfg.sources[name] != source {
+			t.Errorf("expected %s from %s, got %s", name, source, cfg.sources[name])
+		}
+	}
+}
+
+func TestLoadJSONAndArchiveDir(t *testing.T) {
+	path := writeFile(t, "server.json", `{"persistence-path": "/var/lib/chessbot", "feature-webui": false}`)
+
+	cfg, err := Load([]string{"-config", path, "-reaper-finished-action", "archive"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Features.WebUI {
+		t.Errorf("expected web UI to be disabled by the config file")
+	}
+	if cfg.Reaper.ArchiveDir != filepath.Join("/var/lib/chessbot", "archive") {
+		t.Errorf("unexpected archive dir %q", cfg.Reaper.ArchiveDir)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	unknown := writeFile(t, "server.yaml", "api-port: 8080\n")
+
+	cases := [][]string{
+		{"-log-level", "verbose"},
+		{"-reaper-finished-action", "archive"},
+		{"-config", unknown},
+		{"-turn-timeout", "soon"},
+		{"-uci-engines", "stockfish"},
+		{"-opening-suites", "=openings.epd"},
+		{"-engine-book", "missing"},
+		{"-bot-timeout", "0s"},
+	}
+	for _, args := range cases {
+		if _, err := Load(args, io.Discard); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+
+	if _, err := Load([]string{"-h"}, io.Discard); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("expected flag.ErrHelp, got %v", err)
+	}
+}
+
+func TestLoadUCIEngines(t *testing.T) {
+	cfg, err := Load([]string{"-uci-engines", "stockfish=/usr/games/stockfish, lc0 = /opt/lc0"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.UCIEngines) != 2 || cfg.UCIEngines["stockfish"] != "/usr/games/stockfish" || cfg.UCIEngines["lc0"] != "/opt/lc0" {
+		t.Errorf("unexpected engines %v", cfg.UCIEngines)
+	}
+}
+
+func TestLoadOpeningSuites(t *testing.T) {
+	cfg, err := Load([]string{"-opening-suites", "eco=/srv/eco.pgn,balanced=/srv/balanced.epd"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.OpeningSuites) != 2 || cfg.OpeningSuites["eco"] != "/srv/eco.pgn" || cfg.OpeningSuites["balanced"] != "/srv/balanced.epd" {
+		t.Errorf("unexpected opening suites %v", cfg.OpeningSuites)
+	}
+}
+
+func TestLoadBooks(t *testing.T) {
+	cfg, err := Load([]string{"-books", "main=/srv/book.bin", "-engine-book", "main"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Books["main"] != "/srv/book.bin" || cfg.EngineBook != "main" {
+		t.Errorf("unexpected books %v and engine book %q", cfg.Books, cfg.EngineBook)
+	}
+}
+
+func TestLoadBotDefaults(t *testing.T) {
+	cfg, err := Load([]string{"-api-addr", ":9000", "-persistence-path", "/var/lib/chessbot"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Bots.ServerURL != "http://localhost:9000" {
+		t.Errorf("unexpected bot server URL %q", cfg.Bots.ServerURL)
+	}
+	if cfg.Bots.LogDir != filepath.Join("/var/lib/chessbot", "botlogs") {
+		t.Errorf("unexpected bot log dir %q", cfg.Bots.LogDir)
+	}
+}
+
+func TestLogAttrsRedactsSecrets(t *testing.T) {
+	cfg, err := Load([]string{"-admin-token", "s3cret"}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.AdminToken != "s3cret" {
+		t.Errorf("unexpected admin token %q", cfg.AdminToken)
+	}
+	for _, attr := range cfg.LogAttrs() {
+		if strings.Contains(attr.(slog.Attr).Value.String(), "s3cret") {
+			t.Errorf("admin token logged in %v", attr)
+		}
+	}
+}
@@ -4,23 +4,59 @@ Manages the data storage of the server.
 package data
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"sync"
+	"time"
 
 	"github.com/matetirpak/chessbot-playground-server/internal/game_logic"
 )
 
+// Game holds a session and its board history.
+//
+// Termination:
+//   - "": game is ongoing or was decided on the board
+//   - "forfeit": a player gave up
+//   - "abandoned": no move was made for too long
+//   - "time": the side to move exceeded the move time of a tournament
+//   - "adjudicated": a tournament game reached its ply limit
+//
+// The session password and the player tokens are only stored as hashes,
+// see HashSecret.
 type Game struct {
-	Name         string
-	ID           int32
-	Password     string
-	Started      bool
-	HasWPlayer   bool
-	WPlayerToken string
-	HasBPlayer   bool
-	BPlayerToken string
-	Winner       string
-	BoardData    []game_logic.BoardState
-	Mu           sync.RWMutex
+	Name           string
+	ID             int32
+	PasswordHash   string
+	Started        bool
+	HasWPlayer     bool
+	WTokenHash     string
+	WTokenExpires  time.Time // Zero if the token doesn't expire
+	HasBPlayer     bool
+	BTokenHash     string
+	BTokenExpires  time.Time
+	WEngine        string        // Name of the built-in engine playing white, if any
+	BEngine        string        // Name of the built-in engine playing black, if any
+	WBot           string        // Name of the bot process playing white, if any
+	BBot           string        // Name of the bot process playing black, if any
+	MoveTime       time.Duration // Time per move of seated engines and bots, 0 for their defaults
+	Rated          bool          // The result updates the ratings of the players
+	WPlayer        string        // Rating identity of the white player, "" if anonymous
+	BPlayer        string        // Rating identity of the black player, "" if anonymous
+	WAccount       string        // Account playing white, if any
+	BAccount       string        // Account playing black, if any
+	CreatedBy      string        // Account that created the game, if any
+	RatingRecorded bool          // The result was counted in the ratings
+	Opening        string        // Name of the opening the game started from, if any
+	OpeningPlies   int           // Moves of the opening at the start of BoardData
+	StartTurn      string        // Side to move once the game starts, "" for white
+	Winner         string
+	Termination    string
+	BoardData      []game_logic.BoardState
+	CreatedAt      time.Time
+	LastActivity   time.Time
+	Done           chan struct{} `json:"-"` // Closed once the game is removed from GamesMap
+	Mu             sync.RWMutex  `json:"-"`
 }
 
 var GamesMap = make(map[int32]*Game)
@@ -28,3 +64,60 @@ var GamesMapMu sync.RWMutex
 
 var NextFreeBoardID int32 = 1
 var NextFreeBoardIDMu sync.RWMutex
+
+// Removes a game from GamesMap and wakes everyone waiting on it.
+// Returns nil if the game did not exist.
+func RemoveGame(id int32) *Game {
+	GamesMapMu.Lock()
+	game, exists := GamesMap[id]
+	delete(GamesMap, id)
+	GamesMapMu.Unlock()
+
+	if !exists {
+		return nil
+	}
+	if game.Done != nil {
+		close(game.Done)
+	}
+	return game
+}
+
+// Called by EndGame with game.Mu held for writing, e.g. to count the
+// result in the ratings. Set by the server at startup.
+var OnGameEnd func(game *Game)
+
+// Ends a running game with the given winner ("w", "b" or "r") and
+// termination, then calls OnGameEnd. Every way a game ends goes through
+// here, so OnGameEnd runs once per game. Returns false if the game had
+// already ended. Expects game.Mu to be held for writing.
+func EndGame(game *Game, winner string, termination string, now time.Time) bool {
+	if game.Winner != "n" {
+		return false
+	}
+	latest := &game.BoardData[len(game.BoardData)-1]
+	latest.TurnColor = "n"
+	latest.Winner = winner
+	game.Winner = winner
+	game.Termination = termination
+	game.LastActivity = now
+	if OnGameEnd != nil {
+		OnGameEnd(game)
+	}
+	return true
+}
+
+// Returns the hex SHA-256 hash of a password or token. Both are random
+// UUIDs, so a plain hash keeps them safe in snapshots and archives.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Reports in constant time whether secret belongs to hash. An empty hash
+// matches nothing.
+func MatchesHash(hash, secret string) bool {
+	if hash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(HashSecret(secret)), []byte(hash)) == 1
+}
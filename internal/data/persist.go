@@ -0,0 +1,118 @@
+/*
+Persistence of the game storage to a JSON snapshot file.
+*/
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+const SnapshotFile = "state.json"
+
+type snapshot struct {
+	NextFreeBoardID int32   `json:"nextfreeboardid"`
+	Games           []*Game `json:"games"`
+}
+
+// Secrets of snapshots written before only their hashes were stored.
+type legacySnapshot struct {
+	Games []struct {
+		Password     string
+		WPlayerToken string
+		BPlayerToken string
+	} `json:"games"`
+}
+
+// Writes all games to dir/SnapshotFile. The file is replaced atomically.
+func SaveSnapshot(dir string) error {
+	var snap snapshot
+
+	NextFreeBoardIDMu.RLock()
+	snap.NextFreeBoardID = NextFreeBoardID
+	NextFreeBoardIDMu.RUnlock()
+
+	GamesMapMu.RLock()
+	for _, game := range GamesMap {
+		snap.Games = append(snap.Games, game)
+	}
+	for _, game := range snap.Games {
+		game.Mu.RLock()
+	}
+	encoded, err := json.Marshal(snap)
+	for _, game := range snap.Games {
+		game.Mu.RUnlock()
+	}
+	GamesMapMu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, SnapshotFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(encoded); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, SnapshotFile))
+}
+
+// Restores the games from dir/SnapshotFile. A missing file is not an error.
+// Returns the number of restored games.
+func LoadSnapshot(dir string) (int, error) {
+	encoded, err := os.ReadFile(filepath.Join(dir, SnapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(encoded, &snap); err != nil {
+		return 0, err
+	}
+	var legacy legacySnapshot
+	if err := json.Unmarshal(encoded, &legacy); err != nil {
+		return 0, err
+	}
+	for i, secrets := range legacy.Games {
+		game := snap.Games[i]
+		migrateSecret(&game.PasswordHash, secrets.Password)
+		migrateSecret(&game.WTokenHash, secrets.WPlayerToken)
+		migrateSecret(&game.BTokenHash, secrets.BPlayerToken)
+	}
+
+	GamesMapMu.Lock()
+	for _, game := range snap.Games {
+		game.Done = make(chan struct{})
+		GamesMap[game.ID] = game
+	}
+	GamesMapMu.Unlock()
+
+	NextFreeBoardIDMu.Lock()
+	if snap.NextFreeBoardID > NextFreeBoardID {
+		NextFreeBoardID = snap.NextFreeBoardID
+	}
+	NextFreeBoardIDMu.Unlock()
+
+	return len(snap.Games), nil
+}
+
+// Replaces a plain text secret of an old snapshot by its hash.
+func migrateSecret(hash *string, secret string) {
+	if *hash == "" && secret != "" {
+		*hash = HashSecret(secret)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Unittest for the persistence of the data package.
+*/
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	game := &Game{
+		Name:         "persisted",
+		ID:           42,
+		PasswordHash: HashSecret("secret"),
+		Started:      true,
+		HasWPlayer:   true,
+		WTokenHash:   HashSecret("white"),
+		HasBPlayer:   true,
+		BTokenHash:   HashSecret("black"),
+		Winner:       "n",
+		CreatedAt:    time.Now().Truncate(time.Second),
+		Done:         make(chan struct{}),
+	}
+	game_logic.InitializeBoard(&game.BoardData)
+	GamesMapMu.Lock()
+	GamesMap[game.ID] = game
+	GamesMapMu.Unlock()
+	NextFreeBoardIDMu.Lock()
+	NextFreeBoardID = 43
+	NextFreeBoardIDMu.Unlock()
+
+	if err := SaveSnapshot(dir); err != nil {
+		t.Fatalf("failed to save snapshot: %v", err)
+	}
+	RemoveGame(game.ID)
+	NextFreeBoardIDMu.Lock()
+	NextFreeBoardID = 1
+	NextFreeBoardIDMu.Unlock()
+
+	n, err := LoadSnapshot(dir)
+	if err != nil {
+		t.Fatalf("failed to load snapshot: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 restored game, got %d", n)
+	}
+	t.Cleanup(func() { RemoveGame(42) })
+
+	GamesMapMu.RLock()
+	restored := GamesMap[42]
+	GamesMapMu.RUnlock()
+	if restored == nil {
+		t.Fatalf("game was not restored")
+	}
+	if restored.Name != "persisted" || !MatchesHash(restored.BTokenHash, "black") || !restored.CreatedAt.Equal(game.CreatedAt) {
+		t.Errorf("restored game differs: %+v", restored)
+	}
+	if restored.BoardData[0].Board != game.BoardData[0].Board {
+		t.Errorf("restored board differs")
+	}
+	if restored.Done == nil {
+		t.Errorf("restored game has no Done channel")
+	}
+	if NextFreeBoardID != 43 {
+		t.Errorf("expected next free board ID 43, got %d", NextFreeBoardID)
+	}
+}
+
+func TestLoadLegacySnapshot(t *testing.T) {
+	dir := t.TempDir()
+	legacy := `{"nextfreeboardid": 8, "games": [{"Name": "old", "ID": 7, "Password": "secret", "HasWPlayer": true, "WPlayerToken": "white", "Winner": "n"}]}`
+	if err := os.WriteFile(filepath.Join(dir, SnapshotFile), []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := LoadSnapshot(dir); err != nil || n != 1 {
+		t.Fatalf("expected 1 restored game, got %d %v", n, err)
+	}
+	t.Cleanup(func() { RemoveGame(7) })
+
+	GamesMapMu.RLock()
+	restored := GamesMap[7]
+	GamesMapMu.RUnlock()
+	if !MatchesHash(restored.PasswordHash, "secret") || !MatchesHash(restored.WTokenHash, "white") || restored.BTokenHash != "" {
+		t.Errorf("secrets were not migrated: %+v", restored)
+	}
+	if MatchesHash(restored.BTokenHash, "") {
+		t.Error("empty hash matched")
+	}
+}
+
+func TestLoadSnapshotMissingFile(t *testing.T) {
+	n, err := LoadSnapshot(t.TempDir())
+	if err != nil || n != 0 {
+		t.Errorf("expected no games and no error, got %d, %v", n, err)
+	}
+}
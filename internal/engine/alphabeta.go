@@ -0,0 +1,135 @@
+/*
+Depth-limited alpha-beta search.
+*/
+package engine
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+)
+
+const mateScore = 1000000
+
+var errSearchStopped = errors.New("search stopped")
+
+// AlphaBeta searches the game tree with iterative deepening up to
+// Limits.Depth (default 3) and evaluates leaves by material and
+// piece-square tables. When Limits.MoveTime runs out, the best move of
+// the last completed depth is played.
+type AlphaBeta struct{}
+
+func (AlphaBeta) BestMove(ctx context.Context, pos gl.BoardState, limits Limits) (gl.Move, error) {
+	moves, err := LegalMoves(&pos)
+	if err != nil {
+		return gl.Move{}, err
+	}
+	if len(moves) == 0 {
+		return gl.Move{}, ErrNoLegalMoves
+	}
+
+	maxDepth := limits.Depth
+	if maxDepth <= 0 {
+		maxDepth = DefaultLimits.Depth
+	}
+	if limits.MoveTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.MoveTime)
+		defer cancel()
+	}
+
+	orderMoves(&pos, moves)
+	best := moves[0]
+	for depth := 1; depth <= maxDepth; depth++ {
+		move, err := searchRoot(ctx, &pos, moves, depth)
+		if errors.Is(err, errSearchStopped) {
+			break
+		}
+		if err != nil {
+			return gl.Move{}, err
+		}
+		best = move
+
+		// Search the best move first in the next iteration
+		for i := range moves {
+			if gl.EqMove(&moves[i], &best) {
+				moves[0], moves[i] = moves[i], moves[0]
+				break
+			}
+		}
+	}
+	return best, nil
+}
+
+func searchRoot(ctx context.Context, pos *gl.BoardState, moves []gl.Move, depth int) (gl.Move, error) {
+	alpha, beta := -mateScore-1, mateScore+1
+	var best gl.Move
+	for i := range moves {
+		child, _ := gl.MakeMove(&moves[i], *pos, false)
+		score, err := search(ctx, &child, depth-1, 1, -beta, -alpha)
+		if err != nil {
+			return gl.Move{}, err
+		}
+		score = -score
+		if i == 0 || score > alpha {
+			alpha = score
+			best = moves[i]
+		}
+	}
+	return best, nil
+}
+
+// Negamax search returning the score from the side to move's point of view.
+func search(ctx context.Context, pos *gl.BoardState, depth, ply, alpha, beta int) (int, error) {
+	select {
+	case <-ctx.Done():
+		return 0, errSearchStopped
+	default:
+	}
+
+	moves, err := LegalMoves(pos)
+	if err != nil {
+		return 0, err
+	}
+	if len(moves) == 0 {
+		inCheck, err := gl.InCheck(rune(pos.TurnColor[0]), pos)
+		if err != nil {
+			return 0, err
+		}
+		if inCheck {
+			// Prefer the fastest mate
+			return -mateScore + ply, nil
+		}
+		return 0, nil
+	}
+	if depth == 0 {
+		return evaluate(pos), nil
+	}
+
+	orderMoves(pos, moves)
+	for i := range moves {
+		child, _ := gl.MakeMove(&moves[i], *pos, false)
+		score, err := search(ctx, &child, depth-1, ply+1, -beta, -alpha)
+		if err != nil {
+			return 0, err
+		}
+		score = -score
+		if score >= beta {
+			return beta, nil
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+	return alpha, nil
+}
+
+// Sorts captures and promotions of valuable pieces first, which lets
+// alpha-beta cut off more of the tree.
+func orderMoves(pos *gl.BoardState, moves []gl.Move) {
+	sort.SliceStable(moves, func(i, j int) bool {
+		return materialGain(pos, &moves[i]) > materialGain(pos, &moves[j])
+	})
+}
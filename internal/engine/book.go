@@ -0,0 +1,37 @@
+/*
+Engines playing from an opening book.
+*/
+package engine
+
+import (
+	"context"
+
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+	"github.com/matetirpak/chessbot-playground-server/internal/polyglot"
+)
+
+// Booked plays a move of the book while the position is in it and lets
+// the wrapped engine search otherwise.
+type Booked struct {
+	Engine Engine
+	Book   *polyglot.Book
+}
+
+func (b Booked) BestMove(ctx context.Context, pos gl.BoardState, limits Limits) (gl.Move, error) {
+	move, found, err := b.Book.Pick(&pos)
+	if err != nil {
+		return gl.Move{}, err
+	}
+	if found {
+		return move, nil
+	}
+	return b.Engine.BestMove(ctx, pos, limits)
+}
+
+// Makes the built-in engines play from the book. Engines registered under
+// other names, e.g. UCI engines with books of their own, are unaffected.
+func UseBook(book *polyglot.Book) {
+	for name, factory := range builtIn {
+		Register(name, func() Engine { return Booked{Engine: factory(), Book: book} })
+	}
+}
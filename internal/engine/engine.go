@@ -0,0 +1,110 @@
+/*
+Chess engines the server can seat as players.
+*/
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+)
+
+var ErrNoLegalMoves = errors.New("no legal moves")
+
+// Limits bound the search of an engine. A zero value means no limit of
+// that kind, engines fall back to their own defaults.
+type Limits struct {
+	Depth    int           // Maximum search depth in plies
+	MoveTime time.Duration // Maximum time to choose a move
+}
+
+// Limits used for engines seated in a game.
+var DefaultLimits = Limits{Depth: 3, MoveTime: 2 * time.Second}
+
+// An Engine chooses a move for the side to move of a position.
+type Engine interface {
+	BestMove(ctx context.Context, pos gl.BoardState, limits Limits) (gl.Move, error)
+}
+
+// Implemented by engines that need the moves leading to the position, e.g.
+// UCI engines for repetitions and the fifty-move rule. positions holds the
+// positions of the game from its start, each following the LastMove of
+// the next; the last one is searched.
+type HistoryEngine interface {
+	Engine
+	BestMoveInGame(ctx context.Context, positions []gl.BoardState, limits Limits) (gl.Move, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]func() Engine{}
+)
+
+// Engines implemented by the server itself.
+var builtIn = map[string]func() Engine{
+	"random":    func() Engine { return Random{} },
+	"greedy":    func() Engine { return Greedy{} },
+	"alphabeta": func() Engine { return AlphaBeta{} },
+}
+
+func init() {
+	for name, factory := range builtIn {
+		Register(name, factory)
+	}
+}
+
+// Makes an engine available under the given name. Registering a name
+// again replaces the previous engine.
+func Register(name string, factory func() Engine) {
+	registryMu.Lock()
+	registry[name] = factory
+	registryMu.Unlock()
+}
+
+// Returns a new instance of the engine registered under name.
+func New(name string) (Engine, error) {
+	registryMu.RLock()
+	factory, exists := registry[name]
+	registryMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown engine %q", name)
+	}
+	return factory(), nil
+}
+
+// Returns the sorted names of all registered engines.
+func Names() []string {
+	registryMu.RLock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	registryMu.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// Returns the legal moves of the side to move.
+func LegalMoves(pos *gl.BoardState) ([]gl.Move, error) {
+	if pos.TurnColor != "w" && pos.TurnColor != "b" {
+		return nil, nil
+	}
+	moves := gl.AllPossibleMoves(rune(pos.TurnColor[0]), pos, nil)
+	validMoves, err := gl.FilterInvalidMoves(moves, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	var legal []gl.Move
+	for current := validMoves; current != nil; current = current.Next {
+		move := *current
+		move.Next = nil
+		legal = append(legal, move)
+	}
+	return legal, nil
+}
@@ -0,0 +1,152 @@
+/*
+Unittest for the engine package.
+*/
+package engine
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+	"github.com/matetirpak/chessbot-playground-server/internal/polyglot"
+)
+
+func startPosition() gl.BoardState {
+	var boards []gl.BoardState
+	gl.InitializeBoard(&boards)
+	boards[0].TurnColor = "w"
+	return boards[0]
+}
+
+func TestRegistry(t *testing.T) {
+	for _, name := range []string{"alphabeta", "greedy", "random"} {
+		if _, err := New(name); err != nil {
+			t.Errorf("built-in engine %q missing: %v", name, err)
+		}
+	}
+	if _, err := New("unknown"); err == nil {
+		t.Error("expected error for unknown engine")
+	}
+}
+
+func TestEnginesPlayLegalMoves(t *testing.T) {
+	pos := startPosition()
+	for _, name := range Names() {
+		e, _ := New(name)
+		move, err := e.BestMove(context.Background(), pos, Limits{Depth: 2})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := gl.ValidateMove(&move, &pos); err != nil {
+			t.Errorf("%s played illegal move %q: %v", name, gl.MoveToString(&move), err)
+		}
+	}
+}
+
+func TestNoLegalMoves(t *testing.T) {
+	pos := startPosition()
+	pos.TurnColor = "n"
+	if _, err := (AlphaBeta{}).BestMove(context.Background(), pos, Limits{}); err != ErrNoLegalMoves {
+		t.Errorf("expected ErrNoLegalMoves, got %v", err)
+	}
+}
+
+func TestGreedyCapturesMostValuable(t *testing.T) {
+	pos := gl.BoardState{
+		Board: [8][8]rune{
+			{' ', ' ', ' ', 'R', ' ', ' ', ' ', 'X'},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{'P', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', 'q', ' ', ' ', ' ', 'x'},
+		},
+		WhiteKingPos: [2]int{7, 7},
+		BlackKingPos: [2]int{0, 7},
+		TurnColor:    "w",
+		Winner:       "n",
+	}
+	move, err := (Greedy{}).BestMove(context.Background(), pos, Limits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := gl.MoveToString(&move); got != "d1 d8" {
+		t.Errorf("expected \"d1 d8\", got %q", got)
+	}
+}
+
+func TestAlphaBetaFindsMate(t *testing.T) {
+	pos := gl.BoardState{
+		Board: [8][8]rune{
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', 'X'},
+			{' ', ' ', ' ', ' ', ' ', ' ', 'P', 'P'},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{'r', ' ', ' ', ' ', ' ', ' ', 'x', ' '},
+		},
+		WhiteKingPos: [2]int{7, 6},
+		BlackKingPos: [2]int{0, 7},
+		TurnColor:    "w",
+		Winner:       "n",
+	}
+	move, err := (AlphaBeta{}).BestMove(context.Background(), pos, Limits{Depth: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := gl.MoveToString(&move); got != "a1 a8" {
+		t.Errorf("expected mate with \"a1 a8\", got %q", got)
+	}
+}
+
+func TestAlphaBetaRespectsMoveTime(t *testing.T) {
+	pos := startPosition()
+	start := time.Now()
+	_, err := (AlphaBeta{}).BestMove(context.Background(), pos, Limits{Depth: 20, MoveTime: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("search took %v despite 200ms move time", elapsed)
+	}
+}
+
+func TestBookedPlaysBookMoves(t *testing.T) {
+	start := startPosition()
+	// A book with the single move d2 d4 (square 11 to 27) in the start position
+	entry := make([]byte, 16)
+	binary.BigEndian.PutUint64(entry, polyglot.Key(&start))
+	binary.BigEndian.PutUint16(entry[8:], 11<<6|27)
+	binary.BigEndian.PutUint16(entry[10:], 1)
+	path := filepath.Join(t.TempDir(), "book.bin")
+	if err := os.WriteFile(path, entry, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	book, err := polyglot.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := Booked{Engine: Random{}, Book: book}
+	for range 10 {
+		move, err := e.BestMove(context.Background(), start, Limits{})
+		if err != nil || gl.MoveToString(&move) != "d2 d4" {
+			t.Fatalf("expected the book move d2 d4, got %v %v", gl.MoveToString(&move), err)
+		}
+	}
+
+	// Out of book the wrapped engine takes over
+	move, _ := gl.StringToMoveStruct("e2 e4", 'w')
+	pos, _ := gl.MakeMove(&move, start, true)
+	if move, err = e.BestMove(context.Background(), pos, Limits{}); err != nil || gl.ValidateMove(&move, &pos) != nil {
+		t.Errorf("expected a legal move out of book, got %v %v", gl.MoveToString(&move), err)
+	}
+}
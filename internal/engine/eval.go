@@ -0,0 +1,122 @@
+/*
+Static evaluation of positions: material plus piece-square tables.
+*/
+package engine
+
+import (
+	"unicode"
+
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+)
+
+// Values of the lowercase piece letters in centipawns.
+var pieceValues = map[rune]int{
+	'p': 100,
+	'k': 320, // knight
+	'b': 330,
+	'r': 500,
+	'q': 900,
+	'x': 0, // king, never captured
+}
+
+// Piece-square tables from white's point of view. Row 0 is rank 8 like
+// on gl.BoardState.Board, black uses the rows mirrored.
+var pieceSquareTables = map[rune][8][8]int{
+	'p': {
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{50, 50, 50, 50, 50, 50, 50, 50},
+		{10, 10, 20, 30, 30, 20, 10, 10},
+		{5, 5, 10, 25, 25, 10, 5, 5},
+		{0, 0, 0, 20, 20, 0, 0, 0},
+		{5, -5, -10, 0, 0, -10, -5, 5},
+		{5, 10, 10, -20, -20, 10, 10, 5},
+		{0, 0, 0, 0, 0, 0, 0, 0},
+	},
+	'k': {
+		{-50, -40, -30, -30, -30, -30, -40, -50},
+		{-40, -20, 0, 0, 0, 0, -20, -40},
+		{-30, 0, 10, 15, 15, 10, 0, -30},
+		{-30, 5, 15, 20, 20, 15, 5, -30},
+		{-30, 0, 15, 20, 20, 15, 0, -30},
+		{-30, 5, 10, 15, 15, 10, 5, -30},
+		{-40, -20, 0, 5, 5, 0, -20, -40},
+		{-50, -40, -30, -30, -30, -30, -40, -50},
+	},
+	'b': {
+		{-20, -10, -10, -10, -10, -10, -10, -20},
+		{-10, 0, 0, 0, 0, 0, 0, -10},
+		{-10, 0, 5, 10, 10, 5, 0, -10},
+		{-10, 5, 5, 10, 10, 5, 5, -10},
+		{-10, 0, 10, 10, 10, 10, 0, -10},
+		{-10, 10, 10, 10, 10, 10, 10, -10},
+		{-10, 5, 0, 0, 0, 0, 5, -10},
+		{-20, -10, -10, -10, -10, -10, -10, -20},
+	},
+	'r': {
+		{0, 0, 0, 0, 0, 0, 0, 0},
+		{5, 10, 10, 10, 10, 10, 10, 5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{-5, 0, 0, 0, 0, 0, 0, -5},
+		{0, 0, 0, 5, 5, 0, 0, 0},
+	},
+	'q': {
+		{-20, -10, -10, -5, -5, -10, -10, -20},
+		{-10, 0, 0, 0, 0, 0, 0, -10},
+		{-10, 0, 5, 5, 5, 5, 0, -10},
+		{-5, 0, 5, 5, 5, 5, 0, -5},
+		{0, 0, 5, 5, 5, 5, 0, -5},
+		{-10, 5, 5, 5, 5, 5, 0, -10},
+		{-10, 0, 5, 0, 0, 0, 0, -10},
+		{-20, -10, -10, -5, -5, -10, -10, -20},
+	},
+	'x': {
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-30, -40, -40, -50, -50, -40, -40, -30},
+		{-20, -30, -30, -40, -40, -30, -30, -20},
+		{-10, -20, -20, -20, -20, -20, -20, -10},
+		{20, 20, 0, 0, 0, 0, 20, 20},
+		{20, 30, 10, 0, 0, 10, 30, 20},
+	},
+}
+
+// Returns the score of the position in centipawns from the point of view
+// of the side to move.
+func evaluate(pos *gl.BoardState) int {
+	score := 0
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			square := pos.Board[row][col]
+			if square == gl.Empty {
+				continue
+			}
+			piece := unicode.ToLower(square)
+			if square == piece {
+				score += pieceValues[piece] + pieceSquareTables[piece][row][col]
+			} else {
+				score -= pieceValues[piece] + pieceSquareTables[piece][7-row][col]
+			}
+		}
+	}
+	if pos.TurnColor == "b" {
+		return -score
+	}
+	return score
+}
+
+// Returns the material won by a move: the captured piece plus the value
+// a promotion adds.
+func materialGain(pos *gl.BoardState, move *gl.Move) int {
+	gain := 0
+	if captured := pos.Board[move.To[0]][move.To[1]]; captured != gl.Empty {
+		gain += pieceValues[unicode.ToLower(captured)]
+	}
+	if move.Promotion != 0 {
+		gain += pieceValues[move.Promotion] - pieceValues['p']
+	}
+	return gain
+}
@@ -0,0 +1,39 @@
+/*
+Engine capturing the most valuable piece it can.
+*/
+package engine
+
+import (
+	"context"
+	"math/rand"
+
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+)
+
+// Greedy plays the move winning the most material right away, without
+// looking at the reply. Ties are broken randomly.
+type Greedy struct{}
+
+func (Greedy) BestMove(ctx context.Context, pos gl.BoardState, limits Limits) (gl.Move, error) {
+	moves, err := LegalMoves(&pos)
+	if err != nil {
+		return gl.Move{}, err
+	}
+	if len(moves) == 0 {
+		return gl.Move{}, ErrNoLegalMoves
+	}
+
+	var best []gl.Move
+	bestGain := -1
+	for _, move := range moves {
+		gain := materialGain(&pos, &move)
+		switch {
+		case gain > bestGain:
+			best = []gl.Move{move}
+			bestGain = gain
+		case gain == bestGain:
+			best = append(best, move)
+		}
+	}
+	return best[rand.Intn(len(best))], nil
+}
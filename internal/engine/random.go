@@ -0,0 +1,25 @@
+/*
+Engine playing random legal moves.
+*/
+package engine
+
+import (
+	"context"
+	"math/rand"
+
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+)
+
+// Random plays a random legal move, like the 'randommove' request.
+type Random struct{}
+
+func (Random) BestMove(ctx context.Context, pos gl.BoardState, limits Limits) (gl.Move, error) {
+	moves, err := LegalMoves(&pos)
+	if err != nil {
+		return gl.Move{}, err
+	}
+	if len(moves) == 0 {
+		return gl.Move{}, ErrNoLegalMoves
+	}
+	return moves[rand.Intn(len(moves))], nil
+}
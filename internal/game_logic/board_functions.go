@@ -6,10 +6,10 @@ board information are implemented here.
 package game_logic
 
 import (
-	"encoding/json"
 	"fmt"
 	"math"
 	"strings"
+	"unicode"
 )
 
 // BoardState represents the chessboard and other game infos.
@@ -114,15 +114,15 @@ func isInBounds(pos [2]int) bool {
 	return pos[0] >= 0 && pos[0] < 8 && pos[1] >= 0 && pos[1] < 8
 }
 
-// Applies a specified move to the board.
-func MakeMove(move *Move, bstate BoardState, realMove bool) BoardState {
+// Applies a specified move to the board. For real moves the game end
+// is detected as well, which is the only source of errors.
+func MakeMove(move *Move, bstate BoardState, realMove bool) (BoardState, error) {
 	fromRow, fromCol := move.From[0], move.From[1]
 	toRow, toCol := move.To[0], move.To[1]
 	fromColor, fromPiece := getColorAndPiece(fromRow, fromCol, bstate.Board)
 
-	var newBstate BoardState
-	data, _ := json.Marshal(bstate)
-	json.Unmarshal(data, &newBstate)
+	// BoardState only holds values, assigning it copies the board
+	newBstate := bstate
 
 	// Update king positions
 	if fromPiece == 'x' {
@@ -150,6 +150,13 @@ func MakeMove(move *Move, bstate BoardState, realMove bool) BoardState {
 	// Update board
 	newBstate.Board[toRow][toCol] = newBstate.Board[fromRow][fromCol]
 	newBstate.Board[fromRow][fromCol] = Empty
+	if move.Promotion != 0 {
+		promoted := move.Promotion
+		if fromColor == 'b' {
+			promoted = unicode.ToUpper(promoted)
+		}
+		newBstate.Board[toRow][toCol] = promoted
+	}
 
 	// Update player
 	if fromColor == 'w' {
@@ -165,7 +172,7 @@ func MakeMove(move *Move, bstate BoardState, realMove bool) BoardState {
 		}
 		checkmate, err := isCheckmatePlayer(enemyColor, newBstate)
 		if err != nil {
-			fmt.Println("Error occured when checking for checkmate.")
+			return newBstate, fmt.Errorf("checking for checkmate: %w", err)
 		}
 		if checkmate {
 			newBstate.Winner = string(fromColor)
@@ -173,12 +180,12 @@ func MakeMove(move *Move, bstate BoardState, realMove bool) BoardState {
 		}
 		remis, err := isRemisPlayer(enemyColor, newBstate)
 		if err != nil {
-			fmt.Println("Error occured when checking for remis.")
+			return newBstate, fmt.Errorf("checking for remis: %w", err)
 		}
 		if remis {
 			newBstate.Winner = "r"
 			newBstate.TurnColor = "n"
 		}
 	}
-	return newBstate
+	return newBstate, nil
 }
@@ -0,0 +1,165 @@
+/*
+Conversion of board states to and from Forsyth-Edwards Notation (FEN).
+*/
+
+package game_logic
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Piece letters of this package mapped to FEN letters of white pieces.
+// FEN uses uppercase for white, so the case is swapped as well.
+var fenPieces = map[rune]rune{
+	'p': 'P',
+	'k': 'N',
+	'b': 'B',
+	'r': 'R',
+	'q': 'Q',
+	'x': 'K',
+}
+
+var ErrInvalidFEN = errors.New("invalid FEN")
+
+// Returns the FEN of a board state. Castling and en passant captures are
+// not supported by the game logic, so they are never offered. fullmove is
+// the number of the current full move, starting at 1.
+func ToFEN(bstate *BoardState, fullmove int) string {
+	var sb strings.Builder
+	for row := 0; row < 8; row++ {
+		empty := 0
+		for col := 0; col < 8; col++ {
+			square := bstate.Board[row][col]
+			if square == Empty {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+			piece := fenPieces[unicode.ToLower(square)]
+			if unicode.IsUpper(square) {
+				piece = unicode.ToLower(piece)
+			}
+			sb.WriteRune(piece)
+		}
+		if empty > 0 {
+			sb.WriteString(strconv.Itoa(empty))
+		}
+		if row < 7 {
+			sb.WriteByte('/')
+		}
+	}
+
+	turn := "w"
+	if bstate.TurnColor == "b" {
+		turn = "b"
+	}
+	if fullmove < 1 {
+		fullmove = 1
+	}
+	sb.WriteString(" " + turn + " - - 0 " + strconv.Itoa(fullmove))
+	return sb.String()
+}
+
+// Parses the first fields of a FEN into a board state: the piece
+// placement, the side to move and optionally castling rights and the en
+// passant square. EPD positions, which end after these four fields, are
+// accepted as well. Without castling rights a king counts as moved.
+func FromFEN(fen string) (BoardState, error) {
+	fields := strings.Fields(fen)
+	if len(fields) < 2 {
+		return BoardState{}, fmt.Errorf("%w: expected at least placement and side to move in %q", ErrInvalidFEN, fen)
+	}
+	bstate := BoardState{Winner: "n", EnPassant: [2]int{-1, -1}}
+
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != 8 {
+		return BoardState{}, fmt.Errorf("%w: expected 8 ranks, got %d", ErrInvalidFEN, len(ranks))
+	}
+	kings := map[rune]int{}
+	for row, rank := range ranks {
+		col := 0
+		for _, c := range rank {
+			if c >= '1' && c <= '8' {
+				for range int(c - '0') {
+					if col < 8 {
+						bstate.Board[row][col] = Empty
+					}
+					col++
+				}
+				continue
+			}
+			var piece rune
+			for own, fenPiece := range fenPieces {
+				if unicode.ToUpper(c) == fenPiece {
+					piece = own
+				}
+			}
+			if piece == 0 || col > 7 {
+				return BoardState{}, fmt.Errorf("%w: invalid rank %q", ErrInvalidFEN, rank)
+			}
+			if unicode.IsLower(c) {
+				// Black pieces are uppercase in this package
+				piece = unicode.ToUpper(piece)
+			}
+			bstate.Board[row][col] = piece
+			switch piece {
+			case 'x':
+				bstate.WhiteKingPos = [2]int{row, col}
+			case 'X':
+				bstate.BlackKingPos = [2]int{row, col}
+			}
+			kings[piece]++
+			col++
+		}
+		if col != 8 {
+			return BoardState{}, fmt.Errorf("%w: rank %q doesn't have 8 squares", ErrInvalidFEN, rank)
+		}
+	}
+	if kings['x'] != 1 || kings['X'] != 1 {
+		return BoardState{}, fmt.Errorf("%w: each side needs exactly one king", ErrInvalidFEN)
+	}
+
+	switch fields[1] {
+	case "w", "b":
+		bstate.TurnColor = fields[1]
+	default:
+		return BoardState{}, fmt.Errorf("%w: invalid side to move %q", ErrInvalidFEN, fields[1])
+	}
+
+	castling := "-"
+	if len(fields) > 2 {
+		castling = fields[2]
+	}
+	bstate.WhiteKingMoved = !strings.ContainsAny(castling, "KQ")
+	bstate.BlackKingMoved = !strings.ContainsAny(castling, "kq")
+
+	if len(fields) > 3 && fields[3] != "-" {
+		square, err := SquareToRowCol(fields[3])
+		if err != nil {
+			return BoardState{}, fmt.Errorf("%w: invalid en passant square %q", ErrInvalidFEN, fields[3])
+		}
+		// BoardState holds the square of the pawn that moved two squares
+		if bstate.TurnColor == "w" {
+			square[0]++
+		} else {
+			square[0]--
+		}
+		bstate.EnPassant = square
+	}
+
+	opponent := 'b'
+	if bstate.TurnColor == "b" {
+		opponent = 'w'
+	}
+	if attacked, err := kingAttacked(opponent, &bstate); err != nil || attacked {
+		return BoardState{}, fmt.Errorf("%w: the side not to move is in check", ErrInvalidFEN)
+	}
+	return bstate, nil
+}
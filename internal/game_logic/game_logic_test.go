@@ -4,7 +4,9 @@ Unittest for the game_logic package.
 package game_logic
 
 import (
+	"errors"
 	"sort"
+	"strings"
 	"testing"
 )
 
@@ -399,6 +401,208 @@ func TestRemis(t *testing.T) {
 
 }
 
+func TestValidateMoveErrors(t *testing.T) {
+	bstate := &BoardState{
+		Board: [8][8]rune{
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', 'X'},
+			{'p', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', 'R', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{'r', ' ', ' ', ' ', 'x', ' ', ' ', ' '},
+		},
+		TurnColor: "w",
+		Winner:    "n",
+	}
+
+	tests := []struct {
+		move  string
+		color rune
+		err   error
+	}{
+		{"a7 a8q", 'w', nil},
+		{"a7 a8", 'w', ErrMissingPromotion},
+		{"a1 a2q", 'w', ErrInvalidPromotion},
+		{"e1 d1", 'w', ErrKingInCheck},
+		{"a1 b2", 'w', ErrIllegalPattern},
+		{"b3 b4", 'w', ErrNoPiece},
+		{"h8 h7", 'w', ErrNotOwned},
+		{"a1 e1", 'w', ErrOwnPieceTarget},
+		{"h8 g8", 'b', ErrWrongTurn},
+	}
+	for _, test := range tests {
+		move, err := StringToMoveStruct(test.move, test.color)
+		if err != nil {
+			t.Fatalf("%q: failed to parse move: %v", test.move, err)
+		}
+		err = ValidateMove(&move, bstate)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: expected error %v, got %v", test.move, test.err, err)
+		}
+	}
+
+	if _, err := StringToMoveStruct("i1 i2", 'w'); !errors.Is(err, ErrOutOfBounds) {
+		t.Errorf("expected ErrOutOfBounds, got %v", err)
+	}
+	if _, err := StringToMoveStruct("a7 a8z", 'w'); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("expected ErrInvalidFormat, got %v", err)
+	}
+}
+
+func TestPromotion(t *testing.T) {
+	bstate := BoardState{
+		Board: [8][8]rune{
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', 'X'},
+			{'p', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			{' ', ' ', ' ', ' ', ' ', ' ', 'P', ' '},
+			{' ', ' ', ' ', ' ', 'x', ' ', ' ', ' '},
+		},
+		TurnColor: "w",
+		Winner:    "n",
+	}
+
+	var moves *Move
+	GenerateMovesForPiece(1, 0, &bstate, &moves)
+	if n := NMoves(moves); n != len(PromotionPieces) {
+		t.Fatalf("expected %d promotion moves, got %d", len(PromotionPieces), n)
+	}
+
+	move, _ := StringToMoveStruct("a7 a8k", 'w')
+	if MoveToString(&move) != "a7 a8k" {
+		t.Errorf("expected \"a7 a8k\", got %q", MoveToString(&move))
+	}
+	bstate, err := MakeMove(&move, bstate, true)
+	if err != nil {
+		t.Fatalf("failed to make move: %v", err)
+	}
+	if bstate.Board[0][0] != 'k' {
+		t.Errorf("expected white knight on a8, got %q", bstate.Board[0][0])
+	}
+
+	move, _ = StringToMoveStruct("g2 g1q", 'b')
+	bstate, err = MakeMove(&move, bstate, true)
+	if err != nil {
+		t.Fatalf("failed to make move: %v", err)
+	}
+	if bstate.Board[7][6] != 'Q' {
+		t.Errorf("expected black queen on g1, got %q", bstate.Board[7][6])
+	}
+}
+
+func TestToFEN(t *testing.T) {
+	var boards []BoardState
+	InitializeBoard(&boards)
+	start := boards[0]
+	start.TurnColor = "w"
+	if fen := ToFEN(&start, 1); fen != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w - - 0 1" {
+		t.Errorf("unexpected start position FEN %q", fen)
+	}
+
+	move, _ := StringToMoveStruct("e2 e4", 'w')
+	next, _ := MakeMove(&move, start, true)
+	if fen := ToFEN(&next, 1); fen != "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b - - 0 1" {
+		t.Errorf("unexpected FEN after e2 e4 %q", fen)
+	}
+}
+
+func TestFromFEN(t *testing.T) {
+	var boards []BoardState
+	InitializeBoard(&boards)
+	start := boards[0]
+	start.TurnColor = "w"
+	bstate, err := FromFEN("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bstate != start {
+		t.Errorf("start position differs from InitializeBoard: %+v", bstate)
+	}
+
+	// EPD with an en passant square and no castling rights
+	bstate, err = FromFEN("rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b - e3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bstate.EnPassant != [2]int{4, 4} || !bstate.WhiteKingMoved || bstate.TurnColor != "b" {
+		t.Errorf("unexpected board state %+v", bstate)
+	}
+	if fen := ToFEN(&bstate, 2); fen != "rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b - - 0 2" {
+		t.Errorf("FEN doesn't round trip, got %q", fen)
+	}
+
+	for _, fen := range []string{
+		"",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w",
+		"rnbqkbnr/pppppppp/9/8/8/8/PPPPPPPP/RNBQKBNR w",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQQBNR w",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x",
+		"4k3/8/8/8/8/8/8/4R2K w", // Black is in check but white to move
+	} {
+		if _, err := FromFEN(fen); !errors.Is(err, ErrInvalidFEN) {
+			t.Errorf("expected ErrInvalidFEN for %q, got %v", fen, err)
+		}
+	}
+}
+
+func TestParseSAN(t *testing.T) {
+	bstate, _ := FromFEN("r3k3/1P6/8/8/8/8/4K3/R6R w")
+	for san, expected := range map[string]string{
+		"Rad1":   "a1 d1",
+		"Rhf1+":  "h1 f1",
+		"bxa8=Q": "b7 a8q",
+		"b8N":    "b7 b8k",
+		"Kd2":    "e2 d2",
+	} {
+		move, err := ParseSAN(san, &bstate)
+		if err != nil {
+			t.Errorf("%s: %v", san, err)
+			continue
+		}
+		if got := MoveToString(&move); got != expected {
+			t.Errorf("%s: expected %s, got %s", san, expected, got)
+		}
+	}
+	for _, san := range []string{"Rd1", "O-O", "Nf3", "b8=K", "e9", "Rb8"} {
+		if _, err := ParseSAN(san, &bstate); !errors.Is(err, ErrInvalidSAN) {
+			t.Errorf("expected ErrInvalidSAN for %q, got %v", san, err)
+		}
+	}
+}
+
+func TestParsePGN(t *testing.T) {
+	text := `[Event "Test"]
+[Opening "Sicilian"]
+
+1. e4 c5 {the Sicilian; sharp
+   and popular} 2. Nf3 (2. Nc3 Nc6 (2... e6)) d6 $1 3.d4 1/2-1/2
+
+1.d4 ; comment
+d5 *
+`
+	games, err := ParsePGN(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 2 {
+		t.Fatalf("expected 2 games, got %d", len(games))
+	}
+	if games[0].Tags["Opening"] != "Sicilian" || strings.Join(games[0].Moves, " ") != "e4 c5 Nf3 d6 d4" {
+		t.Errorf("unexpected first game %+v", games[0])
+	}
+	if strings.Join(games[1].Moves, " ") != "d4 d5" {
+		t.Errorf("unexpected second game %+v", games[1])
+	}
+	if _, err := ParsePGN(`[Event Test]`); err == nil {
+		t.Error("expected an error for an invalid tag")
+	}
+}
+
 func movesListToSlice(t *testing.T, moves *Move) []*Move {
 	var ret []*Move
 	ptmp := moves
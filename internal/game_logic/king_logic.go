@@ -144,6 +144,11 @@ func fieldAttacked(row, col int, attackerColor rune, boardState *BoardState) (bo
 	return false, nil
 }
 
+// Checks whether the king of the given color is attacked.
+func InCheck(color rune, boardState *BoardState) (bool, error) {
+	return kingAttacked(color, boardState)
+}
+
 func kingAttacked(color rune, boardState *BoardState) (bool, error) {
 	king_row, king_col, enemy_color := getKingdataFromColor(color, boardState)
 	attacked, err := fieldAttacked(king_row, king_col, enemy_color, boardState)
@@ -306,7 +311,7 @@ func FilterInvalidMoves(move *Move, bstate *BoardState) (*Move, error) {
 	movep := &retHead
 
 	for move != nil {
-		nextBState := MakeMove(move, *bstate, false)
+		nextBState, _ := MakeMove(move, *bstate, false)
 		check, err := kingAttacked(move.Color, &nextBState)
 		if err != nil {
 			return retHead, err
@@ -0,0 +1,21 @@
+/*
+Errors returned by move parsing and validation. They can be
+matched with errors.Is to tell why a move was rejected.
+*/
+
+package game_logic
+
+import "errors"
+
+var (
+	ErrInvalidFormat    = errors.New("invalid move string format")
+	ErrWrongTurn        = errors.New("it's not the player's turn")
+	ErrOutOfBounds      = errors.New("move out of bounds")
+	ErrNoPiece          = errors.New("there is no piece to move")
+	ErrNotOwned         = errors.New("the piece to be moved is not owned")
+	ErrOwnPieceTarget   = errors.New("the target position contains an owned piece")
+	ErrIllegalPattern   = errors.New("the piece can't move this way")
+	ErrKingInCheck      = errors.New("move leaves the king under attack")
+	ErrMissingPromotion = errors.New("pawn promotion requires a piece")
+	ErrInvalidPromotion = errors.New("promotion is only allowed for pawns reaching the last rank")
+)
@@ -7,7 +7,6 @@ package game_logic
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 )
 
@@ -15,13 +14,17 @@ const Empty = ' '
 
 // Move represents a chess move.
 type Move struct {
-	From    [2]int // [row, col]
-	To      [2]int // [row, col]
-	Color   rune   // 'w' or 'b'
-	Capture bool
-	Next    *Move
+	From      [2]int // [row, col]
+	To        [2]int // [row, col]
+	Color     rune   // 'w' or 'b'
+	Capture   bool
+	Promotion rune // Piece a pawn turns into: 'q', 'r', 'b', 'k' or 0 if none
+	Next      *Move
 }
 
+// Pieces a pawn can be promoted to.
+var PromotionPieces = []rune{'q', 'r', 'b', 'k'}
+
 // Comparator for Move
 func EqMove(move1, move2 *Move) bool {
 	if move1 == nil || move2 == nil {
@@ -30,7 +33,8 @@ func EqMove(move1, move2 *Move) bool {
 
 	return move1.From == move2.From &&
 		move1.To == move2.To &&
-		move1.Color == move2.Color
+		move1.Color == move2.Color &&
+		move1.Promotion == move2.Promotion
 }
 
 // Checks whether a move is part of a move-list
@@ -73,7 +77,11 @@ func MoveToString(move *Move) string {
 		return col + row
 	}
 
-	return rowColToField(move.From) + " " + rowColToField(move.To)
+	moveStr := rowColToField(move.From) + " " + rowColToField(move.To)
+	if move.Promotion != 0 {
+		moveStr += string(move.Promotion)
+	}
+	return moveStr
 }
 
 // AllPossibleMoves generates all moves for the given player and evaluates board value
@@ -136,7 +144,7 @@ func pawnMoves(row, col int, color rune, boardState *BoardState, moves **Move) {
 
 	// Single step forward
 	if isValid(row+direction, col) && board[row+direction][col] == Empty {
-		addMove(row, col, row+direction, col, color, false, moves)
+		addPawnMove(row, col, row+direction, col, color, false, moves)
 	}
 
 	// Double step on initial position
@@ -156,12 +164,28 @@ func pawnMoves(row, col int, color rune, boardState *BoardState, moves **Move) {
 				continue
 			}
 			if color != target_color {
-				addMove(row, col, row+direction, col+offset, color, true, moves)
+				addPawnMove(row, col, row+direction, col+offset, color, true, moves)
 			}
 		}
 	}
 }
 
+// Adds a pawn move, expanded into all promotions if it reaches the last rank.
+func addPawnMove(fromRow, fromCol, toRow, toCol int, color rune, capture bool, moves **Move) {
+	if toRow != 0 && toRow != 7 {
+		addMove(fromRow, fromCol, toRow, toCol, color, capture, moves)
+		return
+	}
+	for _, piece := range PromotionPieces {
+		addMove(fromRow, fromCol, toRow, toCol, color, capture, moves)
+		last := *moves
+		for last.Next != nil {
+			last = last.Next
+		}
+		last.Promotion = piece
+	}
+}
+
 // knightMoves generates moves for a knight
 func knightMoves(row, col int, color rune, boardState *BoardState, moves **Move) {
 	knightOffsets := [][2]int{{-2, -1}, {-2, 1}, {-1, -2}, {-1, 2}, {1, -2}, {1, 2}, {2, -1}, {2, 1}}
@@ -276,96 +300,121 @@ func addMove(fromRow, fromCol, toRow, toCol int, color rune, capture bool, moves
 	}
 }
 
+// Converts a square in chess notation, e.g. "e2", to [row, col].
+func SquareToRowCol(square string) ([2]int, error) {
+	if len(square) != 2 {
+		return [2]int{}, fmt.Errorf("%w: invalid position %q", ErrInvalidFormat, square)
+	}
+	col := int(square[0]) - 'a'       // Convert column ('a' -> 0, ..., 'h' -> 7)
+	row := 8 - (int(square[1]) - '0') // Convert row ('1' -> 7, ..., '8' -> 0)
+	if col < 0 || col > 7 || row < 0 || row > 7 {
+		return [2]int{}, ErrOutOfBounds
+	}
+	return [2]int{row, col}, nil
+}
+
 // Converts a move string to a 'Move' struct.
+// Promotions append the piece to the string, e.g. "e7 e8q".
 func StringToMoveStruct(moveStr string, color rune) (Move, error) {
 	// Ensure the input is valid
-	if len(moveStr) != 5 || moveStr[2] != ' ' {
-		return Move{}, errors.New("invalid move string format")
+	if (len(moveStr) != 5 && len(moveStr) != 6) || moveStr[2] != ' ' {
+		return Move{}, ErrInvalidFormat
 	}
 
-	// Helper function to convert chess notation to indices
-	chessToIndex := func(pos string) ([2]int, error) {
-		if len(pos) != 2 {
-			return [2]int{}, errors.New("invalid position format")
-		}
-		col := int(pos[0] - 'a')   // Convert column ('a' -> 0, ..., 'h' -> 7)
-		row := 8 - int(pos[1]-'0') // Convert row ('1' -> 7, ..., '8' -> 0)
-		if col < 0 || col > 7 || row < 0 || row > 7 {
-			return [2]int{}, errors.New("position out of bounds")
+	var promotion rune
+	if len(moveStr) == 6 {
+		promotion = rune(moveStr[5])
+		if !isExcluded(promotion, PromotionPieces) {
+			return Move{}, fmt.Errorf("%w: unknown promotion piece %q", ErrInvalidFormat, promotion)
 		}
-		return [2]int{row, col}, nil
 	}
 
 	// Parse positions
-	from, err := chessToIndex(moveStr[:2])
+	from, err := SquareToRowCol(moveStr[:2])
 	if err != nil {
 		return Move{}, fmt.Errorf("invalid 'From' position: %w", err)
 	}
 
-	to, err := chessToIndex(moveStr[3:5])
+	to, err := SquareToRowCol(moveStr[3:5])
 	if err != nil {
 		return Move{}, fmt.Errorf("invalid 'To' position: %w", err)
 	}
 
 	// Create the Move struct (Color and Capture need additional context to fill correctly)
 	move := Move{
-		From:    from,
-		To:      to,
-		Color:   color,
-		Capture: false,
+		From:      from,
+		To:        to,
+		Color:     color,
+		Capture:   false,
+		Promotion: promotion,
 	}
 
 	return move, nil
 }
 
 // validateMove checks whether a move is valid.
+// The returned error wraps one of the Err* values of this package.
 func ValidateMove(move *Move, bstate *BoardState) error {
 	if move.Color != rune(bstate.TurnColor[0]) {
-		return fmt.Errorf("it's not %q's turn", move.Color)
+		return fmt.Errorf("%w: it's not %q's turn", ErrWrongTurn, move.Color)
 	}
 
 	board := bstate.Board
 	from_row, from_col := move.From[0], move.From[1]
 	color := move.Color
 	if !isInBounds(move.From) || !isInBounds(move.To) {
-		return errors.New("move out of bounds")
+		return ErrOutOfBounds
 	}
 
-	fromColor, _ := getColorAndPiece(move.From[0], move.From[1], board)
+	fromColor, fromPiece := getColorAndPiece(move.From[0], move.From[1], board)
+	if fromPiece == Empty {
+		return ErrNoPiece
+	}
 	if fromColor != color {
-		return errors.New("the piece to be moved is not owned")
+		return ErrNotOwned
 	}
 
 	toColor, _ := getColorAndPiece(move.To[0], move.To[1], board)
 	if toColor == color {
-		return errors.New("the target position contains an owned piece")
+		return ErrOwnPieceTarget
 	}
 
 	var moves *Move
 	GenerateMovesForPiece(from_row, from_col, bstate, &moves)
 	if !IsMoveInMoves(move, moves) {
-		return errors.New("move doesn't exist")
+		promotes := fromPiece == 'p' && (move.To[0] == 0 || move.To[0] == 7)
+		withoutPromotion := *move
+		withoutPromotion.Promotion = 0
+		withPromotion := *move
+		withPromotion.Promotion = 'q'
+		switch {
+		case move.Promotion == 0 && promotes && IsMoveInMoves(&withPromotion, moves):
+			return ErrMissingPromotion
+		case move.Promotion != 0 && IsMoveInMoves(&withoutPromotion, moves):
+			return ErrInvalidPromotion
+		}
+		return ErrIllegalPattern
 	}
 	validMoves, err := FilterInvalidMoves(moves, bstate)
 	if err != nil {
 		return err
 	}
 	if !IsMoveInMoves(move, validMoves) {
-		return errors.New("move is not valid")
+		return ErrKingInCheck
 	}
 
 	var tmpBstate BoardState
 	data, _ := json.Marshal(bstate)
 	json.Unmarshal(data, &tmpBstate)
 
-	new_bstate := MakeMove(move, tmpBstate, false)
+	new_bstate, _ := MakeMove(move, tmpBstate, false)
 
 	attacked, err := kingAttacked(color, &new_bstate)
 	if err != nil {
 		return err
 	}
 	if attacked {
-		return errors.New("king is under attack")
+		return ErrKingInCheck
 	}
 
 	return nil
@@ -0,0 +1,116 @@
+/*
+Reading of games in Portable Game Notation (PGN). Only the tags and the
+moves of the main line are kept; comments, variations and annotations are
+skipped.
+*/
+
+package game_logic
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// A game of a PGN file. Moves are in SAN.
+type PGNGame struct {
+	Tags  map[string]string
+	Moves []string
+}
+
+var (
+	pgnTag        = regexp.MustCompile(`^\[(\w+)\s+"((?:[^"\\]|\\.)*)"\]$`)
+	pgnMoveNumber = regexp.MustCompile(`^\d+\.+`)
+)
+
+// Parses all games of a PGN text.
+func ParsePGN(text string) ([]PGNGame, error) {
+	var games []PGNGame
+	var current *PGNGame
+	// Comments and variations may span lines
+	depth, comment := 0, false
+	finish := func() {
+		if current != nil && (len(current.Moves) > 0 || len(current.Tags) > 0) {
+			games = append(games, *current)
+		}
+		current = nil
+	}
+	start := func() {
+		if current == nil {
+			current = &PGNGame{Tags: make(map[string]string)}
+		}
+	}
+
+	for lineNo, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if depth == 0 && !comment && strings.HasPrefix(line, "[") {
+			// Tags after moves start the next game
+			if current != nil && len(current.Moves) > 0 {
+				finish()
+			}
+			match := pgnTag.FindStringSubmatch(line)
+			if match == nil {
+				return nil, fmt.Errorf("line %d: invalid tag %q", lineNo+1, line)
+			}
+			start()
+			current.Tags[match[1]] = strings.ReplaceAll(match[2], `\"`, `"`)
+			continue
+		}
+		if strings.HasPrefix(line, "%") {
+			continue
+		}
+		for _, token := range pgnTokens(line, &depth, &comment) {
+			switch token {
+			case "1-0", "0-1", "1/2-1/2", "*":
+				start()
+				finish()
+				continue
+			}
+			start()
+			current.Moves = append(current.Moves, token)
+		}
+	}
+	finish()
+	return games, nil
+}
+
+// Splits a line of movetext into moves and results. depth and comment
+// hold whether the line starts inside variations or a comment.
+func pgnTokens(line string, depth *int, comment *bool) []string {
+	var tokens []string
+	var sb strings.Builder
+	flush := func() {
+		token := pgnMoveNumber.ReplaceAllString(sb.String(), "")
+		sb.Reset()
+		if token != "" && !strings.HasPrefix(token, "$") {
+			tokens = append(tokens, token)
+		}
+	}
+	for _, c := range line {
+		switch {
+		case *comment:
+			if c == '}' {
+				*comment = false
+			}
+		case c == '{':
+			flush()
+			*comment = true
+		case c == ';':
+			flush()
+			return tokens
+		case c == '(':
+			flush()
+			*depth++
+		case c == ')':
+			flush()
+			*depth--
+		case *depth > 0:
+		case c == ' ' || c == '\t' || c == '\r':
+			flush()
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	flush()
+	return tokens
+}
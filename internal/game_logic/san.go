@@ -0,0 +1,111 @@
+/*
+Parsing of moves in Standard Algebraic Notation (SAN), e.g. "Nf3", "exd5"
+or "e8=Q+", as used by PGN files.
+*/
+
+package game_logic
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var ErrInvalidSAN = errors.New("invalid SAN move")
+
+// SAN piece letters mapped to the piece letters of this package.
+var sanPieces = map[byte]rune{'N': 'k', 'B': 'b', 'R': 'r', 'Q': 'q', 'K': 'x'}
+
+// Returns the legal move of the side to move that the SAN describes.
+// Castling is not supported by the game logic and rejected.
+func ParseSAN(san string, bstate *BoardState) (Move, error) {
+	if bstate.TurnColor != "w" && bstate.TurnColor != "b" {
+		return Move{}, fmt.Errorf("%w: nobody has to move", ErrInvalidSAN)
+	}
+	color := rune(bstate.TurnColor[0])
+
+	s := strings.TrimRight(san, "+#!?")
+	if strings.HasPrefix(s, "O-O") || strings.HasPrefix(s, "0-0") {
+		return Move{}, fmt.Errorf("%w: castling %q is not supported", ErrInvalidSAN, san)
+	}
+
+	var promotion rune
+	if i := strings.IndexByte(s, '='); i >= 0 {
+		if i != len(s)-2 {
+			return Move{}, fmt.Errorf("%w: %q", ErrInvalidSAN, san)
+		}
+		promotion = sanPieces[s[i+1]]
+		s = s[:i]
+	} else if len(s) > 2 && sanPieces[s[len(s)-1]] != 0 && unicode.IsDigit(rune(s[len(s)-2])) {
+		// Promotion without '=', e.g. "e8Q"
+		promotion = sanPieces[s[len(s)-1]]
+		s = s[:len(s)-1]
+	}
+	if promotion == 'x' {
+		return Move{}, fmt.Errorf("%w: %q promotes to a king", ErrInvalidSAN, san)
+	}
+
+	piece := 'p'
+	if len(s) > 0 && sanPieces[s[0]] != 0 {
+		piece = sanPieces[s[0]]
+		s = s[1:]
+	}
+	if len(s) < 2 {
+		return Move{}, fmt.Errorf("%w: %q", ErrInvalidSAN, san)
+	}
+	to, err := SquareToRowCol(s[len(s)-2:])
+	if err != nil {
+		return Move{}, fmt.Errorf("%w: %q", ErrInvalidSAN, san)
+	}
+	// What remains is the disambiguation, e.g. the "b" of "Nbd7"
+	disambiguation := strings.ReplaceAll(s[:len(s)-2], "x", "")
+
+	moves := AllPossibleMoves(color, bstate, nil)
+	legal, err := FilterInvalidMoves(moves, bstate)
+	if err != nil {
+		return Move{}, err
+	}
+	var matches []Move
+	for move := legal; move != nil; move = move.Next {
+		_, movedPiece := getColorAndPiece(move.From[0], move.From[1], bstate.Board)
+		if movedPiece != piece || move.To != to || move.Promotion != promotion {
+			continue
+		}
+		from := MoveToString(move)[:2]
+		if !matchesDisambiguation(from, disambiguation) {
+			continue
+		}
+		found := *move
+		found.Next = nil
+		matches = append(matches, found)
+	}
+	switch len(matches) {
+	case 0:
+		return Move{}, fmt.Errorf("%w: %q is not legal", ErrInvalidSAN, san)
+	case 1:
+		return matches[0], nil
+	default:
+		return Move{}, fmt.Errorf("%w: %q is ambiguous", ErrInvalidSAN, san)
+	}
+}
+
+// Reports whether the origin square, e.g. "b8", matches a file, a rank or
+// a square of a SAN disambiguation.
+func matchesDisambiguation(from string, disambiguation string) bool {
+	for _, c := range disambiguation {
+		switch {
+		case c >= 'a' && c <= 'h':
+			if rune(from[0]) != c {
+				return false
+			}
+		case c >= '1' && c <= '8':
+			if rune(from[1]) != c {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+/*
+Structured logging setup and request scoped loggers.
+*/
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// Installs the default slog logger. Output of the standard log package
+// is routed through it as well.
+func Setup(level, format string, w io.Writer) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+
+	logger := slog.New(handler)
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+type contextKey struct{}
+
+// Logger of a single request. Handlers add attributes while they learn
+// more about the request, e.g. the board ID.
+type requestLogger struct {
+	mu     sync.Mutex
+	logger *slog.Logger
+}
+
+// Returns a context carrying the given logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, &requestLogger{logger: logger})
+}
+
+// Returns the logger of the context, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	rl, ok := ctx.Value(contextKey{}).(*requestLogger)
+	if !ok {
+		return slog.Default()
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.logger
+}
+
+// Adds attributes to all following log lines of the context's request.
+func AddAttrs(ctx context.Context, args ...any) {
+	rl, ok := ctx.Value(contextKey{}).(*requestLogger)
+	if !ok {
+		return
+	}
+	rl.mu.Lock()
+	rl.logger = rl.logger.With(args...)
+	rl.mu.Unlock()
+}
@@ -0,0 +1,239 @@
+/*
+Matchmaking queue for bots. A bot that wants a game enters the queue with
+a ticket and is paired with the first waiting ticket that is compatible:
+same move time, both rated or both casual, and each rating within the
+range the other one asked for. The caller creates the game of a pair and
+resolves both tickets with their seats, waiting bots are woken up.
+
+Tickets live in memory only. A waiting ticket nobody asked about for
+TicketTTL leaves the queue, so crashed bots don't get paired. A resolved
+ticket holds the seat's token and is dropped TicketTTL after it was
+resolved, however often it is asked about.
+*/
+package matchmaking
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Time after which unclaimed tickets are dropped.
+var TicketTTL = time.Minute
+
+var ErrTicketNotFound = errors.New("ticket not found")
+
+type Status string
+
+const (
+	StatusWaiting Status = "waiting" // In the queue
+	StatusPairing Status = "pairing" // Paired, the game is being created
+	StatusMatched Status = "matched"
+	StatusFailed  Status = "failed" // The game couldn't be created
+)
+
+// What a bot asks for.
+type Request struct {
+	Player    string        // Rating identity, "" if anonymous
+	Rating    float64       // Current rating of the player
+	MoveTime  time.Duration // Time per move, 0 for none
+	Rated     bool
+	MinRating float64 // Lowest rating of the opponent, 0 for no limit
+	MaxRating float64 // Highest rating of the opponent, 0 for no limit
+}
+
+// Accepts the rating of an opponent.
+func (r *Request) accepts(rating float64) bool {
+	return (r.MinRating == 0 || rating >= r.MinRating) && (r.MaxRating == 0 || rating <= r.MaxRating)
+}
+
+// Reports whether two requests can be paired.
+func compatible(a, b *Request) bool {
+	if a.Player != "" && a.Player == b.Player {
+		return false
+	}
+	return a.MoveTime == b.MoveTime && a.Rated == b.Rated && a.accepts(b.Rating) && b.accepts(a.Rating)
+}
+
+// The seat of a paired bot.
+type Match struct {
+	BoardID  int32
+	Color    string // "w" or "b"
+	Token    string
+	Opponent string // Rating identity of the opponent, "" if anonymous
+}
+
+// Copy of the data of a ticket.
+type State struct {
+	ID         string
+	SecretHash string // Hash of the secret the owner asks about the ticket with
+	Request    Request
+	Status     Status
+	Match      Match  // Set once matched
+	Error      string // Set if failed
+	CreatedAt  time.Time
+}
+
+type Ticket struct {
+	state    State
+	seen     time.Time     // Last time the owner asked about the waiting ticket, or when it was resolved
+	waiters  int           // Requests waiting for the ticket to be resolved
+	resolved chan struct{} // Closed once matched or failed
+}
+
+var (
+	mu      sync.Mutex
+	tickets = map[string]*Ticket{}
+	queue   []*Ticket // Waiting tickets, oldest first
+)
+
+// Enters a request into the queue under the given ticket ID and hash of its
+// secret. If a waiting
+// ticket is compatible, both are taken out of the queue and the opponent
+// is returned; the caller has to resolve both tickets.
+func Join(id string, secretHash string, req Request) (*Ticket, *Ticket) {
+	mu.Lock()
+	defer mu.Unlock()
+	now := time.Now()
+	expire(now)
+
+	ticket := &Ticket{
+		state:    State{ID: id, SecretHash: secretHash, Request: req, Status: StatusWaiting, CreatedAt: now},
+		seen:     now,
+		resolved: make(chan struct{}),
+	}
+	tickets[id] = ticket
+
+	for i, waiting := range queue {
+		if compatible(&waiting.state.Request, &req) {
+			queue = append(queue[:i], queue[i+1:]...)
+			waiting.state.Status = StatusPairing
+			ticket.state.Status = StatusPairing
+			return ticket, waiting
+		}
+	}
+	queue = append(queue, ticket)
+	return ticket, nil
+}
+
+// Returns the ticket with the given ID.
+func Lookup(id string) (*Ticket, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	now := time.Now()
+	expire(now)
+
+	ticket, exists := tickets[id]
+	if !exists {
+		return nil, ErrTicketNotFound
+	}
+	ticket.touch(now)
+	return ticket, nil
+}
+
+// Takes a waiting ticket out of the queue. Returns false if it was
+// already paired.
+func Leave(id string) (bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	ticket, exists := tickets[id]
+	if !exists {
+		return false, ErrTicketNotFound
+	}
+	if ticket.state.Status != StatusWaiting {
+		return false, nil
+	}
+	removeFromQueue(ticket)
+	delete(tickets, id)
+	return true, nil
+}
+
+// Returns the number of waiting tickets.
+func Waiting() int {
+	mu.Lock()
+	defer mu.Unlock()
+	expire(time.Now())
+	return len(queue)
+}
+
+// Drops tickets their owners didn't ask about for TicketTTL, unless
+// someone is waiting for them. Expects mu to be held.
+func expire(now time.Time) {
+	for id, ticket := range tickets {
+		if ticket.waiters == 0 && ticket.state.Status != StatusPairing && now.Sub(ticket.seen) > TicketTTL {
+			removeFromQueue(ticket)
+			delete(tickets, id)
+		}
+	}
+}
+
+func removeFromQueue(ticket *Ticket) {
+	for i, waiting := range queue {
+		if waiting == ticket {
+			queue = append(queue[:i], queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// Records that the owner asked about the ticket. Only waiting tickets are
+// kept alive that way. Expects mu to be held.
+func (t *Ticket) touch(now time.Time) {
+	if t.state.Status == StatusWaiting {
+		t.seen = now
+	}
+}
+
+// Returns a copy of the ticket's data.
+func (t *Ticket) State() State {
+	mu.Lock()
+	defer mu.Unlock()
+	return t.state
+}
+
+// Hands a paired ticket its seat.
+func (t *Ticket) Resolve(match Match) {
+	mu.Lock()
+	defer mu.Unlock()
+	t.state.Status = StatusMatched
+	t.state.Match = match
+	t.seen = time.Now()
+	close(t.resolved)
+}
+
+// Marks a paired ticket as failed, e.g. because its game couldn't be
+// created.
+func (t *Ticket) Fail(err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	t.state.Status = StatusFailed
+	t.state.Error = err.Error()
+	t.seen = time.Now()
+	close(t.resolved)
+}
+
+// Blocks until the ticket is matched or failed, the timeout expires or
+// one of the channels is closed, and returns the ticket's data.
+func (t *Ticket) Wait(ctx context.Context, timeout time.Duration, cancel <-chan struct{}) State {
+	mu.Lock()
+	t.waiters++
+	mu.Unlock()
+	defer func() {
+		mu.Lock()
+		t.waiters--
+		t.touch(time.Now())
+		mu.Unlock()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-t.resolved:
+	case <-timer.C:
+	case <-cancel:
+	case <-ctx.Done():
+	}
+	return t.State()
+}
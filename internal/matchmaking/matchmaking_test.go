@@ -0,0 +1,107 @@
+/*
+Unittest for the matchmaking package.
+*/
+package matchmaking
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func reset() {
+	mu.Lock()
+	tickets = map[string]*Ticket{}
+	queue = nil
+	mu.Unlock()
+}
+
+func TestPairing(t *testing.T) {
+	reset()
+	if _, opponent := Join("a", "", Request{Player: "a", Rating: 1500, MoveTime: time.Second}); opponent != nil {
+		t.Fatal("the first ticket has nobody to play")
+	}
+	// Different time control, casual against rated, out of range and self-play
+	for id, req := range map[string]Request{
+		"b": {Player: "b", Rating: 1500},
+		"c": {Player: "c", Rating: 1500, MoveTime: time.Second, Rated: true},
+		"d": {Player: "d", Rating: 1500, MoveTime: time.Second, MinRating: 1600},
+		"e": {Player: "e", Rating: 1900, MoveTime: time.Second, MaxRating: 1450},
+		"f": {Player: "a", Rating: 1500, MoveTime: time.Second},
+	} {
+		if _, opponent := Join(id, "", req); opponent != nil {
+			t.Errorf("%s should not be paired with %s", id, opponent.State().ID)
+		}
+	}
+	if n := Waiting(); n != 6 {
+		t.Fatalf("expected 6 waiting tickets, got %d", n)
+	}
+
+	ticket, opponent := Join("g", "", Request{Player: "g", Rating: 1450, MoveTime: time.Second, MaxRating: 1600})
+	if opponent == nil || opponent.State().ID != "a" {
+		t.Fatalf("expected a pairing with the oldest compatible ticket, got %v", opponent)
+	}
+	if ticket.State().Status != StatusPairing || Waiting() != 5 {
+		t.Errorf("paired tickets should leave the queue")
+	}
+}
+
+func TestWaitAndResolve(t *testing.T) {
+	reset()
+	waiting, _ := Join("a", "", Request{})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		ticket, opponent := Join("b", "", Request{})
+		opponent.Resolve(Match{BoardID: 1, Color: "w", Token: "t1"})
+		ticket.Resolve(Match{BoardID: 1, Color: "b", Token: "t2"})
+	}()
+
+	state := waiting.Wait(context.Background(), time.Second, nil)
+	if state.Status != StatusMatched || state.Match.BoardID != 1 || state.Match.Token != "t1" {
+		t.Errorf("unexpected state %+v", state)
+	}
+
+	state = waiting.Wait(context.Background(), time.Second, nil)
+	if state.Status != StatusMatched {
+		t.Error("waiting again should return the match immediately")
+	}
+}
+
+func TestLeaveAndExpire(t *testing.T) {
+	reset()
+	Join("a", "", Request{})
+	if left, err := Leave("a"); !left || err != nil {
+		t.Errorf("expected to leave the queue, got %v %v", left, err)
+	}
+	if _, err := Lookup("a"); err != ErrTicketNotFound {
+		t.Errorf("expected ErrTicketNotFound, got %v", err)
+	}
+
+	defer func(ttl time.Duration) { TicketTTL = ttl }(TicketTTL)
+	TicketTTL = 10 * time.Millisecond
+	Join("b", "", Request{})
+	time.Sleep(20 * time.Millisecond)
+	if _, opponent := Join("c", "", Request{}); opponent != nil {
+		t.Error("expired tickets should not be paired")
+	}
+}
+
+func TestResolvedTicketsExpire(t *testing.T) {
+	reset()
+	defer func(ttl time.Duration) { TicketTTL = ttl }(TicketTTL)
+	TicketTTL = 50 * time.Millisecond
+	Join("a", "", Request{})
+	ticket, opponent := Join("b", "", Request{})
+	opponent.Resolve(Match{BoardID: 1, Color: "w", Token: "t1"})
+	ticket.Resolve(Match{BoardID: 1, Color: "b", Token: "t2"})
+
+	// Asking about a resolved ticket doesn't keep it
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := Lookup("a"); err != nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("expected the resolved ticket to expire although it was polled")
+}
@@ -0,0 +1,115 @@
+/*
+Prometheus metrics of the server.
+*/
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+)
+
+const namespace = "chessbot"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Handled HTTP requests by route name, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by route name. Includes time spent waiting for a turn.",
+		Buckets:   []float64{.001, .005, .01, .05, .1, .5, 1, 5, 10, 60, 600, 3600},
+	}, []string{"route"})
+
+	MovesApplied = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "moves_applied_total",
+		Help:      "Moves applied to games by request type.",
+	}, []string{"reqtype"})
+
+	MoveValidationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "move_validation_duration_seconds",
+		Help:      "Latency of move validation by function.",
+		Buckets:   prometheus.ExponentialBuckets(0.00005, 2, 14),
+	}, []string{"func"})
+
+	OpenConnections = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "open_connections",
+		Help:      "Connections held open by the server, e.g. long-polls waiting for a turn.",
+	}, []string{"kind"})
+
+	Timeouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "timeouts_total",
+		Help:      "Turn waits that timed out and games abandoned by the reaper.",
+	}, []string{"kind"})
+
+	Forfeits = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "forfeits_total",
+		Help:      "Games ended by a player forfeiting.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(gamesCollector{})
+
+	// Export zero values before the first event
+	OpenConnections.WithLabelValues("longpoll")
+	Timeouts.WithLabelValues("turn")
+	Timeouts.WithLabelValues("abandoned")
+}
+
+// Records the time spent in a validation function since start.
+func ObserveValidation(function string, start time.Time) {
+	MoveValidationDuration.WithLabelValues(function).Observe(time.Since(start).Seconds())
+}
+
+var (
+	sessionsDesc = prometheus.NewDesc(namespace+"_sessions_active",
+		"Sessions currently held in memory.", nil, nil)
+	gamesDesc = prometheus.NewDesc(namespace+"_games",
+		"Games by status: waiting for players, active or finished.", []string{"status"}, nil)
+)
+
+// Computes the game gauges from data.GamesMap at scrape time.
+type gamesCollector struct{}
+
+func (gamesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sessionsDesc
+	ch <- gamesDesc
+}
+
+func (gamesCollector) Collect(ch chan<- prometheus.Metric) {
+	counts := map[string]int{"waiting": 0, "active": 0, "finished": 0}
+
+	data.GamesMapMu.RLock()
+	total := len(data.GamesMap)
+	for _, game := range data.GamesMap {
+		game.Mu.RLock()
+		switch {
+		case game.Winner != "n":
+			counts["finished"]++
+		case game.Started:
+			counts["active"]++
+		default:
+			counts["waiting"]++
+		}
+		game.Mu.RUnlock()
+	}
+	data.GamesMapMu.RUnlock()
+
+	ch <- prometheus.MustNewConstMetric(sessionsDesc, prometheus.GaugeValue, float64(total))
+	for status, count := range counts {
+		ch <- prometheus.MustNewConstMetric(gamesDesc, prometheus.GaugeValue, float64(count), status)
+	}
+}
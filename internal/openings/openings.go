@@ -0,0 +1,188 @@
+/*
+Opening suites for matches and tournaments. A suite is read from an EPD or
+FEN file with one position per line:
+
+	rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w - - id "Sicilian";
+
+or from a PGN file of short lines, each game being one opening:
+
+	[Opening "Sicilian"]
+	1. e4 c5 *
+
+Games of a pairing start from the same opening with swapped colors, so
+neither player profits from a lopsided position.
+*/
+package openings
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+)
+
+// An opening line. Positions[0] is the position the line starts from,
+// every further position follows one move. The game continues from the
+// last position.
+type Opening struct {
+	Name      string
+	Positions []gl.BoardState
+}
+
+// Returns the position the game continues from.
+func (o *Opening) Final() gl.BoardState {
+	return o.Positions[len(o.Positions)-1]
+}
+
+// Returns the number of moves of the line.
+func (o *Opening) Plies() int {
+	return len(o.Positions) - 1
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string][]Opening{}
+)
+
+var epdID = regexp.MustCompile(`\bid\s+"([^"]*)"`)
+
+// Reads the openings of an .epd, .fen or .pgn file.
+func LoadFile(path string) ([]Opening, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var suite []Opening
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".epd", ".fen":
+		suite, err = parseEPD(string(content))
+	case ".pgn":
+		suite, err = parsePGN(string(content))
+	default:
+		return nil, errors.New("unsupported file type, use .epd, .fen or .pgn")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening file %s: %w", path, err)
+	}
+	if len(suite) == 0 {
+		return nil, fmt.Errorf("opening file %s: no openings", path)
+	}
+	for i := range suite {
+		final := suite[i].Final()
+		moves := gl.AllPossibleMoves(rune(final.TurnColor[0]), &final, nil)
+		if legal, err := gl.FilterInvalidMoves(moves, &final); err != nil || legal == nil {
+			return nil, fmt.Errorf("opening file %s: opening %q leaves no legal move", path, suite[i].Name)
+		}
+	}
+	return suite, nil
+}
+
+func parseEPD(text string) ([]Opening, error) {
+	var suite []Opening
+	for lineNo, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// Operations like id follow the first four fields, FEN lines end
+		// with the move counters instead
+		fields := strings.Fields(line)
+		bstate, err := gl.FromFEN(strings.Join(fields[:min(4, len(fields))], " "))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo+1, err)
+		}
+		name := fmt.Sprintf("#%d", len(suite)+1)
+		if match := epdID.FindStringSubmatch(line); match != nil {
+			name = match[1]
+		}
+		suite = append(suite, Opening{Name: name, Positions: []gl.BoardState{bstate}})
+	}
+	return suite, nil
+}
+
+func parsePGN(text string) ([]Opening, error) {
+	games, err := gl.ParsePGN(text)
+	if err != nil {
+		return nil, err
+	}
+
+	suite := make([]Opening, 0, len(games))
+	for i, game := range games {
+		name := game.Tags["Opening"]
+		if variation := game.Tags["Variation"]; variation != "" {
+			name = strings.TrimPrefix(name+": "+variation, ": ")
+		}
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+
+		var start gl.BoardState
+		if fen, exists := game.Tags["FEN"]; exists {
+			if start, err = gl.FromFEN(fen); err != nil {
+				return nil, fmt.Errorf("opening %q: %w", name, err)
+			}
+		} else {
+			var boards []gl.BoardState
+			gl.InitializeBoard(&boards)
+			start = boards[0]
+			start.TurnColor = "w"
+		}
+
+		positions := []gl.BoardState{start}
+		for _, san := range game.Moves {
+			current := positions[len(positions)-1]
+			if current.Winner != "n" {
+				return nil, fmt.Errorf("opening %q: moves after the end of the game", name)
+			}
+			move, err := gl.ParseSAN(san, &current)
+			if err != nil {
+				return nil, fmt.Errorf("opening %q: %w", name, err)
+			}
+			next, err := gl.MakeMove(&move, current, true)
+			if err != nil {
+				return nil, fmt.Errorf("opening %q: %w", name, err)
+			}
+			positions = append(positions, next)
+		}
+		suite = append(suite, Opening{Name: name, Positions: positions})
+	}
+	return suite, nil
+}
+
+// Makes a suite available under a name. Registering a name again replaces
+// the previous suite.
+func Register(name string, suite []Opening) {
+	registryMu.Lock()
+	registry[name] = suite
+	registryMu.Unlock()
+}
+
+// Returns the suite registered under name.
+func Lookup(name string) ([]Opening, error) {
+	registryMu.RLock()
+	suite, exists := registry[name]
+	registryMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown opening suite %q", name)
+	}
+	return suite, nil
+}
+
+// Returns the sorted names of all registered suites.
+func Names() []string {
+	registryMu.RLock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	registryMu.RUnlock()
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,82 @@
+/*
+Unittest for the openings package.
+*/
+package openings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadEPD(t *testing.T) {
+	path := writeFile(t, "suite.epd", `# Two openings
+rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w - - id "Sicilian";
+rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w - - 0 2
+`)
+	suite, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suite) != 2 || suite[0].Name != "Sicilian" || suite[1].Name != "#2" {
+		t.Fatalf("unexpected suite %+v", suite)
+	}
+	if final := suite[0].Final(); suite[0].Plies() != 0 || final.TurnColor != "w" || final.Board[3][2] != 'P' {
+		t.Errorf("unexpected position %+v", final)
+	}
+}
+
+func TestLoadPGN(t *testing.T) {
+	path := writeFile(t, "suite.pgn", `[Opening "French"]
+[Variation "Advance"]
+1. e4 e6 2. d4 d5 3. e5 *
+
+1. d4 d5 *
+`)
+	suite, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suite) != 2 || suite[0].Name != "French: Advance" || suite[1].Name != "#2" {
+		t.Fatalf("unexpected suite %+v", suite)
+	}
+	if final := suite[0].Final(); suite[0].Plies() != 5 || final.TurnColor != "b" || final.Board[3][4] != 'p' {
+		t.Errorf("unexpected position after the French %+v", final)
+	}
+	if start := suite[0].Positions[0]; start.TurnColor != "w" || start.Board[6][4] != 'p' {
+		t.Errorf("line should start from the start position, got %+v", start)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"illegal.pgn":  "1. e4 e4 *\n",
+		"castling.pgn": "1. e4 e5 2. Nf3 Nc6 3. Bc4 Bc5 4. O-O *\n",
+		"mate.pgn":     "1. f3 e5 2. g4 Qh4# *\n",
+		"invalid.epd":  "rnbqkbnr/pppppppp/8 w - -\n",
+		"empty.epd":    "\n",
+		"suite.txt":    "1. e4 *\n",
+	} {
+		if _, err := LoadFile(writeFile(t, name, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	Register("test", []Opening{{Name: "a"}})
+	if suite, err := Lookup("test"); err != nil || len(suite) != 1 {
+		t.Errorf("expected the registered suite, got %v %v", suite, err)
+	}
+	if _, err := Lookup("missing"); err == nil {
+		t.Error("expected an error for an unknown suite")
+	}
+}
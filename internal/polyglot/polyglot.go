@@ -0,0 +1,250 @@
+/*
+Reader for opening books in the Polyglot .bin format. A book is a sorted
+list of 16 byte entries: the Zobrist key of a position, a move, its weight
+and learning data, all big-endian. Moves of a position are found by binary
+search on the key.
+
+The game logic knows neither castling nor en passant captures. Castling
+rights are derived from unmoved kings and rooks on their start squares, so
+that keys match those of real games, and book moves that castle or capture
+en passant are dropped.
+*/
+package polyglot
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+)
+
+const entrySize = 16
+
+// Offsets of the castling, en passant and turn numbers in random64.
+const (
+	castleOffset    = 768
+	enPassantOffset = 772
+	turnOffset      = 780
+)
+
+// Kinds of pieces in the order of random64: black pawn, white pawn, black
+// knight and so on.
+var pieceKinds = map[rune]int{
+	'P': 0, 'p': 1,
+	'K': 2, 'k': 3,
+	'B': 4, 'b': 5,
+	'R': 6, 'r': 7,
+	'Q': 8, 'q': 9,
+	'X': 10, 'x': 11,
+}
+
+// Promotion pieces by their number in a book move.
+var promotions = []rune{0, 'k', 'b', 'r', 'q'}
+
+// A book move with its weight. Weights are relative to the other moves of
+// the position, usually derived from how often and how well it was played.
+type Entry struct {
+	Move   gl.Move
+	Weight uint16
+}
+
+// An opening book held in memory.
+type Book struct {
+	entries []byte
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]*Book{}
+)
+
+// Reads a Polyglot .bin book.
+func LoadFile(path string) (*Book, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) == 0 || len(content)%entrySize != 0 {
+		return nil, fmt.Errorf("book %s: size is not a positive multiple of %d bytes", path, entrySize)
+	}
+	book := &Book{entries: content}
+	for i := 1; i < book.Len(); i++ {
+		if book.key(i-1) > book.key(i) {
+			return nil, fmt.Errorf("book %s: entries are not sorted by key", path)
+		}
+	}
+	return book, nil
+}
+
+// Returns the number of entries of the book.
+func (b *Book) Len() int {
+	return len(b.entries) / entrySize
+}
+
+func (b *Book) key(i int) uint64 {
+	return binary.BigEndian.Uint64(b.entries[i*entrySize:])
+}
+
+// Returns the legal book moves of the side to move, the most weighted
+// first. Moves the game logic doesn't support are left out.
+func (b *Book) Moves(pos *gl.BoardState) ([]Entry, error) {
+	if pos.TurnColor != "w" && pos.TurnColor != "b" {
+		return nil, nil
+	}
+	key := Key(pos)
+	first := sort.Search(b.Len(), func(i int) bool { return b.key(i) >= key })
+
+	moves := gl.AllPossibleMoves(rune(pos.TurnColor[0]), pos, nil)
+	legal, err := gl.FilterInvalidMoves(moves, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for i := first; i < b.Len() && b.key(i) == key; i++ {
+		raw := b.entries[i*entrySize:]
+		move, ok := decodeMove(binary.BigEndian.Uint16(raw[8:]), pos)
+		if !ok || !gl.IsMoveInMoves(&move, legal) {
+			continue
+		}
+		entries = append(entries, Entry{Move: move, Weight: binary.BigEndian.Uint16(raw[10:])})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Weight > entries[j].Weight })
+	return entries, nil
+}
+
+// Chooses a book move at random, weighted by the weights of the moves.
+// Returns false if the position is not in the book.
+func (b *Book) Pick(pos *gl.BoardState) (gl.Move, bool, error) {
+	entries, err := b.Moves(pos)
+	if err != nil {
+		return gl.Move{}, false, err
+	}
+	total := 0
+	for _, entry := range entries {
+		total += int(entry.Weight)
+	}
+	if total == 0 {
+		// Moves without weight are only played if nothing else is known
+		if len(entries) == 0 {
+			return gl.Move{}, false, nil
+		}
+		return entries[rand.Intn(len(entries))].Move, true, nil
+	}
+	r := rand.Intn(total)
+	for _, entry := range entries {
+		if r < int(entry.Weight) {
+			return entry.Move, true, nil
+		}
+		r -= int(entry.Weight)
+	}
+	return entries[len(entries)-1].Move, true, nil
+}
+
+// Decodes a book move: bits 0-5 are the target square, bits 6-11 the
+// origin and bits 12-14 the promotion piece. Squares count from a1 to h8.
+// Castling is encoded as the king capturing its rook and not supported.
+func decodeMove(raw uint16, pos *gl.BoardState) (gl.Move, bool) {
+	square := func(bits uint16) [2]int {
+		return [2]int{7 - int(bits>>3&7), int(bits & 7)}
+	}
+	move := gl.Move{
+		From:  square(raw >> 6),
+		To:    square(raw),
+		Color: rune(pos.TurnColor[0]),
+	}
+	promotion := int(raw >> 12 & 7)
+	if promotion >= len(promotions) {
+		return gl.Move{}, false
+	}
+	move.Promotion = promotions[promotion]
+	move.Capture = pos.Board[move.To[0]][move.To[1]] != gl.Empty
+	return move, true
+}
+
+// Returns the Polyglot Zobrist key of a position.
+func Key(pos *gl.BoardState) uint64 {
+	var key uint64
+	for row := range 8 {
+		for col := range 8 {
+			if kind, exists := pieceKinds[pos.Board[row][col]]; exists {
+				// Polyglot counts ranks from the white side
+				key ^= random64[64*kind+8*(7-row)+col]
+			}
+		}
+	}
+
+	for i, right := range castlingRights(pos) {
+		if right {
+			key ^= random64[castleOffset+i]
+		}
+	}
+
+	// The en passant file only counts if a pawn of the side to move could
+	// capture, even though the game logic doesn't allow it
+	if ep := pos.EnPassant; ep[0] >= 0 && (pos.TurnColor == "w" || pos.TurnColor == "b") {
+		pawn := 'p'
+		if pos.TurnColor == "b" {
+			pawn = 'P'
+		}
+		for _, col := range []int{ep[1] - 1, ep[1] + 1} {
+			if col >= 0 && col < 8 && pos.Board[ep[0]][col] == pawn {
+				key ^= random64[enPassantOffset+ep[1]]
+				break
+			}
+		}
+	}
+
+	if pos.TurnColor == "w" {
+		key ^= random64[turnOffset]
+	}
+	return key
+}
+
+// Returns white short, white long, black short and black long castling
+// rights, assuming rooks on their start squares haven't moved.
+func castlingRights(pos *gl.BoardState) [4]bool {
+	white := !pos.WhiteKingMoved && pos.Board[7][4] == 'x'
+	black := !pos.BlackKingMoved && pos.Board[0][4] == 'X'
+	return [4]bool{
+		white && pos.Board[7][7] == 'r',
+		white && pos.Board[7][0] == 'r',
+		black && pos.Board[0][7] == 'R',
+		black && pos.Board[0][0] == 'R',
+	}
+}
+
+// Makes a book available under a name. Registering a name again replaces
+// the previous book.
+func Register(name string, book *Book) {
+	registryMu.Lock()
+	registry[name] = book
+	registryMu.Unlock()
+}
+
+// Returns the book registered under name.
+func Lookup(name string) (*Book, error) {
+	registryMu.RLock()
+	book, exists := registry[name]
+	registryMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown book %q", name)
+	}
+	return book, nil
+}
+
+// Returns the sorted names of all registered books.
+func Names() []string {
+	registryMu.RLock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	registryMu.RUnlock()
+	sort.Strings(names)
+	return names
+}
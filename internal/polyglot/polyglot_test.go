@@ -0,0 +1,122 @@
+/*
+Unittest for the polyglot package.
+*/
+package polyglot
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+)
+
+func startPosition() gl.BoardState {
+	var boards []gl.BoardState
+	gl.InitializeBoard(&boards)
+	pos := boards[0]
+	pos.TurnColor = "w"
+	return pos
+}
+
+func play(t *testing.T, pos gl.BoardState, moves ...string) gl.BoardState {
+	for _, m := range moves {
+		move, err := gl.StringToMoveStruct(m, rune(pos.TurnColor[0]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos, err = gl.MakeMove(&move, pos, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return pos
+}
+
+func TestKey(t *testing.T) {
+	// Examples of the Polyglot format description
+	cases := []struct {
+		moves []string
+		key   uint64
+	}{
+		{nil, 0x463b96181691fc9c},
+		{[]string{"e2 e4"}, 0x823c9b50fd114196},
+		{[]string{"e2 e4", "d7 d5"}, 0x0756b94461c50fb0},
+		{[]string{"e2 e4", "d7 d5", "e4 e5"}, 0x662fafb965db29d4},
+		{[]string{"e2 e4", "d7 d5", "e4 e5", "f7 f5"}, 0x22a48b5a8e47ff78},
+		{[]string{"e2 e4", "d7 d5", "e4 e5", "f7 f5", "e1 e2"}, 0x652a607ca3f242c1},
+		{[]string{"e2 e4", "d7 d5", "e4 e5", "f7 f5", "e1 e2", "e8 f7"}, 0x00fdd303c946bdd9},
+		{[]string{"a2 a4", "b7 b5", "h2 h4", "b5 b4", "c2 c4"}, 0x3c8123ea7b067637},
+	}
+	for _, c := range cases {
+		pos := play(t, startPosition(), c.moves...)
+		if key := Key(&pos); key != c.key {
+			t.Errorf("%v: expected key %016x, got %016x", c.moves, c.key, key)
+		}
+	}
+}
+
+// Writes a book with the given moves of positions, encoded as
+// "from to weight" for readability.
+func writeBook(t *testing.T, entries map[uint64][][3]uint16) string {
+	var keys []uint64
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	var content []byte
+	for _, key := range keys {
+		for _, e := range entries[key] {
+			entry := make([]byte, entrySize)
+			binary.BigEndian.PutUint64(entry, key)
+			binary.BigEndian.PutUint16(entry[8:], e[0]<<6|e[1])
+			binary.BigEndian.PutUint16(entry[10:], e[2])
+			content = append(content, entry...)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "book.bin")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBook(t *testing.T) {
+	// Squares count from a1 = 0 to h8 = 63
+	const e1, g1, h1, d2, e2, d4, e4, f3 = 4, 6, 7, 11, 12, 27, 28, 21
+	start := startPosition()
+	path := writeBook(t, map[uint64][][3]uint16{
+		Key(&start): {{d2, d4, 10}, {e2, e4, 30}, {e1, h1, 5}, {e2, e1, 100}},
+		1:           {{g1, f3, 1}},
+	})
+	book, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Castling and illegal moves are dropped
+	entries, err := book.Moves(&start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || gl.MoveToString(&entries[0].Move) != "e2 e4" || entries[0].Weight != 30 || gl.MoveToString(&entries[1].Move) != "d2 d4" {
+		t.Errorf("unexpected book moves %+v", entries)
+	}
+
+	for range 20 {
+		move, found, err := book.Pick(&start)
+		if err != nil || !found || (gl.MoveToString(&move) != "e2 e4" && gl.MoveToString(&move) != "d2 d4") {
+			t.Fatalf("unexpected pick %v %v %v", move, found, err)
+		}
+	}
+	after := play(t, start, "e2 e4")
+	if _, found, _ := book.Pick(&after); found {
+		t.Error("position after e2 e4 is not in the book")
+	}
+
+	if _, err := LoadFile(writeBook(t, nil)); err == nil {
+		t.Error("expected an error for an empty book")
+	}
+}
@@ -0,0 +1,85 @@
+/*
+The Glicko-2 rating system, see http://www.glicko.net/glicko/glicko2.pdf
+*/
+package ratings
+
+import "math"
+
+const (
+	glickoScale  = 173.7178 // Between the Glicko and the Glicko-2 scale
+	maxDeviation = 350
+	tau          = 0.5 // Constrains the change of the volatility
+	convergence  = 0.000001
+)
+
+// A game of a rating period from the view of the rated player.
+type result struct {
+	opponent Rating
+	score    float64
+}
+
+// Returns the rating after a rating period with the given games.
+func update(r Rating, results []result) Rating {
+	mu := (r.Rating - 1500) / glickoScale
+	phi := r.Deviation / glickoScale
+	sigma := r.Volatility
+
+	var vInv, sum float64
+	for _, res := range results {
+		muJ := (res.opponent.Rating - 1500) / glickoScale
+		g := gFactor(res.opponent.Deviation / glickoScale)
+		e := 1 / (1 + math.Exp(-g*(mu-muJ)))
+		vInv += g * g * e * (1 - e)
+		sum += g * (res.score - e)
+	}
+	v := 1 / vInv
+	delta := v * sum
+
+	sigma = volatility(phi, sigma, v, delta)
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	phi = 1 / math.Sqrt(1/(phiStar*phiStar)+1/v)
+	mu += phi * phi * sum
+
+	r.Rating = mu*glickoScale + 1500
+	r.Deviation = min(phi*glickoScale, maxDeviation)
+	r.Volatility = sigma
+	return r
+}
+
+func gFactor(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+// Finds the new volatility with the Illinois algorithm (step 5 of the paper).
+func volatility(phi, sigma, v, delta float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + v + ex
+		return ex*(delta*delta-d)/(2*d*d) - (x-a)/(tau*tau)
+	}
+
+	A := a
+	var B float64
+	if delta*delta > phi*phi+v {
+		B = math.Log(delta*delta - phi*phi - v)
+	} else {
+		k := 1.0
+		for f(a-k*tau) < 0 {
+			k++
+		}
+		B = a - k*tau
+	}
+	fA, fB := f(A), f(B)
+	for math.Abs(B-A) > convergence {
+		C := A + (A-B)*fA/(fB-fA)
+		fC := f(C)
+		if fC*fB <= 0 {
+			A, fA = B, fB
+		} else {
+			fA /= 2
+		}
+		B, fB = C, fC
+	}
+	return math.Exp(A / 2)
+}
@@ -0,0 +1,154 @@
+/*
+Glicko-2 ratings of the players of rated games. A player is identified by
+a name that persists across sessions: clients are rated under the name of
+their account, built-in engines and bots as "engine:<name>" and
+"bot:<name>". Names without an account are not authenticated and the API
+keeps them out of rated games.
+
+Ratings are updated after every rated game, each game being a rating
+period of its own.
+*/
+package ratings
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+)
+
+// Rating of a player.
+type Rating struct {
+	Player     string    `json:"player"`
+	Rating     float64   `json:"rating"`
+	Deviation  float64   `json:"deviation"`
+	Volatility float64   `json:"volatility"`
+	Games      int       `json:"games"`
+	Wins       int       `json:"wins"`
+	Draws      int       `json:"draws"`
+	Losses     int       `json:"losses"`
+	LastGame   time.Time `json:"lastgame"`
+}
+
+// Rating of a player without games.
+func Initial(player string) Rating {
+	return Rating{Player: player, Rating: 1500, Deviation: maxDeviation, Volatility: 0.06}
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]*Rating{}
+)
+
+var validName = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// Checks a player name chosen by a client. The prefixes of engines and
+// bots can't be used.
+func ValidName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid player name %q, use up to 64 letters, digits, '_', '.' or '-'", name)
+	}
+	return nil
+}
+
+// Player name of a built-in engine.
+func EngineID(name string) string {
+	return "engine:" + name
+}
+
+// Player name of a bot of the bots file.
+func BotID(name string) string {
+	return "bot:" + name
+}
+
+// Returns the rating of a player.
+func Get(player string) (Rating, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	r, exists := registry[player]
+	if !exists {
+		return Rating{}, false
+	}
+	return *r, true
+}
+
+// Returns all ratings, highest first.
+func Leaderboard() []Rating {
+	registryMu.RLock()
+	list := make([]Rating, 0, len(registry))
+	for _, r := range registry {
+		list = append(list, *r)
+	}
+	registryMu.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Rating != list[j].Rating {
+			return list[i].Rating > list[j].Rating
+		}
+		return list[i].Player < list[j].Player
+	})
+	return list
+}
+
+// Updates the ratings of both players with a game result. score is the
+// score of white: 1 for a win, 0.5 for a draw and 0 for a loss.
+func Record(white, black string, score float64, at time.Time) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	w, b := player(white), player(black)
+	newW := update(*w, []result{{*b, score}})
+	newB := update(*b, []result{{*w, 1 - score}})
+	*w, *b = newW, newB
+	for _, r := range []*Rating{w, b} {
+		r.Games++
+		r.LastGame = at
+	}
+	switch score {
+	case 1:
+		w.Wins++
+		b.Losses++
+	case 0:
+		w.Losses++
+		b.Wins++
+	default:
+		w.Draws++
+		b.Draws++
+	}
+}
+
+// Returns the rating of a player, creating it if needed. Called with
+// registryMu held.
+func player(name string) *Rating {
+	r, exists := registry[name]
+	if !exists {
+		initial := Initial(name)
+		r = &initial
+		registry[name] = r
+	}
+	return r
+}
+
+// Counts the result of a rated game once it has ended. Games that are
+// casual, unfinished, already counted or without both player names are
+// ignored. Called with game.Mu held for writing.
+func RecordGame(game *data.Game) {
+	if !game.Rated || game.RatingRecorded || game.Winner == "n" {
+		return
+	}
+	if game.WPlayer == "" || game.BPlayer == "" || game.WPlayer == game.BPlayer {
+		return
+	}
+	game.RatingRecorded = true
+
+	score := 0.5
+	switch game.Winner {
+	case "w":
+		score = 1
+	case "b":
+		score = 0
+	}
+	Record(game.WPlayer, game.BPlayer, score, time.Now())
+}
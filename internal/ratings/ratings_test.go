@@ -0,0 +1,105 @@
+/*
+Unittest for the ratings package.
+*/
+package ratings
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+)
+
+func reset() {
+	registryMu.Lock()
+	registry = map[string]*Rating{}
+	registryMu.Unlock()
+}
+
+func TestUpdatePaperExample(t *testing.T) {
+	// Example of the Glicko-2 paper
+	player := Rating{Rating: 1500, Deviation: 200, Volatility: 0.06}
+	results := []result{
+		{Rating{Rating: 1400, Deviation: 30}, 1},
+		{Rating{Rating: 1550, Deviation: 100}, 0},
+		{Rating{Rating: 1700, Deviation: 300}, 0},
+	}
+	got := update(player, results)
+	if math.Abs(got.Rating-1464.06) > 0.01 || math.Abs(got.Deviation-151.52) > 0.01 || math.Abs(got.Volatility-0.05999) > 0.00001 {
+		t.Errorf("expected 1464.06 / 151.52 / 0.05999, got %.2f / %.2f / %.5f", got.Rating, got.Deviation, got.Volatility)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	reset()
+	now := time.Now()
+	Record("a", "b", 1, now)
+	Record("b", "a", 0.5, now)
+
+	a, _ := Get("a")
+	b, _ := Get("b")
+	if a.Games != 2 || a.Wins != 1 || a.Draws != 1 || b.Losses != 1 || b.Draws != 1 {
+		t.Errorf("unexpected counts %+v %+v", a, b)
+	}
+	if a.Rating <= 1500 || b.Rating >= 1500 || math.Abs(a.Rating-1500-(1500-b.Rating)) > 0.01 {
+		t.Errorf("expected symmetric ratings around 1500, got %.2f and %.2f", a.Rating, b.Rating)
+	}
+	if a.Deviation >= maxDeviation {
+		t.Errorf("deviation should shrink with games, got %.2f", a.Deviation)
+	}
+	if board := Leaderboard(); len(board) != 2 || board[0].Player != "a" {
+		t.Errorf("unexpected leaderboard %+v", board)
+	}
+}
+
+func TestRecordGame(t *testing.T) {
+	reset()
+	game := &data.Game{Rated: true, Winner: "b", WPlayer: "alice", BPlayer: BotID("mybot")}
+	RecordGame(game)
+	RecordGame(game)
+	if r, _ := Get(BotID("mybot")); r.Games != 1 || r.Wins != 1 || !game.RatingRecorded {
+		t.Errorf("game should be counted once, got %+v", r)
+	}
+
+	for _, skipped := range []*data.Game{
+		{Rated: false, Winner: "w", WPlayer: "x", BPlayer: "y"},
+		{Rated: true, Winner: "n", WPlayer: "x", BPlayer: "y"},
+		{Rated: true, Winner: "w", WPlayer: "x"},
+		{Rated: true, Winner: "w", WPlayer: "x", BPlayer: "x"},
+	} {
+		RecordGame(skipped)
+	}
+	if _, exists := Get("x"); exists {
+		t.Error("casual, unfinished, anonymous and self-play games should not be rated")
+	}
+}
+
+func TestValidName(t *testing.T) {
+	for _, name := range []string{"alice", "bot_v2.1", "A-1"} {
+		if err := ValidName(name); err != nil {
+			t.Errorf("%q should be valid: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "bot:mybot", "engine:random", "a b"} {
+		if ValidName(name) == nil {
+			t.Errorf("%q should be invalid", name)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	reset()
+	Record("a", "b", 0, time.Now())
+	dir := t.TempDir()
+	if err := SaveSnapshot(dir); err != nil {
+		t.Fatal(err)
+	}
+	reset()
+	if n, err := LoadSnapshot(dir); err != nil || n != 2 {
+		t.Fatalf("expected 2 restored ratings, got %d %v", n, err)
+	}
+	if b, _ := Get("b"); b.Wins != 1 || b.Rating <= 1500 {
+		t.Errorf("unexpected restored rating %+v", b)
+	}
+}
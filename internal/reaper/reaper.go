@@ -0,0 +1,180 @@
+/*
+Background cleanup of finished, abandoned and never started sessions.
+*/
+package reaper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+	"github.com/matetirpak/chessbot-playground-server/internal/metrics"
+)
+
+const (
+	ActionDelete  = "delete"
+	ActionArchive = "archive"
+)
+
+// Policy configures which sessions the reaper removes.
+// A zero duration disables the corresponding rule.
+//
+// UnstartedTTL applies to sessions nobody joined, HalfJoinedTTL to sessions
+// whose first player still waits for an opponent. Both count from creation.
+//
+// FinishedAction:
+//   - "delete": finished games are dropped
+//   - "archive": finished games are written to ArchiveDir before being dropped
+type Policy struct {
+	Interval          time.Duration
+	FinishedRetention time.Duration
+	FinishedAction    string
+	ArchiveDir        string
+	UnstartedTTL      time.Duration
+	HalfJoinedTTL     time.Duration
+	AbandonAfter      time.Duration
+}
+
+// Summary of a single sweep.
+type Stats struct {
+	Deleted   int
+	Archived  int
+	Expired   int
+	Abandoned int
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		Interval:          time.Minute,
+		FinishedRetention: 24 * time.Hour,
+		FinishedAction:    ActionDelete,
+		UnstartedTTL:      time.Hour,
+		HalfJoinedTTL:     24 * time.Hour,
+		AbandonAfter:      2 * time.Hour,
+	}
+}
+
+// Runs a sweep every policy interval until the context is cancelled.
+func Run(ctx context.Context, policy Policy) {
+	if policy.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			stats := Sweep(now, policy)
+			if stats != (Stats{}) {
+				slog.Info("reaper sweep finished",
+					"deleted", stats.Deleted,
+					"archived", stats.Archived,
+					"expired", stats.Expired,
+					"abandoned", stats.Abandoned,
+				)
+			}
+		}
+	}
+}
+
+// Applies the policy once to all games in data.GamesMap.
+func Sweep(now time.Time, policy Policy) Stats {
+	var stats Stats
+
+	data.GamesMapMu.RLock()
+	games := make([]*data.Game, 0, len(data.GamesMap))
+	for _, game := range data.GamesMap {
+		games = append(games, game)
+	}
+	data.GamesMapMu.RUnlock()
+
+	for _, game := range games {
+		game.Mu.RLock()
+		started := game.Started
+		joined := game.HasWPlayer || game.HasBPlayer
+		finished := game.Winner != "n"
+		idle := now.Sub(game.LastActivity)
+		age := now.Sub(game.CreatedAt)
+		game.Mu.RUnlock()
+
+		switch {
+		case finished:
+			if policy.FinishedRetention <= 0 || idle < policy.FinishedRetention {
+				continue
+			}
+			if policy.FinishedAction == ActionArchive {
+				if err := archiveGame(game, policy.ArchiveDir); err != nil {
+					slog.Error("reaper failed to archive game", "boardid", game.ID, "error", err)
+					continue
+				}
+				stats.Archived++
+			} else {
+				stats.Deleted++
+			}
+			data.RemoveGame(game.ID)
+
+		case !started:
+			ttl := policy.UnstartedTTL
+			if joined {
+				ttl = policy.HalfJoinedTTL
+			}
+			if ttl <= 0 || age < ttl {
+				continue
+			}
+			data.RemoveGame(game.ID)
+			stats.Expired++
+
+		default:
+			if policy.AbandonAfter <= 0 || idle < policy.AbandonAfter {
+				continue
+			}
+			if abandonGame(game, now) {
+				metrics.Timeouts.WithLabelValues("abandoned").Inc()
+				stats.Abandoned++
+			}
+		}
+	}
+	return stats
+}
+
+// Ends a stalled game. The player who failed to move loses.
+func abandonGame(game *data.Game, now time.Time) bool {
+	game.Mu.Lock()
+	defer game.Mu.Unlock()
+
+	winner := "r"
+	switch game.BoardData[len(game.BoardData)-1].TurnColor {
+	case "w":
+		winner = "b"
+	case "b":
+		winner = "w"
+	}
+	return data.EndGame(game, winner, "abandoned", now)
+}
+
+func archiveGame(game *data.Game, dir string) error {
+	if dir == "" {
+		return fmt.Errorf("no archive directory configured")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	game.Mu.RLock()
+	encoded, err := json.MarshalIndent(game, "", "  ")
+	game.Mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("game-%d.json", game.ID))
+	return os.WriteFile(path, encoded, 0o644)
+}
@@ -0,0 +1,131 @@
+/*
+Unittest for the reaper package.
+*/
+package reaper
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+	"github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+)
+
+func addGame(t *testing.T, id int32, started bool, winner string, created, lastActivity time.Time) *data.Game {
+	t.Helper()
+	game := &data.Game{
+		ID:           id,
+		Started:      started,
+		Winner:       winner,
+		CreatedAt:    created,
+		LastActivity: lastActivity,
+		Done:         make(chan struct{}),
+	}
+	game_logic.InitializeBoard(&game.BoardData)
+	if started {
+		game.BoardData[0].TurnColor = "w"
+	}
+	data.GamesMapMu.Lock()
+	data.GamesMap[id] = game
+	data.GamesMapMu.Unlock()
+	t.Cleanup(func() { data.RemoveGame(id) })
+	return game
+}
+
+func gameExists(id int32) bool {
+	data.GamesMapMu.RLock()
+	defer data.GamesMapMu.RUnlock()
+	_, exists := data.GamesMap[id]
+	return exists
+}
+
+func TestSweep(t *testing.T) {
+	now := time.Now()
+	policy := Policy{
+		FinishedRetention: time.Hour,
+		FinishedAction:    ActionDelete,
+		UnstartedTTL:      time.Hour,
+		HalfJoinedTTL:     3 * time.Hour,
+		AbandonAfter:      time.Hour,
+	}
+
+	oldFinished := addGame(t, 1, true, "w", now.Add(-3*time.Hour), now.Add(-2*time.Hour))
+	addGame(t, 2, true, "b", now.Add(-3*time.Hour), now.Add(-time.Minute))
+	addGame(t, 3, false, "n", now.Add(-2*time.Hour), now.Add(-2*time.Hour))
+	addGame(t, 4, false, "n", now.Add(-time.Minute), now.Add(-time.Minute))
+	halfJoined := addGame(t, 7, false, "n", now.Add(-2*time.Hour), now.Add(-2*time.Hour))
+	halfJoined.HasWPlayer = true
+	stalled := addGame(t, 5, true, "n", now.Add(-3*time.Hour), now.Add(-2*time.Hour))
+	addGame(t, 6, true, "n", now.Add(-3*time.Hour), now.Add(-time.Minute))
+
+	defer func(hook func(*data.Game)) { data.OnGameEnd = hook }(data.OnGameEnd)
+	var ended []int32
+	data.OnGameEnd = func(game *data.Game) { ended = append(ended, game.ID) }
+
+	stats := Sweep(now, policy)
+	expected := Stats{Deleted: 1, Expired: 1, Abandoned: 1}
+	if stats != expected {
+		t.Errorf("expected stats %+v, got %+v", expected, stats)
+	}
+
+	for id, exists := range map[int32]bool{1: false, 2: true, 3: false, 4: true, 5: true, 6: true, 7: true} {
+		if gameExists(id) != exists {
+			t.Errorf("game %d: expected exists=%v", id, exists)
+		}
+	}
+
+	select {
+	case <-oldFinished.Done:
+	default:
+		t.Errorf("long-polls of a reaped game were not woken")
+	}
+
+	if stalled.Winner != "b" || stalled.Termination != "abandoned" {
+		t.Errorf("expected stalled game to be abandoned in favour of black, got winner %q termination %q",
+			stalled.Winner, stalled.Termination)
+	}
+	if turn := stalled.BoardData[len(stalled.BoardData)-1].TurnColor; turn != "n" {
+		t.Errorf("expected no turn after abandonment, got %q", turn)
+	}
+
+	// The game end hook runs once, a later sweep doesn't end the game again
+	Sweep(now, policy)
+	if len(ended) != 1 || ended[0] != 5 {
+		t.Errorf("expected the game end hook to run once for game 5, got %v", ended)
+	}
+}
+
+func TestSweepArchive(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+	policy := Policy{
+		FinishedRetention: time.Hour,
+		FinishedAction:    ActionArchive,
+		ArchiveDir:        dir,
+	}
+
+	addGame(t, 11, true, "r", now.Add(-3*time.Hour), now.Add(-2*time.Hour))
+
+	stats := Sweep(now, policy)
+	if stats.Archived != 1 {
+		t.Fatalf("expected one archived game, got %+v", stats)
+	}
+	if gameExists(11) {
+		t.Errorf("archived game is still in GamesMap")
+	}
+
+	encoded, err := os.ReadFile(filepath.Join(dir, "game-11.json"))
+	if err != nil {
+		t.Fatalf("archive file missing: %v", err)
+	}
+	var archived data.Game
+	if err := json.Unmarshal(encoded, &archived); err != nil {
+		t.Fatalf("archive file is not valid JSON: %v", err)
+	}
+	if archived.ID != 11 || archived.Winner != "r" {
+		t.Errorf("unexpected archived game: id %d winner %q", archived.ID, archived.Winner)
+	}
+}
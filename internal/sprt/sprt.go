@@ -0,0 +1,132 @@
+/*
+Sequential probability ratio test (SPRT) for matches between two players,
+the way engine developers test changes. Games are played in pairs with
+swapped colors, each pair scores 0, 0.5, 1, 1.5 or 2 points for the tested
+player. The test compares the hypotheses that the Elo difference is elo0
+(H0) or elo1 (H1) with the generalized SPRT on these pentanomial counts
+and stops once the log-likelihood ratio leaves the bounds given by the
+error rates alpha and beta.
+*/
+package sprt
+
+import (
+	"errors"
+	"math"
+)
+
+// Hypotheses and error rates of a test. Elo is logistic Elo.
+type Bounds struct {
+	Elo0  float64 `json:"elo0"`
+	Elo1  float64 `json:"elo1"`
+	Alpha float64 `json:"alpha"` // Probability of accepting H1 if H0 holds
+	Beta  float64 `json:"beta"`  // Probability of accepting H0 if H1 holds
+}
+
+// Bounds used if a match doesn't set them.
+var DefaultBounds = Bounds{Elo0: 0, Elo1: 5, Alpha: 0.05, Beta: 0.05}
+
+func (b *Bounds) Validate() error {
+	switch {
+	case b.Elo0 >= b.Elo1:
+		return errors.New("elo0 has to be lower than elo1")
+	case b.Alpha <= 0 || b.Alpha >= 0.5 || b.Beta <= 0 || b.Beta >= 0.5:
+		return errors.New("alpha and beta have to be between 0 and 0.5")
+	}
+	return nil
+}
+
+// Log-likelihood ratio below which H0 is accepted.
+func (b *Bounds) Lower() float64 {
+	return math.Log(b.Beta / (1 - b.Alpha))
+}
+
+// Log-likelihood ratio above which H1 is accepted.
+func (b *Bounds) Upper() float64 {
+	return math.Log((1 - b.Beta) / b.Alpha)
+}
+
+type Decision string
+
+const (
+	Continue Decision = ""
+	AcceptH0 Decision = "H0"
+	AcceptH1 Decision = "H1"
+)
+
+// Numbers of game pairs by the points of the tested player:
+// Pentanomial[i] pairs scored i/2 points.
+type Pentanomial [5]int
+
+// Adds a pair with the given points of the tested player.
+func (p *Pentanomial) Add(points float64) {
+	p[int(math.Round(points*2))]++
+}
+
+func (p *Pentanomial) Pairs() int {
+	return p[0] + p[1] + p[2] + p[3] + p[4]
+}
+
+// State of a test.
+type Result struct {
+	Pentanomial Pentanomial `json:"pentanomial"`
+	LLR         float64     `json:"llr"`
+	Lower       float64     `json:"lower"`
+	Upper       float64     `json:"upper"`
+	Decision    Decision    `json:"decision"`
+	Elo         float64     `json:"elo"`      // Estimated Elo difference of the tested player
+	EloError    float64     `json:"eloerror"` // Half width of the 95% confidence interval
+}
+
+// Evaluates the test on the pairs played so far.
+func Evaluate(bounds Bounds, penta Pentanomial) Result {
+	result := Result{Pentanomial: penta, Lower: bounds.Lower(), Upper: bounds.Upper()}
+	if penta.Pairs() == 0 {
+		return result
+	}
+
+	n, mean, variance := stats(penta)
+	s0, s1 := score(bounds.Elo0), score(bounds.Elo1)
+	result.LLR = n * (s1 - s0) * (2*mean - s0 - s1) / (2 * variance)
+	switch {
+	case result.LLR >= result.Upper:
+		result.Decision = AcceptH1
+	case result.LLR <= result.Lower:
+		result.Decision = AcceptH0
+	}
+
+	margin := 1.959964 * math.Sqrt(variance/n)
+	result.Elo = elo(mean)
+	result.EloError = (elo(mean+margin) - elo(mean-margin)) / 2
+	return result
+}
+
+// Returns the number of pairs, the mean score per game and the variance
+// of the score of a pair. Every outcome counts half a pair more than it
+// occurred, so that a few lucky pairs don't decide the test and the
+// variance of e.g. only drawn pairs isn't zero.
+func stats(penta Pentanomial) (n, mean, variance float64) {
+	var counts [5]float64
+	for i, c := range penta {
+		counts[i] = float64(c) + 0.5
+		n += counts[i]
+	}
+	for i, c := range counts {
+		mean += c / n * float64(i) / 4
+	}
+	for i, c := range counts {
+		d := float64(i)/4 - mean
+		variance += c / n * d * d
+	}
+	return n, mean, variance
+}
+
+// Expected score at an Elo difference.
+func score(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
+
+// Elo difference of an expected score, clamped to avoid infinity.
+func elo(score float64) float64 {
+	score = min(max(score, 1e-6), 1-1e-6)
+	return -400 * math.Log10(1/score-1)
+}
@@ -0,0 +1,68 @@
+/*
+Unittest for the sprt package.
+*/
+package sprt
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBounds(t *testing.T) {
+	b := DefaultBounds
+	if err := b.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(b.Lower()+2.944) > 0.001 || math.Abs(b.Upper()-2.944) > 0.001 {
+		t.Errorf("unexpected bounds %.3f %.3f", b.Lower(), b.Upper())
+	}
+	for _, invalid := range []Bounds{{Elo0: 5, Elo1: 0, Alpha: 0.05, Beta: 0.05}, {Elo1: 5, Alpha: 0, Beta: 0.05}, {Elo1: 5, Alpha: 0.05, Beta: 0.5}} {
+		if invalid.Validate() == nil {
+			t.Errorf("expected error for %+v", invalid)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	bounds := Bounds{Elo0: 0, Elo1: 10, Alpha: 0.05, Beta: 0.05}
+
+	if r := Evaluate(bounds, Pentanomial{}); r.LLR != 0 || r.Decision != Continue {
+		t.Errorf("empty test should continue, got %+v", r)
+	}
+
+	// Balanced results estimate no difference
+	r := Evaluate(bounds, Pentanomial{10, 40, 100, 40, 10})
+	if math.Abs(r.Elo) > 0.001 || r.EloError <= 0 || r.LLR >= 0 {
+		t.Errorf("unexpected balanced result %+v", r)
+	}
+
+	// A clearly stronger player is accepted, a clearly weaker one rejected
+	if r := Evaluate(bounds, Pentanomial{5, 20, 100, 60, 30}); r.Decision != AcceptH1 || r.Elo <= 10 {
+		t.Errorf("expected H1, got %+v", r)
+	}
+	if r := Evaluate(bounds, Pentanomial{30, 60, 100, 20, 5}); r.Decision != AcceptH0 || r.Elo >= 0 {
+		t.Errorf("expected H0, got %+v", r)
+	}
+
+	// Only drawn pairs don't divide by zero
+	if r := Evaluate(bounds, Pentanomial{0, 0, 50, 0, 0}); math.IsNaN(r.LLR) || math.IsInf(r.LLR, 0) {
+		t.Errorf("invalid LLR %v", r.LLR)
+	}
+
+	// More pairs narrow the error bars
+	few := Evaluate(bounds, Pentanomial{1, 4, 10, 4, 1})
+	many := Evaluate(bounds, Pentanomial{10, 40, 100, 40, 10})
+	if many.EloError >= few.EloError {
+		t.Errorf("error should shrink with pairs: %v vs %v", few.EloError, many.EloError)
+	}
+}
+
+func TestPentanomialAdd(t *testing.T) {
+	var p Pentanomial
+	for _, points := range []float64{0, 0.5, 1, 1, 1.5, 2} {
+		p.Add(points)
+	}
+	if p != (Pentanomial{1, 1, 2, 1, 1}) || p.Pairs() != 6 {
+		t.Errorf("unexpected counts %v", p)
+	}
+}
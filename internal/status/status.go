@@ -0,0 +1,60 @@
+/*
+Process status used by the health, readiness and info endpoints.
+*/
+package status
+
+import (
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// Version of the server. Set at build time with
+// -ldflags "-X github.com/matetirpak/chessbot-playground-server/internal/status.Version=v1.2.3"
+var Version = ""
+
+var StartTime = time.Now()
+
+var checks = make(map[string]error)
+var checksMu sync.RWMutex
+
+// Records the result of a readiness check. A nil error marks it as passed.
+func SetCheck(name string, err error) {
+	checksMu.Lock()
+	checks[name] = err
+	checksMu.Unlock()
+}
+
+// Returns all readiness checks with "ok" or the error message, and
+// whether all of them passed.
+func Checks() (map[string]string, bool) {
+	checksMu.RLock()
+	defer checksMu.RUnlock()
+
+	results := make(map[string]string, len(checks))
+	ready := true
+	for name, err := range checks {
+		if err != nil {
+			results[name] = err.Error()
+			ready = false
+		} else {
+			results[name] = "ok"
+		}
+	}
+	return results, ready
+}
+
+// Returns the build version, falling back to the module version.
+func BuildVersion() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+func Uptime() time.Duration {
+	return time.Since(StartTime)
+}
@@ -0,0 +1,88 @@
+/*
+Persistence of the tournaments next to the game snapshot.
+*/
+package tournament
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+)
+
+const SnapshotFile = "tournaments.json"
+
+// Writes all tournaments to dir/SnapshotFile. The file is replaced atomically.
+func SaveSnapshot(dir string) error {
+	var states []State
+	for _, t := range List() {
+		states = append(states, t.State())
+	}
+	encoded, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, SnapshotFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(encoded); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, SnapshotFile))
+}
+
+// Restores the tournaments from dir/SnapshotFile. A missing file is not an
+// error. Returns the running tournaments, which have to be resumed with Run.
+func LoadSnapshot(dir string) ([]*Tournament, error) {
+	encoded, err := os.ReadFile(filepath.Join(dir, SnapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var states []State
+	if err := json.Unmarshal(encoded, &states); err != nil {
+		return nil, err
+	}
+	// Snapshots written before only hashes were stored
+	var legacy []struct {
+		Password string `json:"password"`
+	}
+	if err := json.Unmarshal(encoded, &legacy); err != nil {
+		return nil, err
+	}
+	for i := range states {
+		if states[i].PasswordHash == "" && legacy[i].Password != "" {
+			states[i].PasswordHash = data.HashSecret(legacy[i].Password)
+		}
+	}
+
+	var running []*Tournament
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, state := range states {
+		t := newTournament(state)
+		registry[state.ID] = t
+		if state.ID >= nextID {
+			nextID = state.ID + 1
+		}
+		if state.Status == StatusRunning {
+			running = append(running, t)
+		}
+	}
+	return running, nil
+}
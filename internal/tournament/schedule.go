@@ -0,0 +1,78 @@
+/*
+Pairings of the tournament formats.
+*/
+package tournament
+
+// A pairing of two participants in a round. First gets white in the
+// first game of the pairing.
+type pairing struct {
+	round         int
+	first, second int
+}
+
+// Returns the games of the tournament in the order they are played.
+func schedule(spec Spec) []Game {
+	var pairings []pairing
+	switch spec.Format {
+	case RoundRobin:
+		pairings = roundRobin(len(spec.Participants), 0)
+	case DoubleRoundRobin:
+		pairings = roundRobin(len(spec.Participants), 0)
+		rounds := pairings[len(pairings)-1].round
+		for _, p := range roundRobin(len(spec.Participants), rounds) {
+			p.first, p.second = p.second, p.first
+			pairings = append(pairings, p)
+		}
+	case Gauntlet:
+		for opponent := 1; opponent < len(spec.Participants); opponent++ {
+			pairings = append(pairings, pairing{round: opponent, first: 0, second: opponent})
+		}
+	}
+
+	var games []Game
+	for _, p := range pairings {
+		for i := range spec.GamesPerPairing {
+			white, black := p.first, p.second
+			if i%2 == 1 {
+				white, black = black, white
+			}
+			games = append(games, Game{Index: len(games), Round: p.round, White: white, Black: black, Status: GamePending})
+		}
+	}
+	return games
+}
+
+// Pairs n participants with the circle method: the first participant
+// stays in place while the others rotate, so everyone meets everyone
+// once in n-1 rounds (n rounds with a bye for odd n). Rounds are numbered
+// from offset+1.
+func roundRobin(n int, offset int) []pairing {
+	players := make([]int, n)
+	for i := range players {
+		players[i] = i
+	}
+	if n%2 == 1 {
+		players = append(players, -1) // Bye
+	}
+	size := len(players)
+
+	var pairings []pairing
+	for round := 0; round < size-1; round++ {
+		for i := 0; i < size/2; i++ {
+			a, b := players[i], players[size-1-i]
+			if a == -1 || b == -1 {
+				continue
+			}
+			// Alternate colors of the fixed participant and across boards
+			if (round+i)%2 == 1 {
+				a, b = b, a
+			}
+			pairings = append(pairings, pairing{round: offset + round + 1, first: a, second: b})
+		}
+		// Rotate all but the first participant
+		last := players[size-1]
+		copy(players[2:], players[1:size-1])
+		players[1] = last
+	}
+	return pairings
+}
@@ -0,0 +1,50 @@
+/*
+SPRT matches: the first participant is tested against the second one in
+pairs of games with swapped colors.
+*/
+package tournament
+
+import "github.com/matetirpak/chessbot-playground-server/internal/sprt"
+
+// Maximum pairs of an SPRT match if the spec doesn't set them.
+const defaultSPRTPairs = 1000
+
+// Adds the next pair of games. Each pair is a round of its own.
+func (s *State) addSPRTPair() {
+	round := s.Round() + 1
+	for i := range 2 {
+		white, black := 0, 1
+		if i == 1 {
+			white, black = 1, 0
+		}
+		s.Games = append(s.Games, Game{Index: len(s.Games), Round: round, White: white, Black: black, Status: GamePending})
+	}
+}
+
+// Evaluates the test of an SPRT match on the pairs with both games
+// finished.
+func (s *State) SPRT() sprt.Result {
+	scored := make(map[int]float64)
+	finished := make(map[int]int)
+	for i := range s.Games {
+		game := &s.Games[i]
+		if game.Status != GameFinished {
+			continue
+		}
+		white, black := points(game)
+		if game.White == 0 {
+			scored[game.Round] += white
+		} else {
+			scored[game.Round] += black
+		}
+		finished[game.Round]++
+	}
+
+	var penta sprt.Pentanomial
+	for round, n := range finished {
+		if n == 2 {
+			penta.Add(scored[round])
+		}
+	}
+	return sprt.Evaluate(*s.Spec.SPRT, penta)
+}
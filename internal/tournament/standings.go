@@ -0,0 +1,153 @@
+/*
+Standings and crosstable of a tournament.
+*/
+package tournament
+
+import "sort"
+
+// Score of a participant, overall or against one opponent.
+type Score struct {
+	Games  int     `json:"games"`
+	Wins   int     `json:"wins"`
+	Draws  int     `json:"draws"`
+	Losses int     `json:"losses"`
+	Points float64 `json:"points"`
+}
+
+func (s *Score) add(points float64) {
+	s.Games++
+	s.Points += points
+	switch points {
+	case 1:
+		s.Wins++
+	case 0.5:
+		s.Draws++
+	default:
+		s.Losses++
+	}
+}
+
+// Entry of the standings. Points include byes.
+type Standing struct {
+	Rank        int    `json:"rank"`
+	Participant string `json:"participant"`
+	Score
+	Byes            int     `json:"byes"`
+	Buchholz        float64 `json:"buchholz"`        // Sum of the points of the opponents
+	SonnebornBerger float64 `json:"sonnebornberger"` // Sum of the points of the beaten opponents, half for draws
+}
+
+// Results between all participants. Scores[i][j] is the score of
+// participant i against participant j, nil if they don't meet.
+type Crosstable struct {
+	Participants []string   `json:"participants"`
+	Scores       [][]*Score `json:"scores"`
+}
+
+// Returns the points of white and black in a finished game.
+func points(game *Game) (float64, float64) {
+	switch game.Winner {
+	case "w":
+		return 1, 0
+	case "b":
+		return 0, 1
+	default:
+		return 0.5, 0.5
+	}
+}
+
+// Returns the participants ordered by points, then the Buchholz and
+// Sonneborn-Berger tiebreaks, then wins. Participants equal in all of
+// them share a rank.
+func (s *State) Standings() []Standing {
+	return s.StandingsAfter(0)
+}
+
+// Returns the standings after the given round, counting only the games
+// and byes of the rounds up to it. Round 0 counts all rounds.
+func (s *State) StandingsAfter(round int) []Standing {
+	counts := func(r int) bool { return round <= 0 || r <= round }
+
+	standings := make([]Standing, len(s.Spec.Participants))
+	for i, p := range s.Spec.Participants {
+		standings[i].Participant = p.Name
+	}
+	for i := range s.Games {
+		game := &s.Games[i]
+		if game.Status != GameFinished || !counts(game.Round) {
+			continue
+		}
+		white, black := points(game)
+		standings[game.White].add(white)
+		standings[game.Black].add(black)
+	}
+	for _, bye := range s.Byes {
+		if counts(bye.Round) {
+			standings[bye.Participant].Byes++
+			standings[bye.Participant].Points += s.byePoints()
+		}
+	}
+
+	// Tiebreaks use the points of the opponents, byes don't count
+	for i := range s.Games {
+		game := &s.Games[i]
+		if game.Status != GameFinished || !counts(game.Round) {
+			continue
+		}
+		white, black := points(game)
+		w, b := &standings[game.White], &standings[game.Black]
+		w.Buchholz += standings[game.Black].Points
+		b.Buchholz += standings[game.White].Points
+		w.SonnebornBerger += white * standings[game.Black].Points
+		b.SonnebornBerger += black * standings[game.White].Points
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		return ahead(&standings[i], &standings[j])
+	})
+	for i := range standings {
+		standings[i].Rank = i + 1
+		if i > 0 && !ahead(&standings[i-1], &standings[i]) {
+			standings[i].Rank = standings[i-1].Rank
+		}
+	}
+	return standings
+}
+
+// Reports whether a ranks above b.
+func ahead(a, b *Standing) bool {
+	switch {
+	case a.Points != b.Points:
+		return a.Points > b.Points
+	case a.Buchholz != b.Buchholz:
+		return a.Buchholz > b.Buchholz
+	case a.SonnebornBerger != b.SonnebornBerger:
+		return a.SonnebornBerger > b.SonnebornBerger
+	default:
+		return a.Wins > b.Wins
+	}
+}
+
+// Returns the crosstable of the finished games.
+func (s *State) Crosstable() Crosstable {
+	n := len(s.Spec.Participants)
+	table := Crosstable{Participants: make([]string, n), Scores: make([][]*Score, n)}
+	for i, p := range s.Spec.Participants {
+		table.Participants[i] = p.Name
+		table.Scores[i] = make([]*Score, n)
+	}
+	for i := range s.Games {
+		game := &s.Games[i]
+		if table.Scores[game.White][game.Black] == nil {
+			table.Scores[game.White][game.Black] = &Score{}
+			table.Scores[game.Black][game.White] = &Score{}
+		}
+		if game.Status != GameFinished {
+			continue
+		}
+		white, black := points(game)
+		table.Scores[game.White][game.Black].add(white)
+		table.Scores[game.Black][game.White].add(black)
+	}
+	return table
+}
@@ -0,0 +1,208 @@
+/*
+Pairings of Swiss tournaments. Each round pairs participants with equal
+points where possible, never pairs the same participants twice unless
+there is no other way, or none is found within a bounded search, and
+gives white to the participant who had it less often. With an odd number
+of participants the lowest ranked participant without a bye sits out and
+scores as if it won all games of a pairing.
+*/
+package tournament
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// Rounds of a Swiss tournament if the spec doesn't set them: enough for a
+// single participant to remain with a perfect score.
+func defaultRounds(participants int) int {
+	return max(bits.Len(uint(participants-1)), 1)
+}
+
+// Returns the highest round with games or byes, 0 before the first round.
+func (s *State) Round() int {
+	round := 0
+	for _, game := range s.Games {
+		round = max(round, game.Round)
+	}
+	for _, bye := range s.Byes {
+		round = max(round, bye.Round)
+	}
+	return round
+}
+
+// History of a participant in the rounds played so far.
+type swissPlayer struct {
+	index     int
+	points    float64
+	opponents map[int]bool
+	colorDiff int  // Games with white minus games with black
+	lastWhite bool // Color of the last game
+	played    bool // Played at least one game
+	hadBye    bool
+}
+
+// Adds the games, or the bye, of the next round.
+func (s *State) pairSwissRound() {
+	round := s.Round() + 1
+	players := s.swissPlayers()
+
+	// Rank by points, then seed
+	ranked := make([]*swissPlayer, len(players))
+	for i := range players {
+		ranked[i] = &players[i]
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].points > ranked[j].points })
+
+	pairs, bye := pairSwiss(ranked, false)
+	if pairs == nil {
+		// Late rounds may leave no way around a rematch, or none that the
+		// bounded search finds
+		pairs, bye = pairSwiss(ranked, true)
+	}
+	if bye != nil {
+		s.Byes = append(s.Byes, Bye{Round: round, Participant: bye.index})
+	}
+
+	for _, pair := range pairs {
+		first, second := colors(pair[0], pair[1], round)
+		for i := range s.Spec.GamesPerPairing {
+			white, black := first.index, second.index
+			if i%2 == 1 {
+				white, black = black, white
+			}
+			s.Games = append(s.Games, Game{Index: len(s.Games), Round: round, White: white, Black: black, Status: GamePending})
+		}
+	}
+}
+
+// Points of a bye, the same as winning every game of a pairing.
+func (s *State) byePoints() float64 {
+	return float64(s.Spec.GamesPerPairing)
+}
+
+// Collects the results of the finished games and the byes.
+func (s *State) swissPlayers() []swissPlayer {
+	players := make([]swissPlayer, len(s.Spec.Participants))
+	for i := range players {
+		players[i] = swissPlayer{index: i, opponents: make(map[int]bool)}
+	}
+	for i := range s.Games {
+		game := &s.Games[i]
+		if game.Status != GameFinished {
+			continue
+		}
+		white, black := points(game)
+		w, b := &players[game.White], &players[game.Black]
+		w.points += white
+		b.points += black
+		w.opponents[game.Black] = true
+		b.opponents[game.White] = true
+		w.colorDiff++
+		b.colorDiff--
+		w.lastWhite, b.lastWhite = true, false
+		w.played, b.played = true, true
+	}
+	for _, bye := range s.Byes {
+		players[bye.Participant].points += s.byePoints()
+		players[bye.Participant].hadBye = true
+	}
+	return players
+}
+
+// Steps the pairing search may take. Large fields may have no pairing
+// without rematches, which an exhaustive search takes ages to find out;
+// the round is then paired allowing rematches.
+const pairingSteps = 100_000
+
+// Pairs the ranked participants. With an odd number of participants the
+// lowest ranked participant without a bye that leaves a valid pairing sits
+// out. Returns nil pairs if there is no pairing without rematches, or none
+// was found within pairingSteps, unless rematches are allowed.
+func pairSwiss(ranked []*swissPlayer, rematches bool) ([][2]*swissPlayer, *swissPlayer) {
+	steps := pairingSteps
+	if len(ranked)%2 == 0 {
+		return pairGroup(ranked, rematches, &steps), nil
+	}
+	for _, allowRepeatBye := range []bool{false, true} {
+		for i := len(ranked) - 1; i >= 0; i-- {
+			if ranked[i].hadBye && !allowRepeatBye {
+				continue
+			}
+			rest := append(append([]*swissPlayer(nil), ranked[:i]...), ranked[i+1:]...)
+			if pairs := pairGroup(rest, rematches, &steps); pairs != nil {
+				return pairs, ranked[i]
+			}
+		}
+	}
+	return nil, nil
+}
+
+// Pairs the ranked participants by backtracking. The highest ranked
+// participant first tries the opponent half way down its score group, like
+// the top half meeting the bottom half, then the rest of the group and then
+// lower groups. Each call takes one of the remaining steps, nil is
+// returned once they are used up.
+func pairGroup(ranked []*swissPlayer, rematches bool, steps *int) [][2]*swissPlayer {
+	if len(ranked) == 0 {
+		return [][2]*swissPlayer{}
+	}
+	if *steps <= 0 {
+		return nil
+	}
+	*steps--
+	top := ranked[0]
+	group := 1
+	for group < len(ranked) && ranked[group].points == top.points {
+		group++
+	}
+	candidates := make([]int, 0, len(ranked)-1)
+	for i := max(group/2, 1); i < group; i++ {
+		candidates = append(candidates, i)
+	}
+	for i := 1; i < group/2; i++ {
+		candidates = append(candidates, i)
+	}
+	for i := group; i < len(ranked); i++ {
+		candidates = append(candidates, i)
+	}
+
+	for _, c := range candidates {
+		opponent := ranked[c]
+		if top.opponents[opponent.index] && !rematches {
+			continue
+		}
+		rest := make([]*swissPlayer, 0, len(ranked)-2)
+		for i, p := range ranked[1:] {
+			if i+1 != c {
+				rest = append(rest, p)
+			}
+		}
+		if pairs := pairGroup(rest, rematches, steps); pairs != nil {
+			return append([][2]*swissPlayer{{top, opponent}}, pairs...)
+		}
+	}
+	return nil
+}
+
+// Returns the participant who gets white first, then the other one. White
+// goes to the participant who had it less often, then to the one who had
+// black last, then alternates between rounds for the higher ranked one.
+func colors(a, b *swissPlayer, round int) (*swissPlayer, *swissPlayer) {
+	switch {
+	case a.colorDiff != b.colorDiff:
+		if a.colorDiff < b.colorDiff {
+			return a, b
+		}
+		return b, a
+	case a.played && b.played && a.lastWhite != b.lastWhite:
+		if b.lastWhite {
+			return a, b
+		}
+		return b, a
+	case round%2 == 1:
+		return a, b
+	default:
+		return b, a
+	}
+}
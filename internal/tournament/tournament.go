@@ -0,0 +1,447 @@
+/*
+Tournaments between engines and bots. A tournament schedules its games
+from a format and a list of participants, plays them with limited
+concurrency and keeps the results for standings and crosstables.
+Swiss tournaments are paired round by round from the results so far,
+SPRT matches add pairs of games until the test reaches a decision.
+
+The package doesn't know how games are played, the API passes a PlayFunc.
+*/
+package tournament
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	data "github.com/matetirpak/chessbot-playground-server/internal/data"
+	"github.com/matetirpak/chessbot-playground-server/internal/sprt"
+)
+
+type Format string
+
+const (
+	RoundRobin       Format = "roundrobin"       // Everyone plays everyone
+	DoubleRoundRobin Format = "doubleroundrobin" // Round robin twice, colors reversed in the second cycle
+	Gauntlet         Format = "gauntlet"         // The first participant plays everyone else
+	Swiss            Format = "swiss"            // Participants with equal scores meet, without rematches
+	SPRT             Format = "sprt"             // Pairs of games between two participants until the SPRT decides
+)
+
+var Formats = []Format{RoundRobin, DoubleRoundRobin, Gauntlet, Swiss, SPRT}
+
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusFinished  Status = "finished"
+	StatusCancelled Status = "cancelled"
+)
+
+type GameStatus string
+
+const (
+	GamePending  GameStatus = "pending"
+	GameRunning  GameStatus = "running"
+	GameFinished GameStatus = "finished"
+	GameFailed   GameStatus = "failed" // Couldn't be played, doesn't count
+)
+
+// Returned by a PlayFunc if the game was interrupted by a server shutdown.
+// The game is played again when the tournament is resumed.
+var ErrInterrupted = errors.New("game interrupted")
+
+// A player of the tournament, either a built-in engine or a bot.
+type Participant struct {
+	Name   string `json:"name"`
+	Engine string `json:"engine,omitempty"`
+	Bot    string `json:"bot,omitempty"`
+}
+
+// Settings of a tournament.
+type Spec struct {
+	Name            string        `json:"name"`
+	Format          Format        `json:"format"`
+	Participants    []Participant `json:"participants"`
+	GamesPerPairing int           `json:"gamesperpairing"`    // Colors alternate between the games of a pairing
+	Rounds          int           `json:"rounds,omitempty"`   // Rounds of a Swiss tournament, maximum pairs of an SPRT match
+	SPRT            *sprt.Bounds  `json:"sprt,omitempty"`     // Hypotheses of an SPRT match
+	MoveTime        time.Duration `json:"movetime"`           // Time per move, 0 for none
+	MaxPlies        int           `json:"maxplies"`           // Games reaching it are drawn, 0 for no limit
+	Concurrency     int           `json:"concurrency"`        // Games played at the same time
+	Rated           bool          `json:"rated,omitempty"`    // Games update the ratings of the engines and bots
+	Openings        string        `json:"openings,omitempty"` // Opening suite the pairings start from, "" for the start position
+}
+
+// Returns the number of the pairing a game belongs to. The games of a
+// pairing are scheduled one after another in every format.
+func (spec *Spec) Pairing(game *Game) int {
+	return game.Index / spec.GamesPerPairing
+}
+
+// A scheduled game. White and Black are indices into Spec.Participants.
+type Game struct {
+	Index       int        `json:"index"`
+	Round       int        `json:"round"`
+	White       int        `json:"white"`
+	Black       int        `json:"black"`
+	Status      GameStatus `json:"status"`
+	BoardID     int32      `json:"boardid,omitempty"`
+	Winner      string     `json:"winner,omitempty"` // "w", "b" or "r"
+	Termination string     `json:"termination,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// Outcome of a played game.
+type Result struct {
+	Winner      string // "w", "b" or "r"
+	Termination string
+}
+
+// Plays a game of the tournament and blocks until it has ended. A game
+// with a BoardID was started before a restart and is only watched.
+// The PlayFunc reports the board of a new game with Tournament.Started.
+type PlayFunc func(ctx context.Context, t *Tournament, game Game) (Result, error)
+
+// A round a participant of a Swiss tournament with an odd number of
+// participants sits out. It scores a point.
+type Bye struct {
+	Round       int `json:"round"`
+	Participant int `json:"participant"`
+}
+
+// Copy of the data of a tournament. The password is only kept hashed, see
+// data.HashSecret.
+type State struct {
+	ID           int32     `json:"id"`
+	PasswordHash string    `json:"passwordhash"`
+	Spec         Spec      `json:"spec"`
+	Status       Status    `json:"status"`
+	Games        []Game    `json:"games"`
+	Byes         []Bye     `json:"byes,omitempty"`
+	CreatedAt    time.Time `json:"createdat"`
+	FinishedAt   time.Time `json:"finishedat"`
+}
+
+type Tournament struct {
+	mu     sync.RWMutex
+	state  State
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	claimed     map[int]bool // Games taken by a worker of Run
+	interrupted bool         // A worker of Run was interrupted by a shutdown
+	changed     *sync.Cond   // Signals workers waiting for the end of a round
+}
+
+var (
+	registryMu sync.RWMutex
+	registry         = map[int32]*Tournament{}
+	nextID     int32 = 1
+)
+
+// Validates the spec, fills in defaults and schedules the games.
+// The tournament is registered, Run starts it. Only the hash of the
+// password is stored.
+func New(spec Spec, password string) (*Tournament, error) {
+	spec.Participants = append([]Participant(nil), spec.Participants...)
+	if spec.GamesPerPairing == 0 {
+		spec.GamesPerPairing = 2
+		if spec.Format == Swiss && spec.Openings == "" {
+			spec.GamesPerPairing = 1
+		}
+	}
+	if spec.Rounds == 0 && spec.Format == Swiss {
+		spec.Rounds = defaultRounds(len(spec.Participants))
+	}
+	if spec.Format == SPRT {
+		if spec.Rounds == 0 {
+			spec.Rounds = defaultSPRTPairs
+		}
+		if spec.SPRT == nil {
+			bounds := sprt.DefaultBounds
+			spec.SPRT = &bounds
+		}
+	}
+	if spec.Concurrency == 0 {
+		spec.Concurrency = 1
+	}
+	if err := spec.validate(); err != nil {
+		return nil, err
+	}
+
+	t := newTournament(State{
+		PasswordHash: data.HashSecret(password),
+		Spec:         spec,
+		Status:       StatusRunning,
+		Games:        schedule(spec),
+		CreatedAt:    time.Now(),
+	})
+	switch spec.Format {
+	case Swiss:
+		t.state.pairSwissRound()
+	case SPRT:
+		t.state.addSPRTPair()
+	}
+
+	registryMu.Lock()
+	t.state.ID = nextID
+	nextID++
+	registry[t.state.ID] = t
+	registryMu.Unlock()
+	return t, nil
+}
+
+func newTournament(state State) *Tournament {
+	t := &Tournament{state: state, claimed: make(map[int]bool)}
+	t.changed = sync.NewCond(&t.mu)
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+	return t
+}
+
+func (spec *Spec) validate() error {
+	if !validFormat(spec.Format) {
+		return fmt.Errorf("unknown format %q, use one of %v", spec.Format, Formats)
+	}
+	if len(spec.Participants) < 2 {
+		return errors.New("a tournament needs at least two participants")
+	}
+	names := make(map[string]bool)
+	for i, p := range spec.Participants {
+		if (p.Engine == "") == (p.Bot == "") {
+			return fmt.Errorf("participant %d needs either an engine or a bot", i)
+		}
+		if p.Name == "" {
+			p.Name = p.Engine + p.Bot
+			spec.Participants[i].Name = p.Name
+		}
+		if names[p.Name] {
+			return fmt.Errorf("participant name %q is used twice", p.Name)
+		}
+		names[p.Name] = true
+	}
+	switch {
+	case spec.GamesPerPairing < 1:
+		return errors.New("gamesperpairing has to be positive")
+	case spec.Concurrency < 1:
+		return errors.New("concurrency has to be positive")
+	case spec.MoveTime < 0:
+		return errors.New("movetime must not be negative")
+	case spec.MaxPlies < 0:
+		return errors.New("maxplies must not be negative")
+	case spec.Format != Swiss && spec.Format != SPRT && spec.Rounds != 0:
+		return errors.New("rounds can only be set for swiss tournaments and sprt matches")
+	case (spec.Format == Swiss || spec.Format == SPRT) && spec.Rounds < 1:
+		return errors.New("rounds has to be positive")
+	case spec.Format != SPRT && spec.SPRT != nil:
+		return errors.New("sprt bounds can only be set for sprt matches")
+	case spec.Openings != "" && spec.GamesPerPairing%2 != 0:
+		return errors.New("each opening is played with both colors, gamesperpairing has to be even")
+	}
+	if spec.Format == SPRT {
+		switch {
+		case len(spec.Participants) != 2:
+			return errors.New("an sprt match needs exactly two participants")
+		case spec.GamesPerPairing != 2:
+			return errors.New("an sprt match plays pairs of games, gamesperpairing has to be 2")
+		}
+		return spec.SPRT.Validate()
+	}
+	return nil
+}
+
+func validFormat(format Format) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the tournament with the given ID.
+func Get(id int32) (*Tournament, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	t, exists := registry[id]
+	return t, exists
+}
+
+// Returns all tournaments ordered by ID.
+func List() []*Tournament {
+	registryMu.RLock()
+	list := make([]*Tournament, 0, len(registry))
+	for _, t := range registry {
+		list = append(list, t)
+	}
+	registryMu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].ID() < list[j].ID() })
+	return list
+}
+
+func (t *Tournament) ID() int32 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.state.ID
+}
+
+// Reports whether password is the password of the tournament, in
+// constant time.
+func (t *Tournament) PasswordMatches(password string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return data.MatchesHash(t.state.PasswordHash, password)
+}
+
+// Returns a copy of the tournament data.
+func (t *Tournament) State() State {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	state := t.state
+	state.Spec.Participants = append([]Participant(nil), t.state.Spec.Participants...)
+	state.Games = append([]Game(nil), t.state.Games...)
+	state.Byes = append([]Bye(nil), t.state.Byes...)
+	return state
+}
+
+// Records the board a game is played on.
+func (t *Tournament) Started(index int, boardID int32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.state.Games[index].BoardID = boardID
+	t.state.Games[index].Status = GameRunning
+}
+
+// Stops scheduling games. Returns the boards of the running games, which
+// the caller ends. Returns false if the tournament was not running.
+func (t *Tournament) Cancel() ([]int32, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.state.Status != StatusRunning {
+		return nil, false
+	}
+	t.state.Status = StatusCancelled
+	t.state.FinishedAt = time.Now()
+	t.cancel()
+	t.changed.Broadcast()
+
+	var boards []int32
+	for _, game := range t.state.Games {
+		if game.Status == GameRunning && game.BoardID != 0 {
+			boards = append(boards, game.BoardID)
+		}
+	}
+	return boards, true
+}
+
+// Plays the open games, at most Spec.Concurrency at a time. Returns once
+// all games have ended, the tournament is cancelled or a game was
+// interrupted by a shutdown.
+func (t *Tournament) Run(play PlayFunc) {
+	t.mu.Lock()
+	workers := t.state.Spec.Concurrency
+	t.interrupted = false
+	t.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				game, ok := t.next()
+				if !ok {
+					return
+				}
+				result, err := play(t.ctx, t, game)
+				if errors.Is(err, ErrInterrupted) {
+					t.mu.Lock()
+					t.interrupted = true
+					t.changed.Broadcast()
+					t.mu.Unlock()
+					return
+				}
+				t.finish(game.Index, result, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.state.Status == StatusRunning && !t.interrupted {
+		t.state.Status = StatusFinished
+		t.state.FinishedAt = time.Now()
+	}
+}
+
+// Claims the next game to play. Games resumed after a restart come first.
+// The next Swiss round is paired once all games of the current round have
+// ended, SPRT matches add a pair whenever no game is left.
+func (t *Tournament) next() (Game, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for {
+		if t.state.Status != StatusRunning || t.ctx.Err() != nil || t.interrupted {
+			return Game{}, false
+		}
+		for _, status := range []GameStatus{GameRunning, GamePending} {
+			for i := range t.state.Games {
+				game := &t.state.Games[i]
+				if game.Status != status || t.claimed[i] {
+					continue
+				}
+				game.Status = GameRunning
+				t.claimed[i] = true
+				return *game, true
+			}
+		}
+
+		switch t.state.Spec.Format {
+		case Swiss:
+			if t.playing() {
+				t.changed.Wait()
+				continue
+			}
+			if t.state.Round() >= t.state.Spec.Rounds {
+				return Game{}, false
+			}
+			t.state.pairSwissRound()
+		case SPRT:
+			if t.state.Round() >= t.state.Spec.Rounds || t.state.SPRT().Decision != sprt.Continue {
+				return Game{}, false
+			}
+			t.state.addSPRTPair()
+		default:
+			return Game{}, false
+		}
+	}
+}
+
+// Reports whether a worker is still playing a game. Called with t.mu held.
+func (t *Tournament) playing() bool {
+	for i, game := range t.state.Games {
+		if game.Status == GameRunning && t.claimed[i] {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Tournament) finish(index int, result Result, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	defer t.changed.Broadcast()
+	game := &t.state.Games[index]
+	if err != nil {
+		game.Status = GameFailed
+		game.Error = err.Error()
+		return
+	}
+	game.Status = GameFinished
+	game.Winner = result.Winner
+	game.Termination = result.Termination
+}
@@ -0,0 +1,516 @@
+/*
+Unittest for the tournament package.
+*/
+package tournament
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matetirpak/chessbot-playground-server/internal/sprt"
+)
+
+func participants(names ...string) []Participant {
+	list := make([]Participant, len(names))
+	for i, name := range names {
+		list[i] = Participant{Name: name, Engine: "random"}
+	}
+	return list
+}
+
+func TestScheduleRoundRobin(t *testing.T) {
+	for _, n := range []int{2, 3, 4, 5, 6} {
+		spec := Spec{Format: RoundRobin, Participants: participants(make([]string, n)...), GamesPerPairing: 2}
+		games := schedule(spec)
+		if want := n * (n - 1); len(games) != want {
+			t.Errorf("%d participants: expected %d games, got %d", n, want, len(games))
+		}
+
+		// Every pairing plays twice with each color once
+		colors := make(map[[2]int]int)
+		perRound := make(map[int]map[int]bool)
+		for _, game := range games {
+			colors[[2]int{game.White, game.Black}]++
+			if perRound[game.Round] == nil {
+				perRound[game.Round] = make(map[int]bool)
+			}
+			perRound[game.Round][game.White] = true
+			perRound[game.Round][game.Black] = true
+		}
+		for a := 0; a < n; a++ {
+			for b := 0; b < n; b++ {
+				if a != b && colors[[2]int{a, b}] != 1 {
+					t.Errorf("%d participants: %d has white against %d %d times", n, a, b, colors[[2]int{a, b}])
+				}
+			}
+		}
+		rounds := n - 1
+		if n%2 == 1 {
+			rounds = n
+		}
+		if len(perRound) != rounds {
+			t.Errorf("%d participants: expected %d rounds, got %d", n, rounds, len(perRound))
+		}
+	}
+}
+
+func TestScheduleDoubleRoundRobinAndGauntlet(t *testing.T) {
+	games := schedule(Spec{Format: DoubleRoundRobin, Participants: participants("a", "b", "c", "d"), GamesPerPairing: 1})
+	if len(games) != 12 {
+		t.Fatalf("expected 12 games, got %d", len(games))
+	}
+	seen := make(map[[2]int]bool)
+	for _, game := range games {
+		key := [2]int{game.White, game.Black}
+		if seen[key] {
+			t.Errorf("%d has white against %d twice", game.White, game.Black)
+		}
+		seen[key] = true
+	}
+	if games[6].Round != 4 {
+		t.Errorf("second cycle should start with round 4, got %d", games[6].Round)
+	}
+
+	games = schedule(Spec{Format: Gauntlet, Participants: participants("hero", "a", "b"), GamesPerPairing: 3})
+	if len(games) != 6 {
+		t.Fatalf("expected 6 games, got %d", len(games))
+	}
+	for _, game := range games {
+		if game.White != 0 && game.Black != 0 {
+			t.Errorf("gauntlet game without the first participant: %+v", game)
+		}
+	}
+	if games[0].White != 0 || games[1].Black != 0 || games[2].White != 0 {
+		t.Errorf("colors don't alternate: %+v", games[:3])
+	}
+}
+
+func TestNewValidates(t *testing.T) {
+	cases := []Spec{
+		{Format: "knockout", Participants: participants("a", "b")},
+		{Format: RoundRobin, Participants: participants("a")},
+		{Format: RoundRobin, Participants: participants("a", "a")},
+		{Format: RoundRobin, Participants: []Participant{{Name: "a"}, {Name: "b", Engine: "random"}}},
+		{Format: RoundRobin, Participants: participants("a", "b"), Concurrency: -1},
+	}
+	for _, spec := range cases {
+		if _, err := New(spec, ""); err == nil {
+			t.Errorf("expected error for %+v", spec)
+		}
+	}
+
+	tour, err := New(Spec{Format: RoundRobin, Participants: []Participant{{Engine: "random"}, {Bot: "mybot"}}}, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := tour.State()
+	if state.Spec.Participants[0].Name != "random" || state.Spec.Participants[1].Name != "mybot" {
+		t.Errorf("names should default to engine and bot, got %+v", state.Spec.Participants)
+	}
+	if state.Spec.GamesPerPairing != 2 || state.Spec.Concurrency != 1 || len(state.Games) != 2 {
+		t.Errorf("unexpected defaults %+v", state.Spec)
+	}
+	if got, _ := Get(state.ID); got != tour {
+		t.Error("tournament is not registered")
+	}
+	if !tour.PasswordMatches("secret") || tour.PasswordMatches("") || state.PasswordHash == "secret" {
+		t.Errorf("password should only be stored hashed, got %q", state.PasswordHash)
+	}
+}
+
+func TestLoadLegacySnapshot(t *testing.T) {
+	dir := t.TempDir()
+	legacy := `[{"id": 900, "password": "secret", "status": "finished", "spec": {"format": "roundrobin"}}]`
+	if err := os.WriteFile(filepath.Join(dir, SnapshotFile), []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSnapshot(dir); err != nil {
+		t.Fatal(err)
+	}
+	tour, exists := Get(900)
+	if !exists {
+		t.Fatal("tournament was not restored")
+	}
+	if !tour.PasswordMatches("secret") || tour.State().PasswordHash == "secret" {
+		t.Errorf("legacy password was not migrated to a hash, got %q", tour.State().PasswordHash)
+	}
+}
+
+func TestRunAndStandings(t *testing.T) {
+	tour, err := New(Spec{Format: RoundRobin, Participants: participants("strong", "medium", "weak"), GamesPerPairing: 2, Concurrency: 2}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The participant with the lower index wins, the second game of a pairing is drawn
+	var active, maxActive atomic.Int32
+	var mu sync.Mutex
+	played := make(map[int]bool)
+	tour.Run(func(ctx context.Context, tr *Tournament, game Game) (Result, error) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			current := maxActive.Load()
+			if n <= current || maxActive.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		tr.Started(game.Index, int32(100+game.Index))
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if played[game.Index] {
+			t.Errorf("game %d played twice", game.Index)
+		}
+		played[game.Index] = true
+		if game.Index%2 == 1 {
+			return Result{Winner: "r"}, nil
+		}
+		if game.White < game.Black {
+			return Result{Winner: "w"}, nil
+		}
+		return Result{Winner: "b"}, nil
+	})
+
+	state := tour.State()
+	if state.Status != StatusFinished || len(played) != 6 {
+		t.Fatalf("expected a finished tournament with 6 games, got %s with %d", state.Status, len(played))
+	}
+	if maxActive.Load() > 2 {
+		t.Errorf("concurrency exceeded: %d games at once", maxActive.Load())
+	}
+
+	standings := state.Standings()
+	if standings[0].Participant != "strong" || standings[0].Points != 3 || standings[0].Wins != 2 || standings[0].Draws != 2 {
+		t.Errorf("unexpected leader %+v", standings[0])
+	}
+	if standings[2].Participant != "weak" || standings[2].Points != 1 || standings[2].Rank != 3 {
+		t.Errorf("unexpected last place %+v", standings[2])
+	}
+
+	table := state.Crosstable()
+	if score := table.Scores[0][2]; score == nil || score.Points != 1.5 || score.Games != 2 {
+		t.Errorf("unexpected score of strong against weak %+v", score)
+	}
+	if table.Scores[1][1] != nil {
+		t.Error("participant should not play itself")
+	}
+}
+
+func TestRunInterruptedAndResumed(t *testing.T) {
+	tour, err := New(Spec{Format: Gauntlet, Participants: participants("a", "b", "c"), GamesPerPairing: 1}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tour.Run(func(ctx context.Context, tr *Tournament, game Game) (Result, error) {
+		if game.Index == 0 {
+			return Result{Winner: "w"}, nil
+		}
+		tr.Started(game.Index, 42)
+		return Result{}, ErrInterrupted
+	})
+	if state := tour.State(); state.Status != StatusRunning || state.Games[1].Status != GameRunning {
+		t.Fatalf("interrupted tournament should keep running, got %s / %s", state.Status, state.Games[1].Status)
+	}
+
+	dir := t.TempDir()
+	if err := SaveSnapshot(dir); err != nil {
+		t.Fatal(err)
+	}
+	running, err := LoadSnapshot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resumed *Tournament
+	for _, r := range running {
+		if r.ID() == tour.ID() {
+			resumed = r
+		}
+	}
+	if resumed == nil {
+		t.Fatal("tournament was not resumed")
+	}
+
+	var boards []int32
+	resumed.Run(func(ctx context.Context, tr *Tournament, game Game) (Result, error) {
+		boards = append(boards, game.BoardID)
+		return Result{Winner: "b"}, nil
+	})
+	if len(boards) != 1 || boards[0] != 42 {
+		t.Errorf("expected only the running game on board 42 to be watched, got %v", boards)
+	}
+	if state := resumed.State(); state.Status != StatusFinished {
+		t.Errorf("expected finished tournament, got %s", state.Status)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	tour, err := New(Spec{Format: RoundRobin, Participants: participants("a", "b", "c", "d"), GamesPerPairing: 1}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var played atomic.Int32
+	tour.Run(func(ctx context.Context, tr *Tournament, game Game) (Result, error) {
+		tr.Started(game.Index, 7)
+		if played.Add(1) == 2 {
+			boards, ok := tr.Cancel()
+			if !ok || len(boards) != 1 || boards[0] != 7 {
+				t.Errorf("unexpected cancel result %v %v", boards, ok)
+			}
+			<-ctx.Done()
+			return Result{}, ctx.Err()
+		}
+		return Result{Winner: "w"}, nil
+	})
+
+	state := tour.State()
+	if state.Status != StatusCancelled || played.Load() != 2 {
+		t.Errorf("expected cancel after 2 games, got %s after %d", state.Status, played.Load())
+	}
+	if _, ok := tour.Cancel(); ok {
+		t.Error("cancelling twice should fail")
+	}
+}
+
+func TestSwiss(t *testing.T) {
+	tour, err := New(Spec{Format: Swiss, Participants: participants("a", "b", "c", "d", "e", "f", "g"), Rounds: 5, Concurrency: 3}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := tour.State(); state.Spec.GamesPerPairing != 1 || state.Round() != 1 || len(state.Games) != 3 || len(state.Byes) != 1 {
+		t.Fatalf("expected the first round to be paired, got %+v", state)
+	}
+
+	// The participant with the lower index wins
+	tour.Run(func(ctx context.Context, tr *Tournament, game Game) (Result, error) {
+		if game.White < game.Black {
+			return Result{Winner: "w"}, nil
+		}
+		return Result{Winner: "b"}, nil
+	})
+
+	state := tour.State()
+	if state.Status != StatusFinished || state.Round() != 5 || len(state.Games) != 15 || len(state.Byes) != 5 {
+		t.Fatalf("expected 5 rounds with 3 games and a bye each, got %s with %d games and %d byes", state.Status, len(state.Games), len(state.Byes))
+	}
+	met := make(map[[2]int]bool)
+	colorDiff := make([]int, 7)
+	perRound := make(map[int]map[int]bool)
+	for _, game := range state.Games {
+		key := [2]int{min(game.White, game.Black), max(game.White, game.Black)}
+		if met[key] {
+			t.Errorf("rematch of %v in round %d", key, game.Round)
+		}
+		met[key] = true
+		colorDiff[game.White]++
+		colorDiff[game.Black]--
+		if perRound[game.Round] == nil {
+			perRound[game.Round] = make(map[int]bool)
+		}
+		perRound[game.Round][game.White] = true
+		perRound[game.Round][game.Black] = true
+	}
+	byes := make(map[int]bool)
+	for _, bye := range state.Byes {
+		if byes[bye.Participant] || perRound[bye.Round][bye.Participant] {
+			t.Errorf("unexpected bye %+v", bye)
+		}
+		byes[bye.Participant] = true
+	}
+	for i, diff := range colorDiff {
+		if diff < -2 || diff > 2 {
+			t.Errorf("participant %d has unbalanced colors: %d", i, diff)
+		}
+	}
+
+	// After the first round the winners and the bye meet
+	after1 := state.StandingsAfter(1)
+	winners := map[int]bool{state.Byes[0].Participant: true}
+	for _, game := range state.Games[:3] {
+		winners[min(game.White, game.Black)] = true
+	}
+	for _, game := range state.Games[3:6] {
+		if winners[game.White] != winners[game.Black] {
+			t.Errorf("round 2 pairs a winner with a loser: %+v", game)
+		}
+	}
+	var total float64
+	for _, s := range after1 {
+		total += s.Points
+	}
+	if total != 4 {
+		t.Errorf("expected 4 points after the first round, got %v", total)
+	}
+
+	standings := state.Standings()
+	if standings[0].Participant != "a" || standings[0].Points != 5 {
+		t.Errorf("unexpected winner %+v", standings[0])
+	}
+}
+
+// A bye is worth a won pairing, however many games a pairing has.
+func TestSwissByeScoresPairing(t *testing.T) {
+	tour, err := New(Spec{Format: Swiss, Participants: participants("a", "b", "c", "d", "e"), Rounds: 2, GamesPerPairing: 2, Concurrency: 2}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The participant with the lower index wins
+	tour.Run(func(ctx context.Context, tr *Tournament, game Game) (Result, error) {
+		if game.White < game.Black {
+			return Result{Winner: "w"}, nil
+		}
+		return Result{Winner: "b"}, nil
+	})
+
+	state := tour.State()
+	if state.Status != StatusFinished || len(state.Byes) != 2 {
+		t.Fatalf("expected 2 rounds with a bye each, got %s with %d byes", state.Status, len(state.Byes))
+	}
+	// The bye scores as much as the winners of the first round
+	bye := state.Spec.Participants[state.Byes[0].Participant].Name
+	var total float64
+	for _, s := range state.StandingsAfter(1) {
+		total += s.Points
+		if s.Participant == bye && s.Points != 2 {
+			t.Errorf("expected the bye to score 2 points, got %+v", s)
+		}
+	}
+	if total != 6 {
+		t.Errorf("expected 6 points after the first round, got %v", total)
+	}
+}
+
+// In a large field where every pairing needs a rematch, the search gives up
+// in time and pairs with rematches.
+func TestSwissPairingIsBounded(t *testing.T) {
+	names := make([]string, 41)
+	for i := range names {
+		names[i] = fmt.Sprintf("p%d", i)
+	}
+	state := State{Spec: Spec{Format: Swiss, Participants: participants(names...), GamesPerPairing: 1}}
+	// The first 21 participants met each other and can't all meet the
+	// other 20 in the same round
+	for i := range 21 {
+		for j := i + 1; j < 21; j++ {
+			state.Games = append(state.Games, Game{Index: len(state.Games), Round: 1, White: i, Black: j, Status: GameFinished, Winner: "r"})
+		}
+	}
+	before := len(state.Games)
+
+	done := make(chan struct{})
+	go func() {
+		state.pairSwissRound()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("pairing did not finish in time")
+	}
+	if games := len(state.Games) - before; games != 20 || len(state.Byes) != 1 {
+		t.Errorf("expected 20 games and a bye, got %d games and %d byes", games, len(state.Byes))
+	}
+}
+
+func TestTiebreaks(t *testing.T) {
+	state := State{
+		Spec: Spec{Participants: participants("a", "b", "c", "d")},
+		Games: []Game{
+			{Round: 1, White: 0, Black: 1, Status: GameFinished, Winner: "w"},
+			{Round: 1, White: 2, Black: 3, Status: GameFinished, Winner: "w"},
+			{Round: 2, White: 1, Black: 3, Status: GameFinished, Winner: "w"},
+			{Round: 2, White: 2, Black: 0, Status: GameFinished, Winner: "r"},
+		},
+	}
+	// Points: a 1.5, b 1, c 1.5, d 0
+	standings := state.Standings()
+	byName := make(map[string]Standing)
+	for _, s := range standings {
+		byName[s.Participant] = s
+	}
+	if a := byName["a"]; a.Buchholz != 2.5 || a.SonnebornBerger != 1.75 {
+		t.Errorf("unexpected tiebreaks of a: %+v", a)
+	}
+	if c := byName["c"]; c.Buchholz != 1.5 || c.SonnebornBerger != 0.75 {
+		t.Errorf("unexpected tiebreaks of c: %+v", c)
+	}
+	if standings[0].Participant != "a" || standings[1].Participant != "c" || standings[1].Rank != 2 {
+		t.Errorf("Buchholz should break the tie: %+v", standings)
+	}
+	if after := state.StandingsAfter(1); after[0].Points != 1 || after[0].Rank != 1 || after[1].Rank != 1 {
+		t.Errorf("unexpected standings after round 1: %+v", after)
+	}
+}
+
+func TestSPRT(t *testing.T) {
+	if _, err := New(Spec{Format: SPRT, Participants: participants("a", "b", "c")}, ""); err == nil {
+		t.Error("an sprt match with three participants should fail")
+	}
+	if _, err := New(Spec{Format: RoundRobin, Participants: participants("a", "b"), SPRT: &sprt.DefaultBounds}, ""); err == nil {
+		t.Error("sprt bounds in a round robin should fail")
+	}
+
+	tour, err := New(Spec{Format: SPRT, Participants: participants("new", "old"), Concurrency: 2}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := tour.State(); state.Spec.SPRT == nil || state.Spec.Rounds != defaultSPRTPairs || len(state.Games) != 2 {
+		t.Fatalf("unexpected defaults %+v", state.Spec)
+	}
+
+	// The tested participant wins the first game of each pair and draws the second
+	tour.Run(func(ctx context.Context, tr *Tournament, game Game) (Result, error) {
+		if game.Index%2 == 1 {
+			return Result{Winner: "r"}, nil
+		}
+		return Result{Winner: "w"}, nil
+	})
+
+	state := tour.State()
+	result := state.SPRT()
+	if state.Status != StatusFinished || result.Decision != sprt.AcceptH1 {
+		t.Fatalf("expected H1, got %s with %+v", state.Status, result)
+	}
+	pairs := result.Pentanomial.Pairs()
+	if result.Pentanomial[3] != pairs || pairs >= defaultSPRTPairs || len(state.Games) > 2*pairs+2 {
+		t.Errorf("expected only 1.5 point pairs and an early stop, got %v after %d games", result.Pentanomial, len(state.Games))
+	}
+	for _, game := range state.Games {
+		if want := (game.Index%2 == 1) == (game.White == 1); !want {
+			t.Errorf("colors are not swapped within the pair: %+v", game)
+		}
+	}
+}
+
+func TestOpeningPairings(t *testing.T) {
+	if _, err := New(Spec{Format: Gauntlet, Participants: participants("a", "b"), GamesPerPairing: 3, Openings: "eco"}, ""); err == nil {
+		t.Error("expected an error for an odd number of games per pairing")
+	}
+
+	// Swiss tournaments play each opening with both colors as well
+	tour, err := New(Spec{Format: Swiss, Participants: participants("a", "b", "c", "d"), Openings: "eco"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := tour.State()
+	if state.Spec.GamesPerPairing != 2 || len(state.Games) != 4 {
+		t.Fatalf("expected two pairings of two games, got %+v", state.Games)
+	}
+	for i := 0; i < len(state.Games); i += 2 {
+		first, second := state.Games[i], state.Games[i+1]
+		if state.Spec.Pairing(&first) != i/2 || state.Spec.Pairing(&second) != i/2 {
+			t.Errorf("games %d and %d should belong to pairing %d", i, i+1, i/2)
+		}
+		if first.White != second.Black || first.Black != second.White {
+			t.Errorf("colors are not swapped within the pairing: %+v %+v", first, second)
+		}
+	}
+}
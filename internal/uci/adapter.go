@@ -0,0 +1,112 @@
+/*
+UCI engines as engine.Engine, so they can be seated like built-in engines.
+*/
+package uci
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/matetirpak/chessbot-playground-server/internal/engine"
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+)
+
+// Player runs a UCI engine executable. The process is started on the
+// first move and runs until Close.
+type Player struct {
+	Path string
+	Args []string
+
+	mu      sync.Mutex
+	process *Engine
+}
+
+func NewPlayer(path string, args ...string) *Player {
+	return &Player{Path: path, Args: args}
+}
+
+// Asks the engine for a move without the history of the position.
+func (p *Player) BestMove(ctx context.Context, pos gl.BoardState, limits engine.Limits) (gl.Move, error) {
+	return p.BestMoveInGame(ctx, []gl.BoardState{pos}, limits)
+}
+
+// Asks the engine for a move. The engine gets the start position of the
+// game with the moves played since, and its search is restricted to the
+// moves the game logic accepts.
+func (p *Player) BestMoveInGame(ctx context.Context, positions []gl.BoardState, limits engine.Limits) (gl.Move, error) {
+	pos := positions[len(positions)-1]
+	moves, err := movesOf(positions)
+	if err != nil {
+		return gl.Move{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.process == nil {
+		process, err := Start(ctx, p.Path, p.Args...)
+		if err != nil {
+			return gl.Move{}, err
+		}
+		if err := process.NewGame(ctx); err != nil {
+			process.Close()
+			return gl.Move{}, err
+		}
+		p.process = process
+	}
+
+	legal, err := engine.LegalMoves(&pos)
+	if err != nil {
+		return gl.Move{}, err
+	}
+	if len(legal) == 0 {
+		return gl.Move{}, engine.ErrNoLegalMoves
+	}
+	searchMoves := make([]string, len(legal))
+	for i := range legal {
+		searchMoves[i] = MoveToUCI(&legal[i])
+	}
+
+	if err := p.process.Position(gl.ToFEN(&positions[0], 1), moves); err != nil {
+		return gl.Move{}, err
+	}
+	params := GoParams{MoveTime: limits.MoveTime, SearchMoves: searchMoves}
+	if params.MoveTime <= 0 {
+		params.Depth = limits.Depth
+	}
+	result, err := p.process.Go(ctx, params)
+	if err != nil {
+		return gl.Move{}, err
+	}
+	return MoveFromUCI(result.BestMove, rune(pos.TurnColor[0]))
+}
+
+// Returns the moves between the positions in UCI notation.
+func movesOf(positions []gl.BoardState) ([]string, error) {
+	var moves []string
+	for i := 1; i < len(positions); i++ {
+		color := positions[i-1].TurnColor
+		if color != "w" && color != "b" {
+			return nil, fmt.Errorf("position %d has no side to move", i-1)
+		}
+		move, err := gl.StringToMoveStruct(positions[i].LastMove, rune(color[0]))
+		if err != nil {
+			return nil, fmt.Errorf("parsing move %q: %w", positions[i].LastMove, err)
+		}
+		moves = append(moves, MoveToUCI(&move))
+	}
+	return moves, nil
+}
+
+// Stops the engine process.
+func (p *Player) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.process == nil {
+		return nil
+	}
+	err := p.process.Close()
+	p.process = nil
+	return err
+}
@@ -0,0 +1,68 @@
+/*
+Parsing of UCI "info" lines.
+*/
+package uci
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Search information reported by an engine.
+type Info struct {
+	Depth    int
+	SelDepth int
+	Nodes    int64
+	NPS      int64
+	Time     int64 // Milliseconds
+	HasScore bool
+	ScoreCP  int // Centipawns from the engine's point of view
+	Mate     int // Moves to mate if IsMate, negative if the engine gets mated
+	IsMate   bool
+	PV       []string
+	String   string // Free text after "string"
+}
+
+// Parses an "info ..." line. Unknown fields are skipped.
+func ParseInfo(line string) Info {
+	var info Info
+	fields := strings.Fields(line)
+	for i := 1; i < len(fields); i++ {
+		next := func() string {
+			if i+1 < len(fields) {
+				i++
+				return fields[i]
+			}
+			return ""
+		}
+		switch fields[i] {
+		case "depth":
+			info.Depth, _ = strconv.Atoi(next())
+		case "seldepth":
+			info.SelDepth, _ = strconv.Atoi(next())
+		case "nodes":
+			info.Nodes, _ = strconv.ParseInt(next(), 10, 64)
+		case "nps":
+			info.NPS, _ = strconv.ParseInt(next(), 10, 64)
+		case "time":
+			info.Time, _ = strconv.ParseInt(next(), 10, 64)
+		case "score":
+			switch next() {
+			case "cp":
+				info.ScoreCP, _ = strconv.Atoi(next())
+				info.HasScore = true
+			case "mate":
+				info.Mate, _ = strconv.Atoi(next())
+				info.IsMate = true
+				info.HasScore = true
+			}
+		case "pv":
+			info.PV = append([]string(nil), fields[i+1:]...)
+			return info
+		case "string":
+			info.String = strings.Join(fields[i+1:], " ")
+			return info
+		}
+	}
+	return info
+}
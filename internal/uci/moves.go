@@ -0,0 +1,44 @@
+/*
+Conversion between UCI moves and game_logic moves.
+*/
+package uci
+
+import (
+	"fmt"
+	"strings"
+
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+)
+
+// Returns the move in UCI notation, e.g. "e2e4" or "e7e8n".
+// game_logic uses 'k' for knights, UCI uses 'n'.
+func MoveToUCI(move *gl.Move) string {
+	uci := strings.Replace(gl.MoveToString(&gl.Move{From: move.From, To: move.To}), " ", "", 1)
+	switch move.Promotion {
+	case 0:
+	case 'k':
+		uci += "n"
+	default:
+		uci += string(move.Promotion)
+	}
+	return uci
+}
+
+// Converts a UCI move of the given color ('w' or 'b') to a game_logic move.
+func MoveFromUCI(uci string, color rune) (gl.Move, error) {
+	if len(uci) != 4 && len(uci) != 5 {
+		return gl.Move{}, fmt.Errorf("%w: UCI move %q", gl.ErrInvalidFormat, uci)
+	}
+	moveStr := uci[:2] + " " + uci[2:4]
+	if len(uci) == 5 {
+		switch uci[4] {
+		case 'n':
+			moveStr += "k"
+		case 'q', 'r', 'b':
+			moveStr += string(uci[4])
+		default:
+			return gl.Move{}, fmt.Errorf("%w: unknown promotion in UCI move %q", gl.ErrInvalidFormat, uci)
+		}
+	}
+	return gl.StringToMoveStruct(moveStr, color)
+}
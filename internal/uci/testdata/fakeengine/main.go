@@ -0,0 +1,82 @@
+/*
+Minimal UCI engine for tests. It plays the first of the "searchmoves",
+or e2e4 without them, after the delay set by the "Delay" option. With
+FAKE_ENGINE_POSITIONS set, "position" commands are appended to that file.
+*/
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func main() {
+	delay := time.Duration(0)
+	var stop, done chan struct{}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "uci":
+			fmt.Println("id name FakeEngine")
+			fmt.Println("id author chessbot")
+			fmt.Println("option name Delay type spin default 0 min 0 max 60000")
+			fmt.Println("uciok")
+		case "isready":
+			fmt.Println("readyok")
+		case "position":
+			if path := os.Getenv("FAKE_ENGINE_POSITIONS"); path != "" {
+				f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+				if err == nil {
+					fmt.Fprintln(f, scanner.Text())
+					f.Close()
+				}
+			}
+		case "setoption":
+			if len(fields) == 5 && fields[2] == "Delay" {
+				ms, _ := strconv.Atoi(fields[4])
+				delay = time.Duration(ms) * time.Millisecond
+			}
+		case "go":
+			move := "e2e4"
+			for i, field := range fields {
+				if field == "searchmoves" && i+1 < len(fields) {
+					move = fields[i+1]
+				}
+			}
+			stop = make(chan struct{})
+			done = make(chan struct{})
+			go search(move, delay, stop, done)
+		case "stop":
+			if done == nil {
+				continue
+			}
+			select {
+			case <-done:
+			default:
+				close(stop)
+				<-done
+			}
+		case "quit":
+			return
+		}
+	}
+}
+
+func search(move string, delay time.Duration, stop, done chan struct{}) {
+	defer close(done)
+	fmt.Printf("info depth 1 seldepth 2 score cp 13 nodes 42 nps 1000 time 1 pv %s\n", move)
+	select {
+	case <-time.After(delay):
+	case <-stop:
+	}
+	fmt.Printf("bestmove %s\n", move)
+}
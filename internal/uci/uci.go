@@ -0,0 +1,257 @@
+/*
+Client for engines speaking the Universal Chess Interface (UCI) over
+stdin and stdout.
+*/
+package uci
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Time an engine gets to answer "uci" and "isready".
+var HandshakeTimeout = 10 * time.Second
+
+var ErrEngineExited = errors.New("engine exited")
+
+// Engine is a running UCI engine process.
+type Engine struct {
+	Name   string // From "id name"
+	Author string // From "id author"
+
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	lines    chan string
+	quit     chan struct{} // Closed by Close, stops the reader
+	quitOnce sync.Once
+
+	mu sync.Mutex // Serializes commands
+}
+
+// Search parameters of a "go" command. Zero values are omitted.
+type GoParams struct {
+	MoveTime    time.Duration
+	Depth       int
+	Nodes       int64
+	SearchMoves []string // Restrict the search to these UCI moves
+}
+
+// Result of a search.
+type SearchResult struct {
+	BestMove string
+	Ponder   string
+	Info     Info // Last info line with a score
+}
+
+// Launches the engine executable and performs the UCI handshake.
+func Start(ctx context.Context, path string, args ...string) (*Engine, error) {
+	cmd := exec.Command(path, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting engine %s: %w", path, err)
+	}
+
+	e := &Engine{cmd: cmd, stdin: stdin, lines: make(chan string, 64), quit: make(chan struct{})}
+	go func() {
+		defer close(e.lines)
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			select {
+			case e.lines <- strings.TrimSpace(scanner.Text()):
+			case <-e.quit:
+				return
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, HandshakeTimeout)
+	defer cancel()
+	if err := e.send("uci"); err != nil {
+		e.Close()
+		return nil, err
+	}
+	err = e.readUntil(ctx, func(line string) bool {
+		switch {
+		case strings.HasPrefix(line, "id name "):
+			e.Name = strings.TrimPrefix(line, "id name ")
+		case strings.HasPrefix(line, "id author "):
+			e.Author = strings.TrimPrefix(line, "id author ")
+		}
+		return line == "uciok"
+	})
+	if err != nil {
+		e.Close()
+		return nil, fmt.Errorf("uci handshake with %s: %w", path, err)
+	}
+	return e, nil
+}
+
+// Sets an engine option.
+func (e *Engine) SetOption(name, value string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.send("setoption name " + name + " value " + value)
+}
+
+// Waits until the engine is ready for the next command.
+func (e *Engine) IsReady(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.isReady(ctx)
+}
+
+// Tells the engine that the next search belongs to another game.
+func (e *Engine) NewGame(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.send("ucinewgame"); err != nil {
+		return err
+	}
+	return e.isReady(ctx)
+}
+
+// Sets the position to search. An empty fen means the start position,
+// moves are in UCI notation, e.g. "e2e4".
+func (e *Engine) Position(fen string, moves []string) error {
+	cmd := "position startpos"
+	if fen != "" {
+		cmd = "position fen " + fen
+	}
+	if len(moves) > 0 {
+		cmd += " moves " + strings.Join(moves, " ")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.send(cmd)
+}
+
+// Searches the current position until the engine reports its best move.
+// If the context is done first, the search is stopped and the engine's
+// answer to "stop" is returned.
+func (e *Engine) Go(ctx context.Context, params GoParams) (SearchResult, error) {
+	cmd := "go"
+	if params.MoveTime > 0 {
+		cmd += " movetime " + strconv.FormatInt(params.MoveTime.Milliseconds(), 10)
+	}
+	if params.Depth > 0 {
+		cmd += " depth " + strconv.Itoa(params.Depth)
+	}
+	if params.Nodes > 0 {
+		cmd += " nodes " + strconv.FormatInt(params.Nodes, 10)
+	}
+	if len(params.SearchMoves) > 0 {
+		cmd += " searchmoves " + strings.Join(params.SearchMoves, " ")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.send(cmd); err != nil {
+		return SearchResult{}, err
+	}
+
+	var result SearchResult
+	handle := func(line string) bool {
+		switch {
+		case strings.HasPrefix(line, "info "):
+			if info := ParseInfo(line); info.HasScore {
+				result.Info = info
+			}
+		case strings.HasPrefix(line, "bestmove"):
+			fields := strings.Fields(line)
+			if len(fields) > 1 {
+				result.BestMove = fields[1]
+			}
+			if len(fields) > 3 && fields[2] == "ponder" {
+				result.Ponder = fields[3]
+			}
+			return true
+		}
+		return false
+	}
+
+	err := e.readUntil(ctx, handle)
+	if err != nil && ctx.Err() != nil {
+		// Collect the best move found so far
+		if err := e.send("stop"); err != nil {
+			return SearchResult{}, err
+		}
+		stopCtx, cancel := context.WithTimeout(context.Background(), HandshakeTimeout)
+		defer cancel()
+		err = e.readUntil(stopCtx, handle)
+	}
+	if err != nil {
+		return SearchResult{}, err
+	}
+	if result.BestMove == "" || result.BestMove == "(none)" || result.BestMove == "0000" {
+		return result, errors.New("engine returned no move")
+	}
+	return result, nil
+}
+
+// Quits the engine, killing it if it doesn't exit in time.
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.quitOnce.Do(func() { close(e.quit) })
+	e.send("quit")
+	e.stdin.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- e.cmd.Wait() }()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		e.cmd.Process.Kill()
+		return <-done
+	}
+}
+
+func (e *Engine) isReady(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, HandshakeTimeout)
+	defer cancel()
+	if err := e.send("isready"); err != nil {
+		return err
+	}
+	return e.readUntil(ctx, func(line string) bool { return line == "readyok" })
+}
+
+func (e *Engine) send(cmd string) error {
+	_, err := io.WriteString(e.stdin, cmd+"\n")
+	return err
+}
+
+// Reads lines until handle returns true.
+func (e *Engine) readUntil(ctx context.Context, handle func(line string) bool) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case line, ok := <-e.lines:
+			if !ok {
+				return ErrEngineExited
+			}
+			if handle(line) {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Unittest for the uci package.
+*/
+package uci
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/matetirpak/chessbot-playground-server/internal/engine"
+	gl "github.com/matetirpak/chessbot-playground-server/internal/game_logic"
+)
+
+// Path of the fake engine built from testdata/fakeengine.
+var fakeEngine string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "uci-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fakeEngine = filepath.Join(dir, "fakeengine")
+	if runtime.GOOS == "windows" {
+		fakeEngine += ".exe"
+	}
+	goBin := filepath.Join(runtime.GOROOT(), "bin", "go")
+	build := exec.Command(goBin, "build", "-o", fakeEngine, "./testdata/fakeengine")
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintln(os.Stderr, "building fake engine:", err)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func startPosition() gl.BoardState {
+	var boards []gl.BoardState
+	gl.InitializeBoard(&boards)
+	boards[0].TurnColor = "w"
+	return boards[0]
+}
+
+func TestHandshakeAndSearch(t *testing.T) {
+	ctx := context.Background()
+	e, err := Start(ctx, fakeEngine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	if e.Name != "FakeEngine" || e.Author != "chessbot" {
+		t.Errorf("unexpected engine id %q by %q", e.Name, e.Author)
+	}
+	if err := e.NewGame(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Position("", []string{"e2e4", "e7e5"}); err != nil {
+		t.Fatal(err)
+	}
+	result, err := e.Go(ctx, GoParams{MoveTime: 100 * time.Millisecond, SearchMoves: []string{"g1f3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.BestMove != "g1f3" {
+		t.Errorf("expected bestmove g1f3, got %q", result.BestMove)
+	}
+	if result.Info.Depth != 1 || result.Info.ScoreCP != 13 || result.Info.Nodes != 42 {
+		t.Errorf("unexpected info %+v", result.Info)
+	}
+}
+
+func TestGoStopsOnCancel(t *testing.T) {
+	e, err := Start(context.Background(), fakeEngine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	if err := e.SetOption("Delay", "10000"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	result, err := e.Go(ctx, GoParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.BestMove != "e2e4" {
+		t.Errorf("expected bestmove e2e4 after stop, got %q", result.BestMove)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("stop took %v", elapsed)
+	}
+}
+
+func TestStartFails(t *testing.T) {
+	if _, err := Start(context.Background(), filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing executable")
+	}
+}
+
+func TestParseInfo(t *testing.T) {
+	info := ParseInfo("info depth 12 seldepth 18 multipv 1 score mate -3 nodes 123456 nps 999 time 77 pv e2e4 e7e5 g1f3")
+	if info.Depth != 12 || info.SelDepth != 18 || info.Nodes != 123456 || info.NPS != 999 || info.Time != 77 {
+		t.Errorf("unexpected numbers %+v", info)
+	}
+	if !info.HasScore || !info.IsMate || info.Mate != -3 {
+		t.Errorf("unexpected score %+v", info)
+	}
+	if len(info.PV) != 3 || info.PV[2] != "g1f3" {
+		t.Errorf("unexpected pv %v", info.PV)
+	}
+
+	info = ParseInfo("info string NNUE evaluation enabled")
+	if info.HasScore || info.String != "NNUE evaluation enabled" {
+		t.Errorf("unexpected string info %+v", info)
+	}
+}
+
+func TestMoveConversion(t *testing.T) {
+	move, err := MoveFromUCI("e7e8n", 'w')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if move.From != [2]int{1, 4} || move.To != [2]int{0, 4} || move.Promotion != 'k' {
+		t.Errorf("unexpected move %+v", move)
+	}
+	if uci := MoveToUCI(&move); uci != "e7e8n" {
+		t.Errorf("expected e7e8n, got %q", uci)
+	}
+	if _, err := MoveFromUCI("e7e8x", 'w'); err == nil {
+		t.Error("expected error for invalid promotion")
+	}
+}
+
+func TestPlayerAsEngine(t *testing.T) {
+	var eng engine.Engine = NewPlayer(fakeEngine)
+	defer eng.(*Player).Close()
+
+	pos := startPosition()
+	move, err := eng.BestMove(context.Background(), pos, engine.Limits{MoveTime: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fake engine plays the first legal move offered in searchmoves
+	if err := gl.ValidateMove(&move, &pos); err != nil {
+		t.Errorf("engine played illegal move %q: %v", gl.MoveToString(&move), err)
+	}
+}
+
+func TestPlayerSendsHistory(t *testing.T) {
+	positionsLog := filepath.Join(t.TempDir(), "positions")
+	t.Setenv("FAKE_ENGINE_POSITIONS", positionsLog)
+	var eng engine.Engine = NewPlayer(fakeEngine)
+	defer eng.(*Player).Close()
+
+	positions := []gl.BoardState{startPosition()}
+	for _, uci := range []string{"g1f3", "g8f6", "f3g1"} {
+		color := rune(positions[len(positions)-1].TurnColor[0])
+		move, err := MoveFromUCI(uci, color)
+		if err != nil {
+			t.Fatal(err)
+		}
+		next, err := gl.MakeMove(&move, positions[len(positions)-1], true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		positions = append(positions, next)
+	}
+
+	history, ok := eng.(engine.HistoryEngine)
+	if !ok {
+		t.Fatal("UCI player doesn't take the game history")
+	}
+	if _, err := history.BestMoveInGame(context.Background(), positions, engine.Limits{MoveTime: 50 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	logged, err := os.ReadFile(positionsLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "position fen " + gl.ToFEN(&positions[0], 1) + " moves g1f3 g8f6 f3g1\n"
+	if string(logged) != want {
+		t.Errorf("expected %q, got %q", want, logged)
+	}
+}
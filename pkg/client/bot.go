@@ -0,0 +1,48 @@
+/*
+Game loop for bots built on the client.
+*/
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// A Bot chooses moves. It is asked for a move whenever it's its turn,
+// state.TurnColor is the bot's color.
+type Bot interface {
+	Move(ctx context.Context, state BoardState) (string, error)
+}
+
+// Adapts a function to the Bot interface.
+type BotFunc func(ctx context.Context, state BoardState) (string, error)
+
+func (f BotFunc) Move(ctx context.Context, state BoardState) (string, error) {
+	return f(ctx, state)
+}
+
+// Plays the game of the player with the given bot until the game ends or
+// the context is done. Returns the final position.
+func Run(ctx context.Context, c *Client, player *Player, bot Bot) (BoardState, error) {
+	for {
+		err := c.WaitForTurn(ctx, player)
+		if ErrorCode(err) == "game_over" {
+			return c.State(ctx, player, -1)
+		}
+		if err != nil {
+			return BoardState{}, fmt.Errorf("waiting for turn: %w", err)
+		}
+
+		state, err := c.State(ctx, player, -1)
+		if err != nil {
+			return BoardState{}, fmt.Errorf("fetching state: %w", err)
+		}
+		move, err := bot.Move(ctx, state)
+		if err != nil {
+			return state, fmt.Errorf("bot failed to choose a move: %w", err)
+		}
+		if err := c.Move(ctx, player, move); err != nil {
+			return state, fmt.Errorf("playing %q: %w", move, err)
+		}
+	}
+}
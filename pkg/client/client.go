@@ -0,0 +1,328 @@
+/*
+Typed Go client for the v1 API of the chessbot playground server and the
+account administration of the v2 API.
+*/
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client talks to a server at BaseURL, e.g. "http://localhost:8080".
+// With APIKey set, the client joins games as the account of the key.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	APIKey     string
+}
+
+// Returns a client using http.DefaultClient.
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Access data of a session, returned on creation.
+type Session struct {
+	BoardID  int32  `json:"boardid"`
+	Password string `json:"password"`
+}
+
+// Entry of the session list.
+type SessionInfo struct {
+	Name    string `json:"name"`
+	BoardID int32  `json:"boardid"`
+}
+
+// A player registered in a session. Requests of the player carry the
+// token; the session password is kept to manage the session.
+type Player struct {
+	BoardID  int32
+	Color    string // "w" or "b"
+	Token    string
+	Password string
+}
+
+// Position of a game as returned by the server.
+// Board rows start at rank 8, white pieces are lowercase, black pieces
+// uppercase: p pawn, k knight, b bishop, r rook, q queen, x king.
+type BoardState struct {
+	Board          [8][8]rune `json:"board"`
+	LastMove       string     `json:"lastmove"`
+	WhiteKingPos   [2]int     `json:"whitekingpos"`
+	BlackKingPos   [2]int     `json:"blackkingpos"`
+	WhiteKingMoved bool       `json:"whitekingmoved"`
+	BlackKingMoved bool       `json:"blackkingmoved"`
+	Winner         string     `json:"winner"`    // "n" while ongoing, "w", "b" or "r" for remis
+	TurnColor      string     `json:"turncolor"` // "w", "b" or "n" if nobody has to move
+	EnPassant      [2]int     `json:"enpassant"`
+}
+
+// Legal move of a piece. Squares are [row, col] like BoardState.Board.
+type Move struct {
+	From      [2]int `json:"from"`
+	To        [2]int `json:"to"`
+	Capture   bool   `json:"capture"`
+	Promotion string `json:"promotion,omitempty"`
+}
+
+// Returns the move in the notation accepted by Client.Move, e.g. "e2 e4".
+func (m Move) String() string {
+	square := func(pos [2]int) string {
+		return string(rune('a'+pos[1])) + string(rune('1'+(7-pos[0])))
+	}
+	return square(m.From) + " " + square(m.To) + m.Promotion
+}
+
+// Account of the server. Key is only set on creation.
+type Account struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdat"`
+	Key       string    `json:"key,omitempty"`
+}
+
+// Error response of the server.
+type APIError struct {
+	StatusCode int
+	Code       string         `json:"code"`
+	Message    string         `json:"message"`
+	Details    map[string]any `json:"details,omitempty"`
+	RetryAfter time.Duration  `json:"-"` // Set on 503 responses
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s (%d %s)", e.Message, e.StatusCode, e.Code)
+}
+
+// Returns the API error code of err, or "" if err is no *APIError.
+func ErrorCode(err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return ""
+}
+
+// Lists all sessions.
+func (c *Client) ListSessions(ctx context.Context) ([]SessionInfo, error) {
+	var resp struct {
+		Games []SessionInfo `json:"games"`
+	}
+	err := c.do(ctx, http.MethodGet, "/chessserver/v1/sessions", "", nil, &resp)
+	return resp.Games, err
+}
+
+// Creates a session with the given name.
+func (c *Client) CreateSession(ctx context.Context, name string) (Session, error) {
+	var session Session
+	err := c.do(ctx, http.MethodPost, "/chessserver/v1/sessions", "", map[string]any{"name": name}, &session)
+	return session, err
+}
+
+// Deletes a session.
+func (c *Client) DeleteSession(ctx context.Context, session Session) error {
+	return c.do(ctx, http.MethodDelete, "/chessserver/v1/sessions", session.Password, map[string]any{"boardid": session.BoardID}, nil)
+}
+
+// Registers as the player of the given color ("w" or "b").
+func (c *Client) Join(ctx context.Context, session Session, color string) (*Player, error) {
+	return c.JoinAs(ctx, session, color, "")
+}
+
+// Registers as the player of the given color under a player name. Rated
+// sessions need an API key, the player name may then be empty and
+// defaults to the account.
+func (c *Client) JoinAs(ctx context.Context, session Session, color string, player string) (*Player, error) {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	req := map[string]any{"boardid": session.BoardID, "color": color}
+	if player != "" {
+		req["player"] = player
+	}
+	err := c.do(ctx, http.MethodPut, "/chessserver/v1/sessions", session.Password, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &Player{BoardID: session.BoardID, Color: color, Token: resp.Token, Password: session.Password}, nil
+}
+
+// Blocks until it's the player's turn. Server side timeouts and restarts
+// are retried until the context is done. Returns an *APIError with code
+// "game_over" once the game has ended.
+func (c *Client) WaitForTurn(ctx context.Context, player *Player) error {
+	path := "/chessserver/v1/game?" + player.query("turn").Encode()
+	for {
+		err := c.do(ctx, http.MethodGet, path, player.Token, nil, nil)
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			return err
+		}
+
+		switch apiErr.Code {
+		case "turn_timeout":
+			continue
+		case "server_restarting":
+			wait := apiErr.RetryAfter
+			if wait <= 0 {
+				wait = time.Second
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		default:
+			return err
+		}
+	}
+}
+
+// Returns the position after moveIdx moves, -1 for the latest position.
+func (c *Client) State(ctx context.Context, player *Player, moveIdx int) (BoardState, error) {
+	query := player.query("state")
+	query.Set("moveidx", strconv.Itoa(moveIdx))
+
+	var bstate BoardState
+	err := c.do(ctx, http.MethodGet, "/chessserver/v1/game?"+query.Encode(), player.Token, nil, &bstate)
+	return bstate, err
+}
+
+// Returns the legal moves of the piece at [row, col].
+func (c *Client) Moves(ctx context.Context, player *Player, row, col int) ([]Move, error) {
+	query := player.query("moves")
+	query.Set("row", strconv.Itoa(row))
+	query.Set("col", strconv.Itoa(col))
+
+	var moves []Move
+	err := c.do(ctx, http.MethodGet, "/chessserver/v1/game?"+query.Encode(), player.Token, nil, &moves)
+	return moves, err
+}
+
+// Plays a move, e.g. "e2 e4" or "e7 e8q".
+func (c *Client) Move(ctx context.Context, player *Player, move string) error {
+	return c.putGame(ctx, player, "move", move)
+}
+
+// Lets the server play a random legal move.
+func (c *Client) RandomMove(ctx context.Context, player *Player) error {
+	return c.putGame(ctx, player, "randommove", "")
+}
+
+// Gives up the game.
+func (c *Client) Forfeit(ctx context.Context, player *Player) error {
+	return c.putGame(ctx, player, "forfeit", "")
+}
+
+// Creates an account with the admin token of the server. The returned
+// account carries the API key, which can't be retrieved later.
+func (c *Client) CreateAccount(ctx context.Context, adminToken, name string) (Account, error) {
+	var account Account
+	err := c.do(ctx, http.MethodPost, "/chessserver/v2/accounts", adminToken, map[string]any{"name": name}, &account)
+	return account, err
+}
+
+// Lists all accounts with the admin token of the server.
+func (c *Client) ListAccounts(ctx context.Context, adminToken string) ([]Account, error) {
+	var resp struct {
+		Accounts []Account `json:"accounts"`
+	}
+	err := c.do(ctx, http.MethodGet, "/chessserver/v2/accounts", adminToken, nil, &resp)
+	return resp.Accounts, err
+}
+
+// Deletes an account with the admin token of the server.
+func (c *Client) DeleteAccount(ctx context.Context, adminToken, name string) error {
+	return c.do(ctx, http.MethodDelete, "/chessserver/v2/accounts/"+url.PathEscape(name), adminToken, nil, nil)
+}
+
+func (c *Client) putGame(ctx context.Context, player *Player, reqType, move string) error {
+	body := map[string]any{"boardid": player.BoardID, "color": player.Color, "reqtype": reqType}
+	if move != "" {
+		body["move"] = move
+	}
+	return c.do(ctx, http.MethodPut, "/chessserver/v1/game", player.Token, body, nil)
+}
+
+func (p *Player) query(reqType string) url.Values {
+	return url.Values{
+		"boardid": {strconv.Itoa(int(p.BoardID))},
+		"color":   {p.Color},
+		"reqtype": {reqType},
+	}
+}
+
+// Sends a request and decodes the JSON response into out, if given.
+// Responses other than 2xx are returned as *APIError.
+func (c *Client) do(ctx context.Context, method, path, token string, body any, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return parseError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response of %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+func parseError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	raw, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(raw, apiErr); err != nil || apiErr.Code == "" {
+		apiErr.Message = strings.TrimSpace(string(raw))
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
@@ -0,0 +1,233 @@
+/*
+Unittest for the client package.
+*/
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/matetirpak/chessbot-playground-server/internal/api"
+	"github.com/matetirpak/chessbot-playground-server/pkg/server"
+)
+
+func newTestClient(t *testing.T) *Client {
+	srv := httptest.NewServer(server.NewRouter(server.Options{}))
+	t.Cleanup(srv.Close)
+	return New(srv.URL)
+}
+
+// Creates a session and joins both players.
+func newTestGame(t *testing.T, c *Client) (Session, *Player, *Player) {
+	ctx := context.Background()
+	session, err := c.CreateSession(ctx, "test")
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	white, err := c.Join(ctx, session, "w")
+	if err != nil {
+		t.Fatalf("failed to join as white: %v", err)
+	}
+	black, err := c.Join(ctx, session, "b")
+	if err != nil {
+		t.Fatalf("failed to join as black: %v", err)
+	}
+	return session, white, black
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	session, white, _ := newTestGame(t, c)
+
+	sessions, err := c.ListSessions(ctx)
+	if err != nil {
+		t.Fatalf("failed to list sessions: %v", err)
+	}
+	found := false
+	for _, info := range sessions {
+		found = found || info.BoardID == session.BoardID
+	}
+	if !found {
+		t.Errorf("session %d not listed", session.BoardID)
+	}
+
+	if _, err := c.Join(ctx, session, "w"); ErrorCode(err) != "game_full" {
+		t.Errorf("expected game_full when joining a full game, got %v", err)
+	}
+
+	if err := c.DeleteSession(ctx, session); err != nil {
+		t.Fatalf("failed to delete session: %v", err)
+	}
+	_, err = c.State(ctx, white, -1)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 404 || apiErr.Code != "board_not_found" {
+		t.Errorf("expected 404 board_not_found after deletion, got %v", err)
+	}
+}
+
+func TestPlayMoves(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	_, white, black := newTestGame(t, c)
+
+	moves, err := c.Moves(ctx, white, 6, 4)
+	if err != nil {
+		t.Fatalf("failed to fetch moves: %v", err)
+	}
+	if len(moves) != 2 || moves[0].String() != "e2 e3" || moves[1].String() != "e2 e4" {
+		t.Errorf("unexpected moves of e2 pawn: %v", moves)
+	}
+
+	swapped := &Player{BoardID: white.BoardID, Color: "w", Token: black.Token}
+	if _, err := c.State(ctx, swapped, -1); ErrorCode(err) != "invalid_token" {
+		t.Errorf("expected invalid_token with the opponent's token, got %v", err)
+	}
+
+	if err := c.Move(ctx, white, "e2 e5"); ErrorCode(err) != "illegal_move" {
+		t.Errorf("expected illegal_move, got %v", err)
+	}
+	if err := c.Move(ctx, black, "e7 e5"); ErrorCode(err) != "not_your_turn" {
+		t.Errorf("expected not_your_turn, got %v", err)
+	}
+	if err := c.Move(ctx, white, "e2 e4"); err != nil {
+		t.Fatalf("failed to play move: %v", err)
+	}
+	if err := c.WaitForTurn(ctx, black); err != nil {
+		t.Fatalf("failed to wait for turn: %v", err)
+	}
+	if err := c.RandomMove(ctx, black); err != nil {
+		t.Fatalf("failed to play random move: %v", err)
+	}
+
+	state, err := c.State(ctx, white, 1)
+	if err != nil {
+		t.Fatalf("failed to fetch state: %v", err)
+	}
+	if state.LastMove != "e2 e4" || state.Board[4][4] != 'p' {
+		t.Errorf("unexpected state after e2 e4: last move %q", state.LastMove)
+	}
+
+	if err := c.Forfeit(ctx, white); err != nil {
+		t.Fatalf("failed to forfeit: %v", err)
+	}
+	state, err = c.State(ctx, black, -1)
+	if err != nil {
+		t.Fatalf("failed to fetch state: %v", err)
+	}
+	if state.Winner != "b" {
+		t.Errorf("expected black to win after forfeit, got %q", state.Winner)
+	}
+	if err := c.WaitForTurn(ctx, white); ErrorCode(err) != "game_over" {
+		t.Errorf("expected game_over when waiting in a finished game, got %v", err)
+	}
+}
+
+func TestAccounts(t *testing.T) {
+	api.AdminToken = "admin"
+	defer func() { api.AdminToken = "" }()
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	if _, err := c.CreateAccount(ctx, "wrong", "alice"); ErrorCode(err) != "invalid_admin_token" {
+		t.Errorf("expected invalid_admin_token, got %v", err)
+	}
+	account, err := c.CreateAccount(ctx, "admin", "alice")
+	if err != nil {
+		t.Fatalf("failed to create account: %v", err)
+	}
+	defer c.DeleteAccount(ctx, "admin", "alice")
+	if account.Name != "alice" || account.Key == "" {
+		t.Fatalf("unexpected account %+v", account)
+	}
+	accounts, err := c.ListAccounts(ctx, "admin")
+	if err != nil || len(accounts) != 1 || accounts[0].Key != "" {
+		t.Errorf("unexpected account list %+v %v", accounts, err)
+	}
+
+	session, err := c.CreateSession(ctx, "accounts")
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
+	if _, err := c.JoinAs(ctx, session, "w", "alice"); ErrorCode(err) != "player_reserved" {
+		t.Errorf("expected player_reserved when joining as alice without key, got %v", err)
+	}
+	keyed := New(c.BaseURL)
+	keyed.APIKey = account.Key
+	if _, err := keyed.JoinAs(ctx, session, "w", "bob"); ErrorCode(err) != "invalid_player" {
+		t.Errorf("expected invalid_player when joining with another name, got %v", err)
+	}
+	if _, err := keyed.Join(ctx, session, "w"); err != nil {
+		t.Errorf("failed to join with API key: %v", err)
+	}
+
+	if err := c.DeleteAccount(ctx, "admin", "alice"); err != nil {
+		t.Fatalf("failed to delete account: %v", err)
+	}
+	if _, err := keyed.Join(ctx, session, "b"); ErrorCode(err) != "invalid_api_key" {
+		t.Errorf("expected invalid_api_key after deletion, got %v", err)
+	}
+}
+
+func TestWaitForTurnCancel(t *testing.T) {
+	c := newTestClient(t)
+	_, _, black := newTestGame(t, c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	err := c.WaitForTurn(ctx, black)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+// Both bots play the fool's mate.
+func TestRun(t *testing.T) {
+	c := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, white, black := newTestGame(t, c)
+
+	scripted := func(moves ...string) Bot {
+		return BotFunc(func(ctx context.Context, state BoardState) (string, error) {
+			if len(moves) == 0 {
+				return "", errors.New("out of moves")
+			}
+			move := moves[0]
+			moves = moves[1:]
+			return move, nil
+		})
+	}
+
+	type result struct {
+		state BoardState
+		err   error
+	}
+	whiteDone := make(chan result, 1)
+	go func() {
+		state, err := Run(ctx, c, white, scripted("f2 f3", "g2 g4"))
+		whiteDone <- result{state, err}
+	}()
+
+	state, err := Run(ctx, c, black, scripted("e7 e5", "d8 h4"))
+	if err != nil {
+		t.Fatalf("black bot failed: %v", err)
+	}
+	if state.Winner != "b" {
+		t.Errorf("expected black to win, got %q", state.Winner)
+	}
+
+	res := <-whiteDone
+	if res.err != nil {
+		t.Fatalf("white bot failed: %v", res.err)
+	}
+	if res.state.Winner != "b" {
+		t.Errorf("expected white to see black winning, got %q", res.state.Winner)
+	}
+}
@@ -1,23 +1,77 @@
 package server
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/matetirpak/chessbot-playground-server/internal/logging"
+	"github.com/matetirpak/chessbot-playground-server/internal/metrics"
 )
 
+// Attaches a request scoped logger with the request ID to the context
+// and logs every handled request.
 func Logger(inner http.Handler, name string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		inner.ServeHTTP(w, r)
+		requestID := r.Header.Get(logging.RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.New().String()
+		}
+		w.Header().Set(logging.RequestIDHeader, requestID)
+
+		logger := slog.Default().With("requestid", requestID, "route", name)
+		ctx := logging.NewContext(r.Context(), logger)
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
 
-		log.Printf(
-			"%s %s %s %s",
-			r.Method,
-			r.RequestURI,
-			name,
-			time.Since(start),
+		inner.ServeHTTP(recorder, r.WithContext(ctx))
+
+		logging.FromContext(ctx).Info("request handled",
+			"method", r.Method,
+			"uri", r.RequestURI,
+			"status", recorder.status,
+			"duration", time.Since(start),
 		)
 	})
 }
+
+// Records request counts and latencies per route name.
+func Instrument(inner http.Handler, name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		inner.ServeHTTP(recorder, r)
+
+		metrics.HTTPRequests.WithLabelValues(name, r.Method, strconv.Itoa(recorder.status)).Inc()
+		metrics.HTTPDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+	})
+}
+
+// Remembers the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status = status
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -2,10 +2,12 @@ package server
 
 import (
 	"fmt"
+	"io/fs"
 	"net/http"
 	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
 
 	"github.com/matetirpak/chessbot-playground-server/internal/api"
@@ -20,16 +22,32 @@ type Route struct {
 
 type Routes []Route
 
-func NewRouter() *mux.Router {
+// Options controls which optional parts are mounted by NewRouter.
+type Options struct {
+	Swagger bool  // Serve the API documentation under /documentation/
+	Metrics bool  // Serve Prometheus metrics under /metrics
+	WebUI   fs.FS // If set, the web UI is served under / next to the API
+}
+
+func NewRouter(opts Options) *mux.Router {
 	router := mux.NewRouter().StrictSlash(true)
+	router.NotFoundHandler = http.HandlerFunc(api.NotFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(api.MethodNotAllowed)
 
 	// Swagger auto documentation
-	router.PathPrefix("/documentation/").Handler(httpSwagger.WrapHandler)
+	if opts.Swagger {
+		router.PathPrefix("/documentation/").Handler(httpSwagger.WrapHandler)
+	}
+
+	if opts.Metrics {
+		router.Methods("GET").Path("/metrics").Name("Metrics").Handler(promhttp.Handler())
+	}
 
 	for _, route := range routes {
 		var handler http.Handler
 		handler = route.HandlerFunc
 		handler = Logger(handler, route.Name)
+		handler = Instrument(handler, route.Name)
 
 		router.
 			Methods(route.Method).
@@ -38,14 +56,54 @@ func NewRouter() *mux.Router {
 			Handler(handler)
 	}
 
+	// Registered last and limited to paths outside the API, so that unknown
+	// API paths still get the JSON 404 and 405 errors
+	if opts.WebUI != nil {
+		router.MatcherFunc(notAPIPath).PathPrefix("/").Handler(WebUIHandler(opts.WebUI))
+	}
+
 	return router
 }
 
+// Path prefixes served by the API rather than the web UI.
+var apiPrefixes = []string{"/chessserver/", "/healthz", "/readyz", "/metrics", "/documentation/"}
+
+func notAPIPath(r *http.Request, _ *mux.RouteMatch) bool {
+	for _, prefix := range apiPrefixes {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
 func Index(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Hello World!")
 }
 
 var routes = Routes{
+	// Health
+	Route{
+		"GetHealthz",
+		strings.ToUpper("Get"),
+		"/healthz",
+		api.GetHealthz,
+	},
+
+	Route{
+		"GetReadyz",
+		strings.ToUpper("Get"),
+		"/readyz",
+		api.GetReadyz,
+	},
+
+	Route{
+		"GetInfo",
+		strings.ToUpper("Get"),
+		"/chessserver/v1/info",
+		api.GetInfo,
+	},
+
 	// Sessions
 	Route{
 		"DeleteSessions",
@@ -89,4 +147,208 @@ var routes = Routes{
 		"/chessserver/v1/game",
 		api.PutGame,
 	},
+
+	// v2
+	Route{
+		"GetGamesV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/games",
+		api.GetGamesV2,
+	},
+
+	Route{
+		"PostGamesV2",
+		strings.ToUpper("Post"),
+		"/chessserver/v2/games",
+		api.PostGamesV2,
+	},
+
+	Route{
+		"GetGameV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/games/{id}",
+		api.GetGameV2,
+	},
+
+	Route{
+		"DeleteGameV2",
+		strings.ToUpper("Delete"),
+		"/chessserver/v2/games/{id}",
+		api.DeleteGameV2,
+	},
+
+	Route{
+		"PutPlayerV2",
+		strings.ToUpper("Put"),
+		"/chessserver/v2/games/{id}/players/{color}",
+		api.PutPlayerV2,
+	},
+
+	Route{
+		"DeletePlayerV2",
+		strings.ToUpper("Delete"),
+		"/chessserver/v2/games/{id}/players/{color}",
+		api.DeletePlayerV2,
+	},
+
+	Route{
+		"PostPlayerTokenV2",
+		strings.ToUpper("Post"),
+		"/chessserver/v2/games/{id}/players/{color}/token",
+		api.PostPlayerTokenV2,
+	},
+
+	Route{
+		"GetMovesV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/games/{id}/moves",
+		api.GetMovesV2,
+	},
+
+	Route{
+		"PostMovesV2",
+		strings.ToUpper("Post"),
+		"/chessserver/v2/games/{id}/moves",
+		api.PostMovesV2,
+	},
+
+	Route{
+		"GetPositionV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/games/{id}/positions/{ply}",
+		api.GetPositionV2,
+	},
+
+	Route{
+		"GetLegalMovesV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/games/{id}/legal-moves",
+		api.GetLegalMovesV2,
+	},
+
+	Route{
+		"GetBookMovesV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/games/{id}/book-moves",
+		api.GetBookMovesV2,
+	},
+
+	Route{
+		"GetTurnV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/games/{id}/turn",
+		api.GetTurnV2,
+	},
+
+	Route{
+		"PostMatchmakingV2",
+		strings.ToUpper("Post"),
+		"/chessserver/v2/matchmaking",
+		api.PostMatchmakingV2,
+	},
+
+	Route{
+		"GetMatchmakingTicketV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/matchmaking/{ticket}",
+		api.GetMatchmakingTicketV2,
+	},
+
+	Route{
+		"DeleteMatchmakingTicketV2",
+		strings.ToUpper("Delete"),
+		"/chessserver/v2/matchmaking/{ticket}",
+		api.DeleteMatchmakingTicketV2,
+	},
+
+	Route{
+		"GetAccountsV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/accounts",
+		api.GetAccountsV2,
+	},
+
+	Route{
+		"PostAccountsV2",
+		strings.ToUpper("Post"),
+		"/chessserver/v2/accounts",
+		api.PostAccountsV2,
+	},
+
+	Route{
+		"GetAccountV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/accounts/{name}",
+		api.GetAccountV2,
+	},
+
+	Route{
+		"DeleteAccountV2",
+		strings.ToUpper("Delete"),
+		"/chessserver/v2/accounts/{name}",
+		api.DeleteAccountV2,
+	},
+
+	Route{
+		"GetTournamentsV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/tournaments",
+		api.GetTournamentsV2,
+	},
+
+	Route{
+		"PostTournamentsV2",
+		strings.ToUpper("Post"),
+		"/chessserver/v2/tournaments",
+		api.PostTournamentsV2,
+	},
+
+	Route{
+		"GetTournamentV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/tournaments/{id}",
+		api.GetTournamentV2,
+	},
+
+	Route{
+		"DeleteTournamentV2",
+		strings.ToUpper("Delete"),
+		"/chessserver/v2/tournaments/{id}",
+		api.DeleteTournamentV2,
+	},
+
+	Route{
+		"GetTournamentGamesV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/tournaments/{id}/games",
+		api.GetTournamentGamesV2,
+	},
+
+	Route{
+		"GetTournamentStandingsV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/tournaments/{id}/standings",
+		api.GetTournamentStandingsV2,
+	},
+
+	Route{
+		"GetTournamentCrosstableV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/tournaments/{id}/crosstable",
+		api.GetTournamentCrosstableV2,
+	},
+
+	Route{
+		"GetRatingsV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/ratings",
+		api.GetRatingsV2,
+	},
+
+	Route{
+		"GetRatingV2",
+		strings.ToUpper("Get"),
+		"/chessserver/v2/ratings/{player}",
+		api.GetRatingV2,
+	},
 }
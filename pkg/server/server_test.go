@@ -0,0 +1,40 @@
+/*
+Unittest for the server package.
+*/
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestWebUIDoesNotShadowAPI(t *testing.T) {
+	router := NewRouter(Options{WebUI: fstest.MapFS{"index.html": {Data: []byte("<html></html>")}}})
+
+	for _, tc := range []struct {
+		method, path string
+		status       int
+		code         string
+	}{
+		{"GET", "/", http.StatusOK, ""},
+		{"GET", "/chessserver/v2/unknown", http.StatusNotFound, "route_not_found"},
+		{"PATCH", "/chessserver/v2/games", http.StatusMethodNotAllowed, "method_not_allowed"},
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+		if rec.Code != tc.status {
+			t.Errorf("%s %s: expected status %d, got %d", tc.method, tc.path, tc.status, rec.Code)
+			continue
+		}
+		if tc.code == "" {
+			continue
+		}
+		var body struct{ Code string }
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Code != tc.code {
+			t.Errorf("%s %s: expected code %q, got %q (%v)", tc.method, tc.path, tc.code, rec.Body.String(), err)
+		}
+	}
+}
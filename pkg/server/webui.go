@@ -0,0 +1,20 @@
+package server
+
+import (
+	"io/fs"
+	"net/http"
+)
+
+// WebUIHandler serves the web UI files with the headers it needs.
+func WebUIHandler(webFS fs.FS) http.Handler {
+	fileServer := http.FileServer(http.FS(webFS))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Add necessary headers for SharedArrayBuffer support
+		w.Header().Set("Cross-Origin-Opener-Policy", "same-origin")
+		w.Header().Set("Cross-Origin-Embedder-Policy", "require-corp")
+
+		// Serve the requested file
+		fileServer.ServeHTTP(w, r)
+	})
+}